@@ -1,6 +1,6 @@
 module weatherdump
 
-go 1.12
+go 1.21
 
 require (
 	github.com/alecthomas/template v0.0.0-20160405071501-a0175ee3bccc // indirect
@@ -11,11 +11,11 @@ require (
 	github.com/gorilla/mux v1.7.0
 	github.com/gorilla/schema v1.1.0
 	github.com/gorilla/websocket v1.4.0
-	github.com/gosuri/uilive v0.0.0-20170323041506-ac356e6e42cd // indirect
+	github.com/gosuri/uilive v0.0.0-20170323041506-ac356e6e42cd
 	github.com/gosuri/uiprogress v0.0.0-20170224063937-d0567a9d84a1
 	github.com/luigifreitas/gofast v0.0.0-20190320204939-3582e734e6cd
 	github.com/luigifreitas/libsathelper v0.0.0-20190402034953-9cb2b576a312
-	github.com/mattn/go-colorable v0.1.1 // indirect
+	github.com/mattn/go-colorable v0.1.1
 	github.com/mattn/go-isatty v0.0.7 // indirect
 	github.com/nfnt/resize v0.0.0-20180221191011-83c6a9932646
 	github.com/stretchr/testify v1.3.0 // indirect
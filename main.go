@@ -2,10 +2,15 @@ package main
 
 import (
 	"fmt"
+	"log"
+	"strconv"
 	"time"
+	"weatherdump/src/handlers"
 	remoteHandler "weatherdump/src/handlers/remote"
 	terminalHandler "weatherdump/src/handlers/terminal"
 	"weatherdump/src/img"
+	"weatherdump/src/protocols/helpers"
+	"weatherdump/src/simulator"
 
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
 )
@@ -26,17 +31,61 @@ var (
 
 	exportPNG  = kingpin.Flag("png", "export pictures as PNG").Default("false").Bool()
 	exportJPEG = kingpin.Flag("jpeg", "export pictures as JPEG (disable: --no-jpeg)").Default("true").Bool()
+	exportTIFF = kingpin.Flag("geotiff", "export pictures as GeoTIFF, georeferenced with the spacecraft diary or the --tle orbit").Default("false").Bool()
 	equalize   = kingpin.Flag("equalize", "apply histogram equalization to output (disable: --no-equalize)").Short('e').Default("true").Bool()
 	invert     = kingpin.Flag("invert", "invert infrared pixels of output (disable: --no-invert)").Short('i').Default("true").Bool()
 	flop       = kingpin.Flag("flop", "apply horizonal flip to output").Short('f').Default("false").Bool()
+	autoRotate = kingpin.Flag("rotate", "rotate the products of northbound passes by 180° so they come out north-up (disable: --no-rotate)").Default("true").Bool()
+	fillBowTie = kingpin.Flag("bowtie", "fill the VIIRS rows deleted on-board by the bow-tie removal (disable: --no-bowtie)").Default("true").Bool()
+	fixBowTie  = kingpin.Flag("bowtie-correction", "resample the VIIRS detectors at their ground positions to correct the bow-tie distortion (needs the diary or --tle)").Default("false").Bool()
+	calibrate  = kingpin.Flag("calibrate", "calibrate VIIRS channels to reflectance and brightness temperature").Default("false").Bool()
+
+	dumpAPIDs = kingpin.Flag("dump", "save the raw packets of the APIDs to their own files (e.g. 11,800-823 or all)").Default("").String()
+	exportPDS = kingpin.Flag("pds", "save the JPSS instrument packets as NASA production data sets").Default("false").Bool()
+	crisIGM   = kingpin.Flag("cris", "save the CrIS interferograms of each band").Default("false").Bool()
+	crisRange = kingpin.Flag("cris-spectrum", "also save the CrIS spectra and a brightness image of the wavenumber range in cm-1 (e.g. 900-910)").Default("").String()
+	tleFile   = kingpin.Flag("tle", "TLE file to propagate the orbit and save the sub-satellite track of each product (e.g. weather.txt from CelesTrak)").Default("").String()
+	passDate  = kingpin.Flag("pass-date", "UTC date of the LRPT pass as YYYY-MM-DD. Default is the modification date of the input file.").Default("").String()
+
+	reproject  = kingpin.Flag("reproject", "also export the products reprojected with the spacecraft diary or the --tle orbit (Options: equirectangular or mercator)").Default("").String()
+	bbox       = kingpin.Flag("bbox", "bounding box of the reprojection in degrees as west,south,east,north (e.g. -10,35,30,60). Default is the whole swath.").Default("").String()
+	resolution = kingpin.Flag("resolution", "pixel size of the reprojection in degrees (equirectangular) or meters (mercator). Default is the nadir resolution.").Default("0").Float64()
+	resampling = kingpin.Flag("resampling", "resampling of the reprojection (Options: nearest or bilinear)").Default("nearest").Enum("nearest", "bilinear")
+
+	coastlines      = kingpin.Flag("coastlines", "GeoJSON or shapefile of the coastlines drawn over the products georeferenced with the spacecraft diary or the --tle orbit").Default("").String()
+	coastlinesStyle = kingpin.Flag("coastlines-style", "color and width in pixels of the coastlines").Default(helpers.CoastlinesStyle).String()
+	borders         = kingpin.Flag("borders", "GeoJSON or shapefile of the borders drawn over the products georeferenced with the spacecraft diary or the --tle orbit").Default("").String()
+	bordersStyle    = kingpin.Flag("borders-style", "color and width in pixels of the borders").Default(helpers.BordersStyle).String()
+	graticule       = kingpin.Flag("graticule", "draw the meridians and parallels every step in degrees over the products georeferenced with the spacecraft diary or the --tle orbit").Default("0").Float64()
+	graticuleStyle  = kingpin.Flag("graticule-style", "color and width in pixels of the graticule").Default(helpers.GraticuleStyle).String()
+
+	sampleRate = kingpin.Flag("samplerate", "sample rate in Hz of raw IQ recordings (WAV files carry their own)").Default("0").Float64()
 
 	hrd            = kingpin.Command("hrd", "Activate workflow for the HRD protocol (NOAA-20 & Suomi).")
-	hrdDecoderType = hrd.Arg("decoder", "choose the decoder (Options: cadu, soft or none to bypass decoder)").Required().String()
+	hrdDecoderType = hrd.Arg("decoder", "choose the decoder (Options: cadu, soft, iq or none to bypass decoder)").Required().String()
 	hrdInputFile   = hrd.Arg("file", "input file path").Required().ExistingFile()
 
 	lrpt            = kingpin.Command("lrpt", "Activate workflow for the LRPT protocol (Meteor-MN2).")
-	lrptDecoderType = lrpt.Arg("decoder", "choose the decoder (Options: soft or none to bypass decoder)").Required().String()
-	lrptInputFile   = lrpt.Arg("file", "input file path").Required().ExistingFile()
+	lrptDecoderType = lrpt.Arg("decoder", "choose the decoder (Options: soft, oqpsk, interleaved80k, iq or none to bypass decoder)").Required().String()
+	lrptInputFile   = lrpt.Arg("file", "input file path or live soft-symbol socket (tcp://host:port or udp://host:port)").Required().String()
+
+	auto          = kingpin.Command("auto", "Detect the datalink and decoder of the input file and activate its workflow.")
+	autoInputFile = auto.Arg("file", "input file path").Required().ExistingFile()
+
+	info          = kingpin.Command("info", "Print a summary of the frames and packets of a recording or decoded file.")
+	infoInputFile = info.Arg("file", "input file path").Required().ExistingFile()
+	infoJSON      = info.Flag("json", "print the summary as JSON").Default("false").Bool()
+
+	simulate         = kingpin.Command("simulate", "Generate a synthetic soft-symbol or IQ file for end-to-end testing.")
+	simulateDatalink = simulate.Arg("datalink", "choose the datalink (Options: lrpt or hrd)").Required().Enum("lrpt", "hrd")
+	simulateOutput   = simulate.Arg("output", "output soft-symbol file path").Required().String()
+	simulateFormat   = simulate.Flag("format", "format of the output, named after its decoder (Options: soft, oqpsk, interleaved80k or iq)").Default("soft").Enum("soft", "oqpsk", "interleaved80k", "iq")
+	simulateFrames   = simulate.Flag("frames", "number of transfer frames to generate (zero reads the whole input)").Default("1024").Int()
+	simulateInput    = simulate.Flag("input", "encode transfer frames from a decoded file instead of generating packets").Default("").String()
+	simulateNoise    = simulate.Flag("noise", "standard deviation of the added noise relative to the symbol amplitude").Default("0").Float64()
+	simulateRotation = simulate.Flag("rotation", "phase rotation of the symbols in degrees (Options: 0, 90, 180 or 270)").Default("0").Enum("0", "90", "180", "270")
+	simulateSwapIQ   = simulate.Flag("swapiq", "swap the I and Q components").Default("false").Bool()
+	simulateSeed     = simulate.Flag("seed", "seed of the generated packets and noise").Default("1").Int64()
 
 	remote     = kingpin.Command("remote", "Activate the remote controll API.")
 	remotePort = remote.Arg("port", "server listen port").Default("3000").String()
@@ -57,19 +106,101 @@ func main() {
 		remoteHandler.New().Listen(*remotePort, *clientPort)
 	}
 
-	fmt.Println(startMessage)
-	fmt.Println()
+	if datalink != "info" || !*infoJSON {
+		fmt.Println(startMessage)
+		fmt.Println()
+	}
+
+	if datalink == "simulate" {
+		sim, err := simulator.New(*simulateDatalink, *simulateSeed)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if err := sim.SetFormat(*simulateFormat); err != nil {
+			log.Fatal(err)
+		}
+		if *simulateFormat == "iq" {
+			fmt.Printf("[SIM] The IQ samples are raw cf32 at %.0f Hz.\n", sim.SampleRate())
+		}
+
+		sim.Noise = *simulateNoise
+		sim.Rotation, _ = strconv.Atoi(*simulateRotation)
+		sim.SwapIQ = *simulateSwapIQ
+
+		if err := sim.Work(*simulateInput, *simulateOutput, *simulateFrames); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if datalink == "info" {
+		terminalHandler.HandleInfo(*infoInputFile, *sampleRate, *infoJSON)
+		return
+	}
+
+	apids, err := helpers.ParseAPIDs(*dumpAPIDs)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	spectrum, err := helpers.ParseRange(*crisRange)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	date, err := helpers.ParseDate(*passDate)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	reprojection, err := helpers.ParseReprojection(*reproject, *bbox, *resolution, *resampling)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	overlay, err := helpers.LoadOverlay(helpers.OverlayOptions{
+		Coastlines:      *coastlines,
+		CoastlinesStyle: *coastlinesStyle,
+		Borders:         *borders,
+		BordersStyle:    *bordersStyle,
+		Graticule:       *graticule,
+		GraticuleStyle:  *graticuleStyle,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	opts := handlers.ProcessorOptions{
+		DumpAPIDs:      apids,
+		ExportPDS:      *exportPDS,
+		Interferograms: *crisIGM,
+		Spectrum:       spectrum,
+		TLE:            *tleFile,
+		PassDate:       date,
+	}
 
 	wf := img.NewPipeline()
 
 	wf.AddPipe("Equalize", *equalize)
 	wf.AddPipe("Flop", *flop)
+	wf.AddPipe("AutoRotate", *autoRotate)
 	wf.AddPipe("Invert", *invert)
+	wf.AddPipe("FillBowTie", *fillBowTie)
+	wf.AddPipe("Calibrate", *calibrate)
+	wf.AddPipe("CorrectBowTie", *fixBowTie)
 	wf.AddPipe("ExportPNG", *exportPNG)
 	wf.AddPipe("ExportJPEG", *exportJPEG)
+	wf.AddPipe("ExportGeoTIFF", *exportTIFF)
+	wf.SetReprojection(reprojection)
+	wf.SetOverlay(overlay)
 
 	start := time.Now()
 	fmt.Printf("[CLI] Version %s\n", version)
-	terminalHandler.HandleInput(datalink, *lrptInputFile+*hrdInputFile, *output, *hrdDecoderType+*lrptDecoderType, wf)
+	if datalink == "auto" {
+		terminalHandler.HandleAuto(*autoInputFile, *output, *sampleRate, opts, wf)
+	} else {
+		terminalHandler.HandleInput(datalink, *lrptInputFile+*hrdInputFile, *output, *hrdDecoderType+*lrptDecoderType, *sampleRate, opts, wf)
+	}
 	fmt.Printf("[CLI] Tasks finished in %s\n", time.Since(start))
 }
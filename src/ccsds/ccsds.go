@@ -1,6 +1,7 @@
 package ccsds
 
 import (
+	"io"
 	"weatherdump/src/ccsds/frames"
 	"weatherdump/src/ccsds/parameters"
 )
@@ -8,27 +9,56 @@ import (
 // Version list of all supported CCSDS variants.
 var Version = parameters.Version
 
+// FrameSize of the decoded transfer frames.
+const FrameSize = 892
+
+// IdleAPID is used by the fill packets.
+const IdleAPID = 2047
+
+// PacketHandler receives each space packet as soon as it's closed.
+type PacketHandler func(packet frames.SpacePacketFrame)
+
 // Worker data structure.
 type Worker struct {
-	spacePackets []frames.SpacePacketFrame
-	tmpPacket    *frames.SpacePacketFrame
-	buffer       []byte
+	handler        PacketHandler
+	packetCount    int
+	droppedPackets int
+	tmpPacket      *frames.SpacePacketFrame
+	buffer         []byte
 }
 
 // New creates a new worker for the CCSDS class.
-func New() *Worker {
-	return &Worker{}
+// Packets are delivered to the handler instead of being stored.
+func New(handler PacketHandler) *Worker {
+	return &Worker{handler: handler}
+}
+
+// GetPacketCount returns the number of packets delivered so far.
+func (e Worker) GetPacketCount() int {
+	return e.packetCount
 }
 
-// GetSpacePackets returns a slice containing all frames.
-func (e Worker) GetSpacePackets() []frames.SpacePacketFrame {
-	return e.spacePackets
+// GetDroppedPacketCount returns the number of partial packets dropped by Reset.
+func (e Worker) GetDroppedPacketCount() int {
+	return e.droppedPackets
+}
+
+// Reset drops the packet being assembled. It should be called when frames
+// are lost, so the partial packet isn't concatenated with unrelated data.
+func (e *Worker) Reset() {
+	if e.tmpPacket != nil || len(e.buffer) > 0 {
+		e.droppedPackets++
+	}
+
+	e.buffer = make([]byte, 0)
+	e.tmpPacket = nil
 }
 
 // CloseFrame deletes all data of the current frame.
 func (e *Worker) CloseFrame() {
 	if e.tmpPacket != nil {
-		e.spacePackets = append(e.spacePackets, *e.tmpPacket)
+		e.packetCount++
+		e.handler(*e.tmpPacket)
 	}
 
 	e.buffer = make([]byte, 0)
@@ -50,7 +80,7 @@ func (e *Worker) CreatePacket(buf []byte) {
 		e.tmpPacket.FromBinary(buf[:6])
 		buf = buf[6:]
 	} else {
-		e.buffer = buf
+		e.buffer = append([]byte(nil), buf...)
 	}
 
 	if e.tmpPacket != nil {
@@ -106,3 +136,18 @@ func (e *Worker) ParseMPDU(MPDU frames.MultiplexingFrame) {
 		e.CloseFrame()
 	}
 }
+
+// ReadFrames reads transfer frames from the input until it ends. The same buffer
+// is used for every frame, so the handler shouldn't keep references to it.
+func ReadFrames(input io.Reader, handler func(frame *frames.TransferFrame)) error {
+	buf := make([]byte, FrameSize)
+	for {
+		if _, err := io.ReadFull(input, buf); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil
+			}
+			return err
+		}
+		handler(frames.NewTransferFrame(buf))
+	}
+}
@@ -0,0 +1,63 @@
+// Package coding implements the channel coding primitives of the CCSDS links.
+//
+// The decoders use the primitives through the interfaces below. By default
+// they are backed by libsathelper, building with the purego tag selects the
+// implementation written in Go, which gives the same results without cgo:
+//
+//	go build -tags purego
+package coding
+
+// Viterbi decodes a frame of soft-symbols of the rate 1/2 k=7 convolutional code.
+type Viterbi interface {
+	Decode(input, output []byte)
+	GetBER() int
+	GetPercentBER() float32
+}
+
+// ReedSolomon corrects the interleaved (255,223) blocks of a frame.
+// The decoders return the number of corrected symbols, the parity included, or -1
+// on failure.
+type ReedSolomon interface {
+	Deinterleave(dat, block []byte, pos, depth int)
+	Interleave(block, dat []byte, pos, depth int)
+	Decode(block []byte) int
+	DecodeDualBasis(block []byte) int
+}
+
+// Correlator finds the sync words inside a soft-symbol buffer.
+// All the words added must have the same size.
+type Correlator interface {
+	AddWord(word uint64)
+	AddWord32(word uint32)
+	Correlate(data []byte)
+	GetHighestCorrelation() uint
+	GetHighestCorrelationPosition() uint
+	GetCorrelationWordNumber() uint
+}
+
+// PacketFixer resolves the phase ambiguity of the soft-symbols.
+type PacketFixer interface {
+	FixPacket(data []byte, shift PhaseShift, iqInversion bool)
+}
+
+// NewGoViterbi27 creates the decoder written in Go whatever the build tags, the
+// same for the constructors below. They let the tests compare the decoders backed
+// by each implementation.
+func NewGoViterbi27(frameBits int) Viterbi {
+	return newViterbi27(frameBits)
+}
+
+// NewGoReedSolomon creates the (255,223) decoder written in Go.
+func NewGoReedSolomon() ReedSolomon {
+	return newReedSolomon()
+}
+
+// NewGoCorrelator creates the correlator written in Go, without words.
+func NewGoCorrelator() Correlator {
+	return newCorrelator()
+}
+
+// NewGoPacketFixer creates the packet fixer written in Go.
+func NewGoPacketFixer() PacketFixer {
+	return newPacketFixer()
+}
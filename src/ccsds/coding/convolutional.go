@@ -0,0 +1,41 @@
+package coding
+
+import (
+	"math/bits"
+)
+
+// Polynomials of the CCSDS rate 1/2 constraint length 7 convolutional code.
+// The newest bit is stored in the LSB of the shift register.
+const (
+	PolyA = 0x4F
+	PolyB = 0x6D
+)
+
+// ConvolutionalEncoder for the rate 1/2 k=7 code. The shift register is kept
+// between calls so a continuous stream can be encoded in chunks.
+type ConvolutionalEncoder struct {
+	register uint8
+}
+
+// NewConvolutionalEncoder returns a new encoder with the shift register cleared.
+func NewConvolutionalEncoder() *ConvolutionalEncoder {
+	return &ConvolutionalEncoder{}
+}
+
+// Encode returns two encoded bytes for each byte of data, MSB first.
+func (e *ConvolutionalEncoder) Encode(dat []byte) []byte {
+	out := make([]byte, len(dat)*2)
+
+	for i, b := range dat {
+		var word uint16
+		for n := 7; n >= 0; n-- {
+			e.register = (e.register<<1 | (b>>uint(n))&1) & 0x7F
+			word = word<<2 |
+				uint16(bits.OnesCount8(e.register&PolyA)&1)<<1 |
+				uint16(bits.OnesCount8(e.register&PolyB)&1)
+		}
+		out[i*2+0] = byte(word >> 8)
+		out[i*2+1] = byte(word)
+	}
+	return out
+}
@@ -0,0 +1,96 @@
+package coding
+
+// correlator finds the position of the sync words inside a soft-symbol buffer.
+// Each word is compared bit by bit with the hard decision of the symbols.
+type correlator struct {
+	words       [][]byte
+	correlation []uint
+	position    []uint
+	wordNumber  uint
+}
+
+// newCorrelator returns a correlator without words.
+func newCorrelator() *correlator {
+	return &correlator{}
+}
+
+// AddWord appends a 64-bit word to the search list.
+func (e *correlator) AddWord(word uint64) {
+	e.addWord(word, 64)
+}
+
+// AddWord32 appends a 32-bit word to the search list.
+func (e *correlator) AddWord32(word uint32) {
+	e.addWord(uint64(word), 32)
+}
+
+// addWord expands the word into hard symbols, MSB first.
+func (e *correlator) addWord(word uint64, size int) {
+	w := make([]byte, size)
+	for i := range w {
+		if (word>>uint(size-1-i))&1 == 1 {
+			w[i] = 0xFF
+		}
+	}
+
+	e.words = append(e.words, w)
+	e.correlation = append(e.correlation, 0)
+	e.position = append(e.position, 0)
+}
+
+// Correlate searches all words inside the data. The first position
+// with the highest correlation of each word is kept. The best word is
+// left unchanged if none of them correlates.
+func (e *correlator) Correlate(data []byte) {
+	for n := range e.words {
+		e.correlation[n] = 0
+		e.position[n] = 0
+	}
+
+	if len(e.words) == 0 {
+		return
+	}
+
+	wordSize := len(e.words[0])
+	for i := 0; i < len(data)-wordSize; i++ {
+		for n, word := range e.words {
+			var corr uint
+			for k, w := range word {
+				if hardCorrelate(data[i+k], w) {
+					corr++
+				}
+			}
+			if corr > e.correlation[n] {
+				e.correlation[n] = corr
+				e.position[n] = uint(i)
+			}
+		}
+	}
+
+	var best uint
+	for n, corr := range e.correlation {
+		if corr > best {
+			e.wordNumber = uint(n)
+			best = corr
+		}
+	}
+}
+
+// GetHighestCorrelation returns the correlation of the best word.
+func (e *correlator) GetHighestCorrelation() uint {
+	return e.correlation[e.wordNumber]
+}
+
+// GetHighestCorrelationPosition returns the position of the best word.
+func (e *correlator) GetHighestCorrelationPosition() uint {
+	return e.position[e.wordNumber]
+}
+
+// GetCorrelationWordNumber returns the index of the best word.
+func (e *correlator) GetCorrelationWordNumber() uint {
+	return e.wordNumber
+}
+
+func hardCorrelate(data, word byte) bool {
+	return (data >= 127 && word == 0) || (data < 127 && word == 255)
+}
@@ -0,0 +1,262 @@
+//go:build !purego
+// +build !purego
+
+package coding
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+// The tests below decode the same simulated stream with libsathelper and with the Go
+// implementation of the primitives, which must give bit-exact results. They need cgo
+// and libsathelper, like the default build.
+
+const (
+	equivalenceFrames      = 64
+	equivalenceFrameSize   = 1024
+	equivalenceFrameBits   = equivalenceFrameSize * 8
+	equivalenceCodedSize   = equivalenceFrameBits * 2
+	equivalenceOverlapBits = 64
+	equivalenceRSBlocks    = 4
+)
+
+var equivalenceSyncWord = []byte{0x1A, 0xCF, 0xFC, 0x1D}
+
+// simulateStream returns the soft-symbols of the frames with random data, coded as the
+// CCSDS downlinks: Reed-Solomon, randomizer, sync word and convolutional code. The
+// symbols are signed, with the amplitude of 100 and gaussian noise of the deviation
+// informed relative to it.
+func simulateStream(rnd *rand.Rand, frames int, noise float64, dualBasis bool) []byte {
+	conv := NewConvolutionalEncoder()
+	out := make([]byte, 0, frames*equivalenceCodedSize)
+
+	cadu := make([]byte, equivalenceFrameSize)
+	block := make([]byte, RSBlockSize)
+
+	for f := 0; f < frames; f++ {
+		copy(cadu, equivalenceSyncWord)
+		codeblock := cadu[len(equivalenceSyncWord):]
+
+		for i := 0; i < equivalenceRSBlocks; i++ {
+			rnd.Read(block[:RSDataSize])
+			if dualBasis {
+				RSEncodeDualBasis(block)
+			} else {
+				RSEncode(block)
+			}
+			RSInterleave(block, codeblock, i, equivalenceRSBlocks)
+		}
+		Randomize(codeblock)
+
+		for _, b := range conv.Encode(cadu) {
+			for n := 7; n >= 0; n-- {
+				v := 100*(1-2*float64(b>>uint(n)&1)) + rnd.NormFloat64()*noise*100
+				if v > 127 {
+					v = 127
+				}
+				if v < -127 {
+					v = -127
+				}
+				out = append(out, byte(int8(v)))
+			}
+		}
+	}
+	return out
+}
+
+func TestViterbiReedSolomonEquivalence(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		noise     float64
+		dualBasis bool
+	}{
+		{"clean", 0.1, false},
+		{"noisy", 0.33, false},
+		{"noisy dual basis", 0.33, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			symbols := simulateStream(rand.New(rand.NewSource(1)), equivalenceFrames, tc.noise, tc.dualBasis)
+
+			cVit := NewViterbi27(equivalenceFrameBits + equivalenceOverlapBits)
+			goVit := newViterbi27(equivalenceFrameBits + equivalenceOverlapBits)
+			cRS := NewReedSolomon()
+			goRS := newReedSolomon()
+
+			input := make([]byte, equivalenceCodedSize+equivalenceOverlapBits)
+			for i := 0; i < equivalenceOverlapBits; i++ {
+				input[i] = 128
+			}
+
+			var corrections, failures int
+			for f := 0; f < equivalenceFrames; f++ {
+				// The decoders feed the end of the previous frame before the current one.
+				copy(input[equivalenceOverlapBits:], symbols[f*equivalenceCodedSize:])
+
+				cOut := make([]byte, equivalenceFrameSize+equivalenceOverlapBits/8)
+				goOut := make([]byte, len(cOut))
+				cVit.Decode(input, cOut)
+				goVit.Decode(input, goOut)
+
+				if !bytes.Equal(cOut, goOut) {
+					t.Fatalf("frame %d: decoded bits differ first at byte %d", f, firstDifference(cOut, goOut))
+				}
+				if cVit.GetBER() != goVit.GetBER() || cVit.GetPercentBER() != goVit.GetPercentBER() {
+					t.Fatalf("frame %d: BER %d (%f%%) with libsathelper and %d (%f%%) in Go", f,
+						cVit.GetBER(), cVit.GetPercentBER(), goVit.GetBER(), goVit.GetPercentBER())
+				}
+
+				copy(input, input[equivalenceCodedSize:])
+
+				frame := cOut[equivalenceOverlapBits/16+len(equivalenceSyncWord):][:equivalenceFrameSize-len(equivalenceSyncWord)]
+				Randomize(frame)
+
+				for i := 0; i < equivalenceRSBlocks; i++ {
+					cBlock := make([]byte, RSBlockSize)
+					goBlock := make([]byte, RSBlockSize)
+					cRS.Deinterleave(frame, cBlock, i, equivalenceRSBlocks)
+					goRS.Deinterleave(frame, goBlock, i, equivalenceRSBlocks)
+
+					var cErrors, goErrors int
+					if tc.dualBasis {
+						cErrors, goErrors = cRS.DecodeDualBasis(cBlock), goRS.DecodeDualBasis(goBlock)
+					} else {
+						cErrors, goErrors = cRS.Decode(cBlock), goRS.Decode(goBlock)
+					}
+
+					if cErrors != goErrors {
+						t.Fatalf("frame %d block %d: %d corrections with libsathelper and %d in Go", f, i, cErrors, goErrors)
+					}
+					if cErrors >= 0 && !bytes.Equal(cBlock, goBlock) {
+						t.Fatalf("frame %d block %d: corrected data differs first at byte %d", f, i, firstDifference(cBlock, goBlock))
+					}
+
+					if cErrors > 0 {
+						corrections += cErrors
+					} else if cErrors < 0 {
+						failures++
+					}
+				}
+			}
+
+			t.Logf("%d bytes corrected and %d blocks failed", corrections, failures)
+		})
+	}
+}
+
+// TestReedSolomonErrorsEquivalence corrupts coded blocks with up to twice the capacity
+// of the code, so both decoders also have to agree on the uncorrectable blocks.
+func TestReedSolomonErrorsEquivalence(t *testing.T) {
+	rnd := rand.New(rand.NewSource(2))
+	cRS := NewReedSolomon()
+	goRS := newReedSolomon()
+
+	for n := 0; n < 2000; n++ {
+		dualBasis := n%2 == 1
+		block := make([]byte, RSBlockSize)
+		rnd.Read(block[:RSDataSize])
+		if dualBasis {
+			RSEncodeDualBasis(block)
+		} else {
+			RSEncode(block)
+		}
+
+		for i := rnd.Intn(RSParitySize + 1); i > 0; i-- {
+			block[rnd.Intn(RSBlockSize)] ^= byte(rnd.Intn(255) + 1)
+		}
+
+		cBlock := append([]byte(nil), block...)
+		goBlock := append([]byte(nil), block...)
+
+		var cErrors, goErrors int
+		if dualBasis {
+			cErrors, goErrors = cRS.DecodeDualBasis(cBlock), goRS.DecodeDualBasis(goBlock)
+		} else {
+			cErrors, goErrors = cRS.Decode(cBlock), goRS.Decode(goBlock)
+		}
+
+		if cErrors != goErrors {
+			t.Fatalf("block %d: %d corrections with libsathelper and %d in Go", n, cErrors, goErrors)
+		}
+		if !bytes.Equal(cBlock, goBlock) {
+			t.Fatalf("block %d: block differs first at byte %d", n, firstDifference(cBlock, goBlock))
+		}
+	}
+}
+
+func TestCorrelatorEquivalence(t *testing.T) {
+	symbols := simulateStream(rand.New(rand.NewSource(3)), 4, 0.3, false)
+
+	c := NewCorrelator()
+	g := newCorrelator()
+	// Encoded sync words of the LRPT downlink in the phase ambiguities of the carrier.
+	for _, w := range []uint64{
+		0xfca2b63db00d9794, 0x56fbd394daa4c1c2, 0x035d49c24ff2686b, 0xa9042c6b255b3e3d,
+		0xfc51793e700e6b68, 0xa9f7e368e558c2c1, 0x03ae86c18ff19497, 0x56081c971aa73d3e,
+	} {
+		c.AddWord(w)
+		g.AddWord(w)
+	}
+
+	for offset := 0; offset < len(symbols)-equivalenceCodedSize; offset += 3001 {
+		data := symbols[offset : offset+equivalenceCodedSize]
+		c.Correlate(data)
+		g.Correlate(data)
+
+		if c.GetCorrelationWordNumber() != g.GetCorrelationWordNumber() ||
+			c.GetHighestCorrelation() != g.GetHighestCorrelation() ||
+			c.GetHighestCorrelationPosition() != g.GetHighestCorrelationPosition() {
+			t.Fatalf("offset %d: word %d correlation %d at %d with libsathelper and word %d correlation %d at %d in Go", offset,
+				c.GetCorrelationWordNumber(), c.GetHighestCorrelation(), c.GetHighestCorrelationPosition(),
+				g.GetCorrelationWordNumber(), g.GetHighestCorrelation(), g.GetHighestCorrelationPosition())
+		}
+	}
+}
+
+func TestPacketFixerEquivalence(t *testing.T) {
+	symbols := simulateStream(rand.New(rand.NewSource(4)), 1, 0.3, false)
+
+	for _, shift := range []PhaseShift{Deg0, Deg90, Deg180, Deg270} {
+		for _, inversion := range []bool{false, true} {
+			c := append([]byte(nil), symbols...)
+			g := append([]byte(nil), symbols...)
+			NewPacketFixer().FixPacket(c, shift, inversion)
+			newPacketFixer().FixPacket(g, shift, inversion)
+
+			if !bytes.Equal(c, g) {
+				t.Fatalf("shift %d inversion %v: symbols differ first at %d", shift, inversion, firstDifference(c, g))
+			}
+		}
+	}
+}
+
+func TestDeRandomizeNRZMEquivalence(t *testing.T) {
+	dat := make([]byte, 4096)
+	rand.New(rand.NewSource(5)).Read(dat)
+
+	c := append([]byte(nil), dat...)
+	g := append([]byte(nil), dat...)
+	DeRandomize(c)
+	Randomize(g)
+	if !bytes.Equal(c, g) {
+		t.Fatalf("derandomized data differs first at byte %d", firstDifference(c, g))
+	}
+
+	c = append(c[:0], dat...)
+	g = append(g[:0], dat...)
+	NRZMDecode(c)
+	nrzmDecode(g)
+	if !bytes.Equal(c, g) {
+		t.Fatalf("NRZ-M decoded data differs first at byte %d", firstDifference(c, g))
+	}
+}
+
+func firstDifference(a, b []byte) int {
+	for i := range a {
+		if i >= len(b) || a[i] != b[i] {
+			return i
+		}
+	}
+	return len(a)
+}
@@ -0,0 +1,35 @@
+package coding
+
+// NRZMEncoder converts a NRZ-L bit stream into NRZ-M, where a one is transmitted
+// as a level change. The last level is kept between calls.
+type NRZMEncoder struct {
+	lastBit byte
+}
+
+// NewNRZMEncoder returns a new encoder starting at the low level.
+func NewNRZMEncoder() *NRZMEncoder {
+	return &NRZMEncoder{}
+}
+
+// Encode the data in place.
+func (e *NRZMEncoder) Encode(dat []byte) {
+	for i, b := range dat {
+		var out byte
+		for n := 7; n >= 0; n-- {
+			e.lastBit ^= (b >> uint(n)) & 1
+			out = out<<1 | e.lastBit
+		}
+		dat[i] = out
+	}
+}
+
+// nrzmDecode converts NRZ-M data back into NRZ-L in place. The level
+// before the first bit is assumed low on every call.
+func nrzmDecode(dat []byte) {
+	var lastBit byte
+	for i, b := range dat {
+		mask := (b>>1)&0x7F | lastBit<<7
+		lastBit = b & 1
+		dat[i] = b ^ mask
+	}
+}
@@ -0,0 +1,42 @@
+package coding
+
+// PhaseShift of the QPSK constellation detected by the correlator.
+type PhaseShift int
+
+// Phase ambiguities resolved by the packet fixer.
+const (
+	Deg0 PhaseShift = iota
+	Deg90
+	Deg180
+	Deg270
+)
+
+// packetFixer rotates the soft-symbols back to the reference phase.
+type packetFixer struct{}
+
+// newPacketFixer returns a new packet fixer.
+func newPacketFixer() *packetFixer {
+	return &packetFixer{}
+}
+
+// FixPacket corrects the I/Q inversion and phase shift of the symbol pairs in place.
+func (e *packetFixer) FixPacket(data []byte, shift PhaseShift, iqInversion bool) {
+	if !iqInversion && shift == Deg0 {
+		return
+	}
+
+	for i := 0; i+1 < len(data); i += 2 {
+		if iqInversion {
+			data[i], data[i+1] = data[i+1], data[i]
+		}
+		if shift == Deg90 || shift == Deg270 {
+			a, b := int8(data[i]), int8(data[i+1])
+			data[i] = byte(128 - int(b))
+			data[i+1] = byte(int(a) + 128)
+		}
+		if shift == Deg180 || shift == Deg270 {
+			data[i] ^= 0xFF
+			data[i+1] ^= 0xFF
+		}
+	}
+}
@@ -0,0 +1,34 @@
+//go:build purego
+// +build purego
+
+package coding
+
+// NewViterbi27 creates a decoder for frames with the number of decoded bits informed.
+func NewViterbi27(frameBits int) Viterbi {
+	return newViterbi27(frameBits)
+}
+
+// NewReedSolomon creates the CCSDS (255,223) decoder.
+func NewReedSolomon() ReedSolomon {
+	return newReedSolomon()
+}
+
+// NewCorrelator creates a correlator without words.
+func NewCorrelator() Correlator {
+	return newCorrelator()
+}
+
+// NewPacketFixer creates a packet fixer.
+func NewPacketFixer() PacketFixer {
+	return newPacketFixer()
+}
+
+// DeRandomize removes the CCSDS pseudo-random sequence in place.
+func DeRandomize(dat []byte) {
+	Randomize(dat)
+}
+
+// NRZMDecode converts NRZ-M data back into NRZ-L in place.
+func NRZMDecode(dat []byte) {
+	nrzmDecode(dat)
+}
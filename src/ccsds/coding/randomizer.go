@@ -0,0 +1,31 @@
+package coding
+
+const pnLength = 255
+
+// pn holds the CCSDS pseudo-randomizer sequence generated by the
+// h(x) = x^8 + x^7 + x^5 + x^3 + 1 polynomial with all bits set as seed.
+var pn = generatePN()
+
+func generatePN() [pnLength]byte {
+	var table [pnLength]byte
+	state := byte(0xFF)
+
+	for i := range table {
+		for b := 0; b < 8; b++ {
+			bit := state >> 7
+			table[i] = table[i]<<1 | bit
+
+			feedback := (state>>7 ^ state>>4 ^ state>>2 ^ state) & 1
+			state = state<<1 | feedback
+		}
+	}
+	return table
+}
+
+// Randomize applies the pseudo-randomizer sequence to the data in place.
+// Since the operation is a XOR, the same function reverts the randomization.
+func Randomize(dat []byte) {
+	for i := range dat {
+		dat[i] ^= pn[i%pnLength]
+	}
+}
@@ -0,0 +1,278 @@
+package coding
+
+// Parameters of the CCSDS (255,223) Reed-Solomon code.
+const (
+	RSBlockSize  = 255
+	RSDataSize   = 223
+	RSParitySize = RSBlockSize - RSDataSize
+
+	rsPrimitivePoly  = 0x187
+	rsFirstRoot      = 112
+	rsRootGap        = 11
+	rsFieldSize      = 255
+	rsGeneratorRoots = RSParitySize
+)
+
+// galoisField tables for GF(2^8) generated by the CCSDS primitive polynomial.
+type galoisField struct {
+	exp [rsFieldSize * 2]byte
+	log [rsFieldSize + 1]int
+}
+
+var gf = newGaloisField()
+
+// generator polynomial coefficients, highest degree first (the leading one is omitted).
+var generator = newGenerator()
+
+func newGaloisField() *galoisField {
+	e := galoisField{}
+	x := 1
+	for i := 0; i < rsFieldSize; i++ {
+		e.exp[i] = byte(x)
+		e.exp[i+rsFieldSize] = byte(x)
+		e.log[x] = i
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= rsPrimitivePoly
+		}
+	}
+	return &e
+}
+
+func (e *galoisField) mul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return e.exp[e.log[a]+e.log[b]]
+}
+
+func newGenerator() [RSParitySize]byte {
+	poly := []byte{1}
+	for i := 0; i < rsGeneratorRoots; i++ {
+		root := gf.exp[((rsFirstRoot+i)*rsRootGap)%rsFieldSize]
+		next := make([]byte, len(poly)+1)
+		for j, c := range poly {
+			next[j] ^= c
+			next[j+1] ^= gf.mul(c, root)
+		}
+		poly = next
+	}
+
+	var out [RSParitySize]byte
+	copy(out[:], poly[1:])
+	return out
+}
+
+// RSEncode calculates the parity of the first 223 bytes of the block and writes
+// it to the last 32 bytes. Symbols are in the conventional basis.
+func RSEncode(block []byte) {
+	var parity [RSParitySize]byte
+
+	for _, b := range block[:RSDataSize] {
+		feedback := b ^ parity[0]
+		copy(parity[:], parity[1:])
+		parity[RSParitySize-1] = 0
+		if feedback != 0 {
+			for j, g := range generator {
+				parity[j] ^= gf.mul(feedback, g)
+			}
+		}
+	}
+
+	copy(block[RSDataSize:RSBlockSize], parity[:])
+}
+
+// RSEncodeDualBasis is the same as RSEncode but with symbols in the Berlekamp dual basis.
+func RSEncodeDualBasis(block []byte) {
+	var tmp [RSBlockSize]byte
+	for i, b := range block[:RSDataSize] {
+		tmp[i] = fromDualBasis[b]
+	}
+
+	RSEncode(tmp[:])
+
+	for i := RSDataSize; i < RSBlockSize; i++ {
+		block[i] = toDualBasis[tmp[i]]
+	}
+}
+
+// RSDeinterleave copies the block at the position pos from the interleaved data.
+func RSDeinterleave(dat, block []byte, pos, depth int) {
+	for i := 0; i < RSBlockSize; i++ {
+		block[i] = dat[i*depth+pos]
+	}
+}
+
+// RSInterleave copies the block into the position pos of the interleaved data.
+func RSInterleave(block, dat []byte, pos, depth int) {
+	for i := 0; i < RSBlockSize; i++ {
+		dat[i*depth+pos] = block[i]
+	}
+}
+
+func (e *galoisField) div(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return e.exp[(e.log[a]-e.log[b]+rsFieldSize)%rsFieldSize]
+}
+
+func (e *galoisField) pow(exponent int) byte {
+	exponent %= rsFieldSize
+	if exponent < 0 {
+		exponent += rsFieldSize
+	}
+	return e.exp[exponent]
+}
+
+// RSDecode corrects the block in place with the Berlekamp-Massey algorithm.
+// It returns the number of corrected symbols, the parity included like libfec, or -1
+// if the block can't be corrected, in this case the block is left untouched.
+func RSDecode(block []byte) int {
+	var syndromes [RSParitySize]byte
+	valid := true
+
+	for j := range syndromes {
+		root := gf.pow((rsFirstRoot + j) * rsRootGap)
+		var s byte
+		for _, b := range block[:RSBlockSize] {
+			s = gf.mul(s, root) ^ b
+		}
+		syndromes[j] = s
+		valid = valid && s == 0
+	}
+
+	if valid {
+		return 0
+	}
+
+	// Berlekamp-Massey to find the error locator polynomial (lowest degree first).
+	locator := []byte{1}
+	prev := []byte{1}
+	length, shift := 0, 1
+	lastDiscrepancy := byte(1)
+
+	for n := 0; n < RSParitySize; n++ {
+		discrepancy := syndromes[n]
+		for i := 1; i <= length && i < len(locator); i++ {
+			discrepancy ^= gf.mul(locator[i], syndromes[n-i])
+		}
+
+		if discrepancy == 0 {
+			shift++
+			continue
+		}
+
+		coef := gf.div(discrepancy, lastDiscrepancy)
+		next := make([]byte, max(len(locator), len(prev)+shift))
+		copy(next, locator)
+		for i, p := range prev {
+			next[i+shift] ^= gf.mul(coef, p)
+		}
+
+		if 2*length <= n {
+			prev = locator
+			length = n + 1 - length
+			lastDiscrepancy = discrepancy
+			shift = 1
+		} else {
+			shift++
+		}
+		locator = next
+	}
+
+	for len(locator) > 1 && locator[len(locator)-1] == 0 {
+		locator = locator[:len(locator)-1]
+	}
+	if len(locator)-1 != length || length > RSParitySize/2 {
+		return -1
+	}
+
+	// Error evaluator polynomial.
+	evaluator := make([]byte, RSParitySize)
+	for i := range evaluator {
+		for j := 0; j <= i && j < len(locator); j++ {
+			evaluator[i] ^= gf.mul(locator[j], syndromes[i-j])
+		}
+	}
+
+	// Chien search and Forney algorithm.
+	var fixed [RSBlockSize]byte
+	copy(fixed[:], block)
+	found := 0
+
+	for pos := 0; pos < RSBlockSize; pos++ {
+		degree := RSBlockSize - 1 - pos
+		xinv := gf.pow(-degree * rsRootGap)
+
+		if evaluate(locator, xinv) != 0 {
+			continue
+		}
+		found++
+
+		var derivative byte
+		for i := 1; i < len(locator); i += 2 {
+			derivative ^= gf.mul(locator[i], gf.pow(gf.log[xinv]*(i-1)))
+		}
+		if derivative == 0 {
+			return -1
+		}
+
+		magnitude := gf.div(evaluate(evaluator, xinv), derivative)
+		magnitude = gf.mul(magnitude, gf.pow(degree*rsRootGap*(1-rsFirstRoot)))
+		fixed[pos] ^= magnitude
+	}
+
+	if found != length {
+		return -1
+	}
+
+	copy(block, fixed[:])
+	return found
+}
+
+// RSDecodeDualBasis is the same as RSDecode but with symbols in the Berlekamp dual basis.
+func RSDecodeDualBasis(block []byte) int {
+	for i := range block[:RSBlockSize] {
+		block[i] = fromDualBasis[block[i]]
+	}
+
+	corrected := RSDecode(block)
+
+	for i := range block[:RSBlockSize] {
+		block[i] = toDualBasis[block[i]]
+	}
+	return corrected
+}
+
+func evaluate(poly []byte, x byte) byte {
+	var out byte
+	for i := len(poly) - 1; i >= 0; i-- {
+		out = gf.mul(out, x) ^ poly[i]
+	}
+	return out
+}
+
+// reedSolomon implements the ReedSolomon interface over the block functions.
+// The parity is always copied back when interleaving.
+type reedSolomon struct{}
+
+func newReedSolomon() *reedSolomon {
+	return &reedSolomon{}
+}
+
+func (e *reedSolomon) Deinterleave(dat, block []byte, pos, depth int) {
+	RSDeinterleave(dat, block, pos, depth)
+}
+
+func (e *reedSolomon) Interleave(block, dat []byte, pos, depth int) {
+	RSInterleave(block, dat, pos, depth)
+}
+
+func (e *reedSolomon) Decode(block []byte) int {
+	return RSDecode(block)
+}
+
+func (e *reedSolomon) DecodeDualBasis(block []byte) int {
+	return RSDecodeDualBasis(block)
+}
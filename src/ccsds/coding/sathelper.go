@@ -0,0 +1,123 @@
+//go:build !purego
+// +build !purego
+
+package coding
+
+import (
+	SatHelper "github.com/luigifreitas/libsathelper"
+)
+
+type satHelperViterbi struct {
+	viterbi SatHelper.Viterbi27
+}
+
+// NewViterbi27 creates a decoder for frames with the number of decoded bits informed.
+func NewViterbi27(frameBits int) Viterbi {
+	return &satHelperViterbi{SatHelper.NewViterbi27(frameBits)}
+}
+
+func (e *satHelperViterbi) Decode(input, output []byte) {
+	e.viterbi.Decode(&input[0], &output[0])
+}
+
+func (e *satHelperViterbi) GetBER() int {
+	return e.viterbi.GetBER()
+}
+
+func (e *satHelperViterbi) GetPercentBER() float32 {
+	return e.viterbi.GetPercentBER()
+}
+
+type satHelperReedSolomon struct {
+	rs SatHelper.ReedSolomon
+}
+
+// NewReedSolomon creates the CCSDS (255,223) decoder.
+func NewReedSolomon() ReedSolomon {
+	e := satHelperReedSolomon{SatHelper.NewReedSolomon()}
+	e.rs.SetCopyParityToOutput(true)
+	return &e
+}
+
+func (e *satHelperReedSolomon) Deinterleave(dat, block []byte, pos, depth int) {
+	e.rs.Deinterleave(&dat[0], &block[0], byte(pos), byte(depth))
+}
+
+func (e *satHelperReedSolomon) Interleave(block, dat []byte, pos, depth int) {
+	e.rs.Interleave(&block[0], &dat[0], byte(pos), byte(depth))
+}
+
+func (e *satHelperReedSolomon) Decode(block []byte) int {
+	return int(int8(e.rs.Decode_rs8(&block[0])))
+}
+
+func (e *satHelperReedSolomon) DecodeDualBasis(block []byte) int {
+	return int(int8(e.rs.Decode_ccsds(&block[0])))
+}
+
+type satHelperCorrelator struct {
+	correlator SatHelper.Correlator
+}
+
+// NewCorrelator creates a correlator without words.
+func NewCorrelator() Correlator {
+	return &satHelperCorrelator{SatHelper.NewCorrelator()}
+}
+
+func (e *satHelperCorrelator) AddWord(word uint64) {
+	e.correlator.AddWord(word)
+}
+
+func (e *satHelperCorrelator) AddWord32(word uint32) {
+	e.correlator.AddWord(uint(word))
+}
+
+func (e *satHelperCorrelator) Correlate(data []byte) {
+	e.correlator.Correlate(&data[0], uint(len(data)))
+}
+
+func (e *satHelperCorrelator) GetHighestCorrelation() uint {
+	return e.correlator.GetHighestCorrelation()
+}
+
+func (e *satHelperCorrelator) GetHighestCorrelationPosition() uint {
+	return e.correlator.GetHighestCorrelationPosition()
+}
+
+func (e *satHelperCorrelator) GetCorrelationWordNumber() uint {
+	return e.correlator.GetCorrelationWordNumber()
+}
+
+type satHelperPacketFixer struct {
+	packetFixer SatHelper.PacketFixer
+}
+
+// NewPacketFixer creates a packet fixer.
+func NewPacketFixer() PacketFixer {
+	return &satHelperPacketFixer{SatHelper.NewPacketFixer()}
+}
+
+func (e *satHelperPacketFixer) FixPacket(data []byte, shift PhaseShift, iqInversion bool) {
+	var phaseShift SatHelper.SatHelperPhaseShift
+	switch shift {
+	case Deg0:
+		phaseShift = SatHelper.DEG_0
+	case Deg90:
+		phaseShift = SatHelper.DEG_90
+	case Deg180:
+		phaseShift = SatHelper.DEG_180
+	case Deg270:
+		phaseShift = SatHelper.DEG_270
+	}
+	e.packetFixer.FixPacket(&data[0], uint(len(data)), phaseShift, iqInversion)
+}
+
+// DeRandomize removes the CCSDS pseudo-random sequence in place.
+func DeRandomize(dat []byte) {
+	SatHelper.DeRandomizerDeRandomize(&dat[0], len(dat))
+}
+
+// NRZMDecode converts NRZ-M data back into NRZ-L in place.
+func NRZMDecode(dat []byte) {
+	SatHelper.DifferentialEncodingNrzmDecode(&dat[0], len(dat))
+}
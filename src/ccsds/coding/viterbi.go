@@ -0,0 +1,124 @@
+package coding
+
+import (
+	"math/bits"
+)
+
+const (
+	viterbiStates  = 64
+	viterbiMaxCost = 1 << 30
+)
+
+// viterbi27 is a soft decision decoder of the rate 1/2 k=7 convolutional code.
+// Soft-symbols are unsigned bytes where 0 is the strongest zero and 255 the strongest one,
+// the same linear metric used by libcorrect. The trellis starts at the zero state and
+// the whole frame is traced back from the best final state.
+type viterbi27 struct {
+	frameBits int
+	decisions []uint64
+	padded    []byte
+	ber       int
+	outputs   [viterbiStates * 2]uint8
+}
+
+// newViterbi27 creates a decoder for frames with the number of decoded bits informed.
+func newViterbi27(frameBits int) *viterbi27 {
+	e := viterbi27{
+		frameBits: frameBits,
+		decisions: make([]uint64, frameBits),
+		padded:    make([]byte, frameBits*2),
+	}
+
+	for reg := 0; reg < viterbiStates*2; reg++ {
+		e.outputs[reg] = uint8(bits.OnesCount8(uint8(reg)&PolyA)&1)<<1 | uint8(bits.OnesCount8(uint8(reg)&PolyB)&1)
+	}
+	return &e
+}
+
+// Decode the soft-symbols of the input into the packed output bits.
+// The input should have two symbols for each decoded bit, missing
+// symbols at the end are treated as erasures.
+func (e *viterbi27) Decode(input, output []byte) {
+	if len(input) < e.frameBits*2 {
+		n := copy(e.padded, input)
+		for i := n; i < len(e.padded); i++ {
+			e.padded[i] = 128
+		}
+		input = e.padded
+	}
+
+	var costs, next [viterbiStates]int
+	for s := 1; s < viterbiStates; s++ {
+		costs[s] = viterbiMaxCost
+	}
+
+	for t := 0; t < e.frameBits; t++ {
+		a, b := int(input[t*2]), int(input[t*2+1])
+		metrics := [4]int{
+			a + b,
+			a + (255 - b),
+			(255 - a) + b,
+			(255 - a) + (255 - b),
+		}
+
+		var decision uint64
+		for s := 0; s < viterbiStates; s++ {
+			p0 := s >> 1
+			p1 := p0 | viterbiStates>>1
+
+			c0 := costs[p0] + metrics[e.outputs[s]]
+			c1 := costs[p1] + metrics[e.outputs[s|viterbiStates]]
+
+			if c1 < c0 {
+				next[s] = c1
+				decision |= 1 << uint(s)
+			} else {
+				next[s] = c0
+			}
+		}
+
+		e.decisions[t] = decision
+		costs = next
+	}
+
+	state := 0
+	for s := 1; s < viterbiStates; s++ {
+		if costs[s] < costs[state] {
+			state = s
+		}
+	}
+
+	for i := range output[:e.frameBits/8] {
+		output[i] = 0
+	}
+	for t := e.frameBits - 1; t >= 0; t-- {
+		output[t/8] |= byte(state&1) << uint(7-t%8)
+		state = state>>1 | int(e.decisions[t]>>uint(state)&1)<<5
+	}
+
+	e.ber = e.countErrors(input, output)
+}
+
+// countErrors re-encodes the decoded bits and compares them with the hard decision of the input.
+func (e *viterbi27) countErrors(input, output []byte) int {
+	encoded := NewConvolutionalEncoder().Encode(output[:e.frameBits/8])
+
+	errors := 0
+	for i := 0; i < e.frameBits*2; i++ {
+		bit := encoded[i/8] >> uint(7-i%8) & 1
+		if (input[i] >= 127) != (bit == 1) {
+			errors++
+		}
+	}
+	return errors
+}
+
+// GetBER returns the number of bit errors corrected in the last frame.
+func (e *viterbi27) GetBER() int {
+	return e.ber / 2
+}
+
+// GetPercentBER returns the bit errors corrected in the last frame as percentage.
+func (e *viterbi27) GetPercentBER() float32 {
+	return 100 * float32(e.ber) / float32(e.frameBits)
+}
@@ -0,0 +1,263 @@
+// Package compression implements the CCSDS 121.0-B lossless data compression
+// (also known as Rice or AEC) decoder used by the instruments science data.
+package compression
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Errors reported while decoding a compressed stream.
+var (
+	ErrTruncated       = errors.New("compressed data ended before the expected number of samples")
+	ErrInvalidSample   = errors.New("mapped sample out of range")
+	ErrInvalidZeroRun  = errors.New("zero block run crosses the reference sample interval")
+	ErrInvalidExtended = errors.New("invalid second extension codeword")
+)
+
+const (
+	// remainderOfSegment is the zero block count that fills up to the end of the segment.
+	remainderOfSegment = 5
+	segmentBlocks      = 64
+	secondExtensionMax = 90
+)
+
+// Decoder of the adaptive entropy coder with the unit delay predictor preprocessing.
+type Decoder struct {
+	bitsPerSample int
+	blockSize     int
+	rsi           int
+	preprocess    bool
+	idLen         int
+	xmax          uint32
+	seTable       [secondExtensionMax + 1][2]uint32
+}
+
+// NewDecoder returns a decoder for unsigned samples up to 16 bits. The block size is the
+// number of samples in each coded block and the RSI the number of blocks between reference
+// samples. The output samples are written as 16-bit big endian words.
+func NewDecoder(bitsPerSample, blockSize, rsi int, preprocess bool) (*Decoder, error) {
+	if bitsPerSample < 1 || bitsPerSample > 16 {
+		return nil, fmt.Errorf("unsupported sample size of %d bits", bitsPerSample)
+	}
+	if blockSize < 2 || blockSize%2 != 0 || rsi < 1 {
+		return nil, fmt.Errorf("invalid block size %d or RSI %d", blockSize, rsi)
+	}
+
+	e := Decoder{
+		bitsPerSample: bitsPerSample,
+		blockSize:     blockSize,
+		rsi:           rsi,
+		preprocess:    preprocess,
+		xmax:          1<<uint(bitsPerSample) - 1,
+	}
+
+	switch {
+	case bitsPerSample > 8:
+		e.idLen = 4
+	case bitsPerSample > 4:
+		e.idLen = 3
+	default:
+		e.idLen = 2
+	}
+
+	// Second extension codewords are indexed by m = (d0+d1)(d0+d1+1)/2 + d1.
+	m := 0
+	for beta := uint32(0); m <= secondExtensionMax; beta++ {
+		first := uint32(m)
+		for i := uint32(0); i <= beta && m <= secondExtensionMax; i++ {
+			e.seTable[m] = [2]uint32{beta, first}
+			m++
+		}
+	}
+
+	return &e, nil
+}
+
+// Decode the compressed input until the output is filled.
+func (e *Decoder) Decode(input, output []byte) error {
+	r := bitReader{dat: input}
+	samples := len(output) / 2
+	rsiSize := e.rsi * e.blockSize
+
+	buf := make([]uint32, 0, rsiSize)
+	var last uint32
+
+	for n := 0; n < samples; {
+		buf = buf[:0]
+
+		for len(buf) < rsiSize && n+len(buf) < samples {
+			var err error
+			if buf, err = e.decodeBlock(&r, buf, rsiSize); err != nil {
+				return err
+			}
+		}
+
+		for i, delta := range buf {
+			if n >= samples {
+				break
+			}
+
+			x := delta
+			if e.preprocess && i != 0 {
+				var ok bool
+				if x, ok = e.unmap(last, delta); !ok {
+					return ErrInvalidSample
+				}
+			}
+			last = x
+
+			binary.BigEndian.PutUint16(output[n*2:], uint16(x))
+			n++
+		}
+	}
+
+	return nil
+}
+
+// decodeBlock appends the samples of the next coded data set to the buffer.
+// Nothing is appended if the block isn't complete.
+func (e *Decoder) decodeBlock(r *bitReader, buf []uint32, rsiSize int) ([]uint32, error) {
+	ref := 0
+	if e.preprocess && len(buf) == 0 {
+		ref = 1
+	}
+
+	id, err := r.read(e.idLen)
+	if err != nil {
+		return buf, err
+	}
+
+	block := make([]uint32, 0, e.blockSize)
+	maxID := uint32(1)<<uint(e.idLen) - 1
+
+	switch {
+	case id == 0:
+		extended, err := r.read(1)
+		if err != nil {
+			return buf, err
+		}
+
+		if ref == 1 {
+			sample, err := r.read(e.bitsPerSample)
+			if err != nil {
+				return buf, err
+			}
+			block = append(block, sample)
+		}
+
+		if extended == 1 {
+			for len(block) < e.blockSize {
+				m, err := r.readFS()
+				if err != nil {
+					return buf, err
+				}
+				if m > secondExtensionMax {
+					return buf, ErrInvalidExtended
+				}
+
+				beta, first := e.seTable[m][0], e.seTable[m][1]
+				d1 := m - first
+				if len(block)%2 == 0 {
+					block = append(block, beta-d1)
+				}
+				block = append(block, d1)
+			}
+			return append(buf, block...), nil
+		}
+
+		fs, err := r.readFS()
+		if err != nil {
+			return buf, err
+		}
+
+		zeroBlocks := int(fs) + 1
+		if zeroBlocks == remainderOfSegment {
+			b := (len(buf) + len(block)) / e.blockSize
+			zeroBlocks = segmentBlocks - b%segmentBlocks
+			if left := e.rsi - b; left < zeroBlocks {
+				zeroBlocks = left
+			}
+		} else if zeroBlocks > remainderOfSegment {
+			zeroBlocks--
+		}
+
+		count := zeroBlocks*e.blockSize - ref
+		if len(buf)+len(block)+count > rsiSize {
+			return buf, ErrInvalidZeroRun
+		}
+
+		buf = append(buf, block...)
+		for i := 0; i < count; i++ {
+			buf = append(buf, 0)
+		}
+		return buf, nil
+
+	case id == maxID:
+		for len(block) < e.blockSize {
+			sample, err := r.read(e.bitsPerSample)
+			if err != nil {
+				return buf, err
+			}
+			block = append(block, sample)
+		}
+		return append(buf, block...), nil
+
+	default:
+		k := uint(id - 1)
+
+		if ref == 1 {
+			sample, err := r.read(e.bitsPerSample)
+			if err != nil {
+				return buf, err
+			}
+			block = append(block, sample)
+		}
+
+		for len(block) < e.blockSize {
+			fs, err := r.readFS()
+			if err != nil {
+				return buf, err
+			}
+			if fs > e.xmax>>k {
+				return buf, ErrInvalidSample
+			}
+			block = append(block, fs<<k)
+		}
+
+		if k > 0 {
+			for i := ref; i < e.blockSize; i++ {
+				lsb, err := r.read(int(k))
+				if err != nil {
+					return buf, err
+				}
+				block[i] |= lsb
+			}
+		}
+		return append(buf, block...), nil
+	}
+}
+
+// unmap reverts the prediction error mapping using the previous sample as predictor.
+func (e *Decoder) unmap(last, delta uint32) (uint32, bool) {
+	theta := last
+	if e.xmax-last < theta {
+		theta = e.xmax - last
+	}
+
+	if delta <= 2*theta {
+		if delta&1 == 0 {
+			return last + delta/2, true
+		}
+		return last - (delta+1)/2, true
+	}
+
+	if delta > e.xmax {
+		return 0, false
+	}
+	if theta == last {
+		return delta, true
+	}
+	return e.xmax - delta, true
+}
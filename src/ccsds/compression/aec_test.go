@@ -0,0 +1,225 @@
+package compression
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// bitWriter writes MSB first bit fields, the counterpart of the bitReader.
+type bitWriter struct {
+	dat []byte
+	pos int
+}
+
+func (e *bitWriter) write(v uint32, n int) {
+	for i := n - 1; i >= 0; i-- {
+		if e.pos%8 == 0 {
+			e.dat = append(e.dat, 0)
+		}
+		e.dat[e.pos/8] |= byte(v>>uint(i)&1) << uint(7-e.pos%8)
+		e.pos++
+	}
+}
+
+// fs writes the fundamental sequence codeword of the value.
+func (e *bitWriter) fs(v uint32) {
+	for i := uint32(0); i < v; i++ {
+		e.write(0, 1)
+	}
+	e.write(1, 1)
+}
+
+// Options of the minimal encoder below, for 8-bit samples with 3-bit identifiers.
+const (
+	testBits      = 8
+	testIDLen     = 3
+	testBlockSize = 8
+	testZeroBlock = 0
+	testRaw       = 1<<testIDLen - 1
+)
+
+// splitSample encodes the values of the block with the k least significant bits sent apart.
+func (e *bitWriter) splitSample(k int, values []uint32) {
+	e.write(uint32(k+1), testIDLen)
+	for _, v := range values {
+		e.fs(v >> uint(k))
+	}
+	for _, v := range values {
+		e.write(v, k)
+	}
+}
+
+// secondExtension encodes the pairs of values of the block.
+func (e *bitWriter) secondExtension(values []uint32) {
+	e.write(testZeroBlock, testIDLen)
+	e.write(1, 1)
+	for i := 0; i < len(values); i += 2 {
+		d0, d1 := values[i], values[i+1]
+		e.fs((d0+d1)*(d0+d1+1)/2 + d1)
+	}
+}
+
+// zeroBlocks encodes a run of blocks of zeros, 5 being the remainder of the segment.
+func (e *bitWriter) zeroBlocks(fs uint32) {
+	e.write(testZeroBlock, testIDLen)
+	e.write(0, 1)
+	e.fs(fs)
+}
+
+// mapDelta applies the prediction error mapping of the unit delay predictor.
+func mapDelta(last, x, xmax uint32) uint32 {
+	theta := last
+	if xmax-last < theta {
+		theta = xmax - last
+	}
+
+	delta := int(x) - int(last)
+	switch {
+	case delta >= 0 && delta <= int(theta):
+		return uint32(2 * delta)
+	case delta < 0 && -delta <= int(theta):
+		return uint32(-2*delta - 1)
+	case delta < 0:
+		return theta + uint32(-delta)
+	default:
+		return theta + uint32(delta)
+	}
+}
+
+func decodeSamples(t *testing.T, rsi int, preprocess bool, input []byte, samples int) ([]uint32, error) {
+	t.Helper()
+
+	decoder, err := NewDecoder(testBits, testBlockSize, rsi, preprocess)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := make([]byte, samples*2)
+	err = decoder.Decode(input, output)
+
+	out := make([]uint32, samples)
+	for i := range out {
+		out[i] = uint32(binary.BigEndian.Uint16(output[i*2:]))
+	}
+	return out, err
+}
+
+func expectSamples(t *testing.T, got, want []uint32) {
+	t.Helper()
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("sample %d is %d, want %d (got %v)", i, got[i], want[i], got)
+		}
+	}
+}
+
+func TestDecodeOptions(t *testing.T) {
+	split := []uint32{3, 17, 0, 255, 64, 9, 130, 1}
+	pairs := []uint32{0, 0, 1, 2, 5, 0, 3, 3}
+	raw := []uint32{255, 0, 128, 127, 1, 254, 42, 7}
+
+	var w bitWriter
+	w.splitSample(2, split)
+	w.secondExtension(pairs)
+	w.write(testRaw, testIDLen)
+	for _, v := range raw {
+		w.write(v, testBits)
+	}
+	w.splitSample(0, split)
+
+	got, err := decodeSamples(t, 4, false, w.dat, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := append(append(append(append([]uint32(nil), split...), pairs...), raw...), split...)
+	expectSamples(t, got, want)
+}
+
+func TestDecodeZeroBlocks(t *testing.T) {
+	values := []uint32{1, 2, 3, 4, 5, 6, 7, 8}
+
+	// Two blocks of zeros after a coded block, then the remainder of the segment
+	// ends at the reference sample interval of 8 blocks.
+	var w bitWriter
+	w.splitSample(0, values)
+	w.zeroBlocks(1)
+	w.splitSample(0, values)
+	w.zeroBlocks(remainderOfSegment - 1)
+	w.splitSample(0, values)
+
+	got, err := decodeSamples(t, 8, false, w.dat, 9*testBlockSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := make([]uint32, 9*testBlockSize)
+	copy(want, values)
+	copy(want[3*testBlockSize:], values)
+	copy(want[8*testBlockSize:], values)
+	expectSamples(t, got, want)
+}
+
+func TestDecodeReferenceSample(t *testing.T) {
+	const xmax = 1<<testBits - 1
+	samples := []uint32{
+		100, 101, 99, 99, 250, 0, 128, 127,
+		127, 130, 120, 255, 255, 254, 3, 2,
+		// The next reference sample interval starts again with a reference sample.
+		50, 51, 52, 50, 49, 0, 255, 200,
+	}
+
+	var w bitWriter
+	for b := 0; b < len(samples)/testBlockSize; b++ {
+		block := samples[b*testBlockSize : (b+1)*testBlockSize]
+		start := 0
+		w.write(1, testIDLen)
+		if b%2 == 0 {
+			w.write(block[0], testBits)
+			start = 1
+		}
+		for i := start; i < testBlockSize; i++ {
+			last := samples[b*testBlockSize+i-1]
+			w.fs(mapDelta(last, block[i], xmax))
+		}
+	}
+
+	got, err := decodeSamples(t, 2, true, w.dat, len(samples))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectSamples(t, got, samples)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	values := []uint32{3, 17, 0, 255, 64, 9, 130, 1}
+
+	var complete bitWriter
+	complete.splitSample(3, values)
+
+	var extended bitWriter
+	extended.write(testZeroBlock, testIDLen)
+	extended.write(1, 1)
+	extended.fs(secondExtensionMax + 1)
+
+	var zeroRun bitWriter
+	zeroRun.splitSample(0, values)
+	zeroRun.zeroBlocks(2)
+
+	for _, tc := range []struct {
+		name  string
+		input []byte
+		want  error
+	}{
+		{"truncated", complete.dat[:len(complete.dat)-2], ErrTruncated},
+		{"empty", nil, ErrTruncated},
+		{"second extension", extended.dat, ErrInvalidExtended},
+		{"zero run", zeroRun.dat, ErrInvalidZeroRun},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := decodeSamples(t, 2, false, tc.input, 2*testBlockSize); err != tc.want {
+				t.Errorf("error %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
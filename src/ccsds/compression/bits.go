@@ -0,0 +1,53 @@
+package compression
+
+// bitReader reads MSB first bit fields from a byte slice.
+type bitReader struct {
+	dat []byte
+	pos int
+}
+
+func (e *bitReader) remaining() int {
+	return len(e.dat)*8 - e.pos
+}
+
+// read returns the next n bits (up to 32).
+func (e *bitReader) read(n int) (uint32, error) {
+	if e.remaining() < n {
+		return 0, ErrTruncated
+	}
+
+	var out uint32
+	for n > 0 {
+		offset := e.pos % 8
+		take := 8 - offset
+		if take > n {
+			take = n
+		}
+
+		b := uint32(e.dat[e.pos/8]>>uint(8-offset-take)) & (1<<uint(take) - 1)
+		out = out<<uint(take) | b
+
+		e.pos += take
+		n -= take
+	}
+	return out, nil
+}
+
+// readFS returns the value of a fundamental sequence codeword,
+// the number of zeros before the next one.
+func (e *bitReader) readFS() (uint32, error) {
+	var count uint32
+	for {
+		if e.remaining() < 1 {
+			return 0, ErrTruncated
+		}
+
+		bit := e.dat[e.pos/8] >> uint(7-e.pos%8) & 1
+		e.pos++
+
+		if bit == 1 {
+			return count, nil
+		}
+		count++
+	}
+}
@@ -0,0 +1,90 @@
+package ccsds
+
+const (
+	counterModulo = 1 << 24
+	// maxCounterGap bigger gaps are counters going backwards, like a
+	// recorder playback restarting, instead of lost frames.
+	maxCounterGap = counterModulo / 2
+
+	sequenceModulo = 1 << 14
+	maxSequenceGap = sequenceModulo / 2
+)
+
+// FrameCounter checks the continuity of the 24-bit virtual channel frame counter.
+// A jump of the counter is only taken as lost frames once the counter of the next
+// frame follows it, so corrupted counters don't add spurious losses.
+type FrameCounter struct {
+	last    uint32
+	pending uint32
+	skipped uint32
+	jumped  bool
+	started bool
+}
+
+// Next registers the counter of a received frame. It returns how many frames of the
+// virtual channel were lost before it or, when the jump is only confirmed by this frame,
+// before the previous one. The continuity with the previous frame is broken when the
+// counter doesn't follow it, even if the jump isn't confirmed yet.
+func (e *FrameCounter) Next(count uint32) (lost uint32, broken bool) {
+	count &= counterModulo - 1
+
+	if !e.started {
+		e.last, e.started = count, true
+		return 0, false
+	}
+
+	if !e.jumped {
+		if count == (e.last+1)&(counterModulo-1) {
+			e.last = count
+			return 0, false
+		}
+
+		e.pending, e.skipped, e.jumped = count, 0, true
+		return 0, true
+	}
+
+	// The jump of the previous frame is confirmed. The frames received since the last
+	// valid counter aren't lost, their counters were corrupted.
+	if count == (e.pending+1)&(counterModulo-1) {
+		gap := (e.pending - e.last - 1) & (counterModulo - 1)
+		e.last, e.jumped = count, false
+		if gap >= maxCounterGap || gap < e.skipped {
+			return 0, false
+		}
+		return gap - e.skipped, false
+	}
+
+	// The counter of the previous frame was corrupted too.
+	e.skipped++
+	if count == (e.last+1+e.skipped)&(counterModulo-1) {
+		e.last, e.jumped = count, false
+		return 0, false
+	}
+
+	e.pending = count
+	return 0, true
+}
+
+// PacketCounter checks the continuity of the 14-bit packet sequence count of an APID.
+type PacketCounter struct {
+	last    uint16
+	started bool
+}
+
+// Next registers the sequence count of a received packet and returns
+// how many packets of the APID were lost before it.
+func (e *PacketCounter) Next(count uint16) uint16 {
+	count &= sequenceModulo - 1
+	last, started := e.last, e.started
+	e.last, e.started = count, true
+
+	if !started {
+		return 0
+	}
+
+	gap := (count - last - 1) & (sequenceModulo - 1)
+	if gap >= maxSequenceGap {
+		return 0
+	}
+	return gap
+}
@@ -0,0 +1,38 @@
+package ccsds
+
+import "testing"
+
+func TestFrameCounter(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		counts   []uint32
+		lost     uint32
+		breakups int
+	}{
+		{"continuous", []uint32{10, 11, 12, 13}, 0, 0},
+		{"wrap around", []uint32{counterModulo - 2, counterModulo - 1, 0, 1}, 0, 0},
+		{"lost frames", []uint32{10, 11, 15, 16, 17}, 3, 1},
+		{"corrupted counter", []uint32{10, 11, 0x800000, 13, 14}, 0, 1},
+		{"corrupted counters", []uint32{10, 11, 0x800000, 0x123456, 15, 16}, 1, 3},
+		{"playback restart", []uint32{10, 11, 3, 4, 5}, 0, 1},
+		{"unconfirmed last frame", []uint32{10, 11, 0x800000}, 0, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var counter FrameCounter
+			var lost uint32
+			var breakups int
+
+			for _, count := range tc.counts {
+				l, broken := counter.Next(count)
+				lost += l
+				if broken {
+					breakups++
+				}
+			}
+
+			if lost != tc.lost || breakups != tc.breakups {
+				t.Errorf("%d frames lost and %d breaks, expected %d and %d", lost, breakups, tc.lost, tc.breakups)
+			}
+		})
+	}
+}
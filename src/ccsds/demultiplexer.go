@@ -0,0 +1,148 @@
+package ccsds
+
+import (
+	"fmt"
+	"sort"
+	"weatherdump/src/ccsds/frames"
+)
+
+// VirtualChannel identifies the packet stream of a spacecraft.
+type VirtualChannel struct {
+	SCID uint8
+	VCID uint8
+}
+
+type virtualChannelState struct {
+	worker     *Worker
+	counter    FrameCounter
+	frames     int
+	lostFrames uint32
+}
+
+// VirtualChannelStatistics of the frames and packets of a virtual channel.
+type VirtualChannelStatistics struct {
+	VirtualChannel
+	Frames         int
+	LostFrames     int
+	Packets        int
+	DroppedPackets int
+}
+
+// Demultiplexer keeps an independent packet reassembly worker for each
+// virtual channel and routes the finished packets to the APID subscribers.
+// Frame counter gaps drop the packet being assembled in the virtual channel.
+type Demultiplexer struct {
+	version     int
+	current     VirtualChannel
+	channels    map[VirtualChannel]*virtualChannelState
+	subscribers map[uint16][]PacketHandler
+}
+
+// NewDemultiplexer creates a demultiplexer for the CCSDS version informed.
+func NewDemultiplexer(version int) *Demultiplexer {
+	return &Demultiplexer{
+		version:     version,
+		channels:    make(map[VirtualChannel]*virtualChannelState),
+		subscribers: make(map[uint16][]PacketHandler),
+	}
+}
+
+// Subscribe the handler to the packets of the APID from any virtual channel.
+// Handlers subscribed to the same APID share the packet data.
+func (e *Demultiplexer) Subscribe(apid uint16, handler PacketHandler) {
+	e.subscribers[apid] = append(e.subscribers[apid], handler)
+}
+
+// SubscribeRange subscribes the handler to all APIDs between first and last.
+func (e *Demultiplexer) SubscribeRange(first, last uint16, handler PacketHandler) {
+	for apid := first; apid <= last; apid++ {
+		e.Subscribe(apid, handler)
+	}
+}
+
+// ParseFrame feeds the multiplexing data of the transfer frame into the
+// worker of its virtual channel.
+func (e *Demultiplexer) ParseFrame(frame *frames.TransferFrame) {
+	mpdu := frames.NewMultiplexingFrame(e.version, frame.GetMPDU())
+	if !mpdu.IsValid() {
+		return
+	}
+
+	vc := VirtualChannel{frame.GetSCID(), frame.GetVCID()}
+	state, ok := e.channels[vc]
+	if !ok {
+		state = &virtualChannelState{worker: New(e.dispatch)}
+		e.channels[vc] = state
+	}
+
+	lost, broken := state.counter.Next(frame.GetVirtualChannelCount())
+	state.lostFrames += lost
+	if broken {
+		state.worker.Reset()
+	}
+	state.frames++
+
+	e.current = vc
+	state.worker.ParseMPDU(*mpdu)
+}
+
+// GetChannel returns the virtual channel of the frame being parsed.
+// Packet handlers can use it to know where the packet came from.
+func (e Demultiplexer) GetChannel() VirtualChannel {
+	return e.current
+}
+
+// GetPacketCount returns the number of packets assembled from all virtual channels.
+func (e Demultiplexer) GetPacketCount() int {
+	count := 0
+	for _, state := range e.channels {
+		count += state.worker.GetPacketCount()
+	}
+	return count
+}
+
+// GetLostFrameCount returns the number of frames lost in all virtual channels.
+func (e Demultiplexer) GetLostFrameCount() int {
+	count := 0
+	for _, state := range e.channels {
+		count += int(state.lostFrames)
+	}
+	return count
+}
+
+// GetStatistics returns the statistics of each virtual channel sorted by SCID and VCID.
+func (e Demultiplexer) GetStatistics() []VirtualChannelStatistics {
+	stats := make([]VirtualChannelStatistics, 0, len(e.channels))
+	for vc, state := range e.channels {
+		stats = append(stats, VirtualChannelStatistics{
+			VirtualChannel: vc,
+			Frames:         state.frames,
+			LostFrames:     int(state.lostFrames),
+			Packets:        state.worker.GetPacketCount(),
+			DroppedPackets: state.worker.GetDroppedPacketCount(),
+		})
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].SCID != stats[j].SCID {
+			return stats[i].SCID < stats[j].SCID
+		}
+		return stats[i].VCID < stats[j].VCID
+	})
+
+	return stats
+}
+
+// PrintStatistics prints the number of packets assembled and frames lost by each virtual channel.
+func (e Demultiplexer) PrintStatistics() {
+	for _, s := range e.GetStatistics() {
+		fmt.Printf("[PRC] Decoded %d packets from SCID %d VCID %d (%d frames lost, %d partial packets dropped).\n",
+			s.Packets, s.SCID, s.VCID, s.LostFrames, s.DroppedPackets)
+	}
+}
+
+func (e *Demultiplexer) dispatch(packet frames.SpacePacketFrame) {
+	for _, handler := range e.subscribers[packet.GetAPID()] {
+		handler(packet)
+	}
+}
@@ -0,0 +1,94 @@
+package ccsds
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"weatherdump/src/ccsds/frames"
+)
+
+// PacketDumper writes the packets of each APID to its own file with the
+// CCSDS packet stream layout, the primary header followed by the data.
+// Incomplete packets are skipped, so the files can be read by other tools.
+type PacketDumper struct {
+	path    string
+	files   map[uint16]*os.File
+	packets map[uint16]int
+	skipped int
+	err     error
+}
+
+// NewPacketDumper creates a dumper saving the files inside the path.
+func NewPacketDumper(path string) *PacketDumper {
+	return &PacketDumper{
+		path:    path,
+		files:   make(map[uint16]*os.File),
+		packets: make(map[uint16]int),
+	}
+}
+
+// Subscribe saves the packets of the APIDs parsed by the demultiplexer. The
+// first write error stops the dumper and is returned by Close.
+func (e *PacketDumper) Subscribe(demux *Demultiplexer, apids []uint16) {
+	for _, apid := range apids {
+		demux.Subscribe(apid, e.handle)
+	}
+}
+
+func (e *PacketDumper) handle(packet frames.SpacePacketFrame) {
+	if e.err == nil {
+		e.err = e.Write(packet)
+	}
+}
+
+// Write the packet to the file of its APID, creating it if needed.
+func (e *PacketDumper) Write(packet frames.SpacePacketFrame) error {
+	if !packet.IsValid() {
+		e.skipped++
+		return nil
+	}
+
+	apid := packet.GetAPID()
+	if e.files[apid] == nil {
+		file, err := os.Create(filepath.Join(e.path, fmt.Sprintf("packets_%04d.bin", apid)))
+		if err != nil {
+			return err
+		}
+		e.files[apid] = file
+	}
+
+	if _, err := e.files[apid].Write(packet.ToBinary()); err != nil {
+		return err
+	}
+
+	e.packets[apid]++
+	return nil
+}
+
+// Close all files created by the dumper.
+func (e *PacketDumper) Close() error {
+	err := e.err
+	for _, file := range e.files {
+		if cerr := file.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// PrintStatistics prints the number of packets saved for each APID.
+func (e PacketDumper) PrintStatistics() {
+	apids := make([]int, 0, len(e.packets))
+	for apid := range e.packets {
+		apids = append(apids, int(apid))
+	}
+	sort.Ints(apids)
+
+	for _, apid := range apids {
+		fmt.Printf("[PRC] Saved %d raw packets of APID %d in %s.\n", e.packets[uint16(apid)], apid, e.files[uint16(apid)].Name())
+	}
+	if e.skipped > 0 {
+		fmt.Printf("[PRC] Skipped %d incomplete packets while saving raw packets.\n", e.skipped)
+	}
+}
@@ -35,13 +35,24 @@ func (e *SpacePacketFrame) FromBinary(dat []byte) {
 	e.sequenceFlags = uint8(binary.BigEndian.Uint16(dat[2:]) & 0xC000 >> 14)
 	e.packetSeqCount = binary.BigEndian.Uint16(dat[2:]) & 0x3FFF
 	e.packetDataLength = binary.BigEndian.Uint16(dat[4:])
-	e.packetData = dat[6:]
+	e.packetData = append([]byte(nil), dat[6:]...)
 
 	if uint16(len(e.packetData)) > e.packetDataLength+1 {
 		e.packetData = e.packetData[:e.packetDataLength+1]
 	}
 }
 
+// ToBinary returns the primary header followed by the packet data,
+// the same layout of the packet inside the CCSDS packet stream.
+func (e SpacePacketFrame) ToBinary() []byte {
+	dat := make([]byte, spacePacketFrameMinimum, spacePacketFrameMinimum+len(e.packetData))
+	dat[0] = e.versionNumber<<5 | e.typeIndicator<<4 | e.secondaryHeaderFlag<<3 | uint8(e.APID>>8)&0x07
+	dat[1] = uint8(e.APID)
+	binary.BigEndian.PutUint16(dat[2:], uint16(e.sequenceFlags)<<14|e.packetSeqCount&0x3FFF)
+	binary.BigEndian.PutUint16(dat[4:], e.packetDataLength)
+	return append(dat, e.packetData...)
+}
+
 // FeedData receives chunks of data and append it to the current data.
 func (e *SpacePacketFrame) FeedData(dat []byte) []byte {
 	currentData := (e.packetDataLength + 1)
@@ -83,6 +94,11 @@ func (e SpacePacketFrame) GetSequenceFlags() uint8 {
 	return e.sequenceFlags
 }
 
+// HasSecondaryHeader returns if the packet data starts with a secondary header.
+func (e SpacePacketFrame) HasSecondaryHeader() bool {
+	return e.secondaryHeaderFlag == 0x01
+}
+
 // IsValid checks if the current packet is valid by comparing the data size.
 // This is helpful to identify corrupted packets.
 func (e SpacePacketFrame) IsValid() bool {
@@ -40,6 +40,11 @@ func (e *TransferFrame) FromBinary(dat []byte) {
 	e.MPDU = dat[6:892]
 }
 
+// GetVersion returns the transfer frame version number of the current frame.
+func (e TransferFrame) GetVersion() uint8 {
+	return e.versionNumber
+}
+
 // IsReplay returns if the current frame is replay.
 func (e TransferFrame) IsReplay() bool {
 	return e.replayFlag == 0x01
@@ -55,6 +60,11 @@ func (e TransferFrame) GetVCID() uint8 {
 	return e.VCID
 }
 
+// GetVirtualChannelCount returns the 24-bit frame counter of the virtual channel.
+func (e TransferFrame) GetVirtualChannelCount() uint32 {
+	return e.virtualChannelCount
+}
+
 // GetSCID returns the SCID of the current frame.
 func (e TransferFrame) GetSCID() uint8 {
 	return e.SCID
@@ -0,0 +1,237 @@
+// Package pds writes space packets as NASA Production Data Sets (PDS), the
+// level-0 layout produced by EDOS and RT-STPS for the EOS and JPSS missions.
+// Each data set has a packet file followed by a construction record file
+// describing the APIDs, times and sequence gaps found inside it.
+package pds
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"weatherdump/src/ccsds"
+	"weatherdump/src/ccsds/frames"
+)
+
+const (
+	nameSize    = 12
+	timeSize    = 8
+	softwareMaj = 1
+	softwareMin = 0
+	recordType  = 1
+)
+
+// cdsEpoch of the CCSDS day segmented time code used by the packets.
+var cdsEpoch = time.Date(1958, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type timeCode [timeSize]byte
+
+func newTimeCode(t time.Time) timeCode {
+	var tc timeCode
+	d := t.Sub(cdsEpoch)
+	day := d / (24 * time.Hour)
+	d -= day * 24 * time.Hour
+	binary.BigEndian.PutUint16(tc[0:], uint16(day))
+	binary.BigEndian.PutUint32(tc[2:], uint32(d/time.Millisecond))
+	binary.BigEndian.PutUint16(tc[6:], uint16(d%time.Millisecond/time.Microsecond))
+	return tc
+}
+
+func (e timeCode) time() time.Time {
+	day := time.Duration(binary.BigEndian.Uint16(e[0:])) * 24 * time.Hour
+	millis := time.Duration(binary.BigEndian.Uint32(e[2:])) * time.Millisecond
+	micros := time.Duration(binary.BigEndian.Uint16(e[6:])) * time.Microsecond
+	return cdsEpoch.Add(day + millis + micros)
+}
+
+type gap struct {
+	firstMissing uint32
+	offset       uint64
+	missing      uint32
+	preceding    timeCode
+	following    timeCode
+}
+
+type apidRecord struct {
+	scid       uint8
+	apid       uint16
+	offset     uint64
+	vcids      map[uint8]bool
+	gaps       []*gap
+	packets    uint32
+	octets     uint64
+	mismatches uint32
+	first      timeCode
+	last       timeCode
+	counter    ccsds.PacketCounter
+	lastSeq    uint16
+	pending    *gap
+}
+
+// Writer saves the packets of a group of APIDs as a production data set.
+// The files are named after the spacecraft, the key APID of the group and
+// the time of the first packet, e.g. P1590826VIIRSSCIENCEAS19001120000001.PDS.
+type Writer struct {
+	path       string
+	key        uint16
+	name       string
+	file       *os.File
+	scid       uint8
+	offset     uint64
+	mismatches uint32
+	first      timeCode
+	last       timeCode
+	apids      map[uint16]*apidRecord
+}
+
+// NewWriter creates a writer for the group identified by the key APID.
+// The name is up to 12 characters describing the group, like VIIRSSCIENCE.
+func NewWriter(path string, key uint16, name string) *Writer {
+	return &Writer{
+		path:  path,
+		key:   key,
+		name:  name,
+		apids: make(map[uint16]*apidRecord),
+	}
+}
+
+// GetPacketCount returns the number of packets saved in the data set.
+func (e Writer) GetPacketCount() int {
+	count := 0
+	for _, r := range e.apids {
+		count += int(r.packets)
+	}
+	return count
+}
+
+// Write appends the packet received from the virtual channel to the data set.
+// Incomplete packets are counted as length mismatches and aren't saved.
+func (e *Writer) Write(vc ccsds.VirtualChannel, packet frames.SpacePacketFrame) error {
+	if !packet.IsValid() {
+		e.mismatches++
+		if r := e.apids[packet.GetAPID()]; r != nil {
+			r.mismatches++
+		}
+		return nil
+	}
+
+	if e.file == nil {
+		file, err := os.Create(e.tempName())
+		if err != nil {
+			return err
+		}
+		e.file = file
+		e.scid = vc.SCID
+	}
+
+	apid := packet.GetAPID()
+	r := e.apids[apid]
+	if r == nil {
+		r = &apidRecord{scid: vc.SCID, apid: apid, offset: e.offset, vcids: make(map[uint8]bool)}
+		e.apids[apid] = r
+	}
+
+	if missing := r.counter.Next(packet.GetSequenceCount()); missing > 0 {
+		r.pending = &gap{
+			firstMissing: uint32((r.lastSeq + 1) & 0x3FFF),
+			offset:       e.offset,
+			missing:      uint32(missing),
+			preceding:    r.last,
+		}
+		r.gaps = append(r.gaps, r.pending)
+	}
+	r.lastSeq = packet.GetSequenceCount()
+	r.vcids[vc.VCID] = true
+
+	if data := packet.GetData(); packet.HasSecondaryHeader() && len(data) >= timeSize {
+		var t timeCode
+		copy(t[:], data)
+
+		if r.first == (timeCode{}) {
+			r.first = t
+		}
+		if e.first == (timeCode{}) {
+			e.first = t
+		}
+		if r.pending != nil {
+			r.pending.following = t
+			r.pending = nil
+		}
+		r.last, e.last = t, t
+	}
+
+	dat := packet.ToBinary()
+	if _, err := e.file.Write(dat); err != nil {
+		return err
+	}
+
+	r.packets++
+	r.octets += uint64(len(dat))
+	e.offset += uint64(len(dat))
+	return nil
+}
+
+// Close the packet file and write the construction record. The name of the
+// packet file is returned, it's empty when the data set has no packets.
+func (e *Writer) Close() (string, error) {
+	if e.file == nil {
+		return "", nil
+	}
+
+	if err := e.file.Close(); err != nil {
+		return "", err
+	}
+
+	packetFile := filepath.Join(e.path, e.fileName(1))
+	if err := os.Rename(e.tempName(), packetFile); err != nil {
+		return "", err
+	}
+
+	record := e.constructionRecord(time.Now())
+	if err := writeFile(filepath.Join(e.path, e.fileName(0)), record); err != nil {
+		return "", err
+	}
+
+	return packetFile, nil
+}
+
+func (e Writer) tempName() string {
+	return filepath.Join(e.path, fmt.Sprintf(".pds_%04d.tmp", e.key))
+}
+
+// identifier of the data set file number, e.g. P1590826VIIRSSCIENCEAS19001120000000.
+func (e Writer) identifier(number int) string {
+	name := e.name + strings.Repeat("A", nameSize-len(e.name))
+	return fmt.Sprintf("P%03d%04d%sAS%s%03d", e.scid, e.key, name, e.first.time().Format("06002150405"), number)
+}
+
+func (e Writer) fileName(number int) string {
+	return e.identifier(number) + ".PDS"
+}
+
+func (e Writer) sortedAPIDs() []*apidRecord {
+	records := make([]*apidRecord, 0, len(e.apids))
+	for _, r := range e.apids {
+		records = append(records, r)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].apid < records[j].apid
+	})
+	return records
+}
+
+func writeFile(name string, dat []byte) error {
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(dat); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
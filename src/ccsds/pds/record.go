@@ -0,0 +1,141 @@
+package pds
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+	"time"
+)
+
+const (
+	identifierSize = 36
+	fileNameSize   = 40
+)
+
+// constructionRecord builds the record describing the data set, following the
+// EDOS layout: the data set totals, the details of each APID with its virtual
+// channels and sequence gaps, and the list of files of the data set.
+// The EDOS service header times aren't known and are left empty.
+func (e Writer) constructionRecord(completion time.Time) []byte {
+	var b bytes.Buffer
+	records := e.sortedAPIDs()
+
+	var packets, gaps uint32
+	var octets uint64
+	for _, r := range records {
+		packets += r.packets
+		octets += r.octets
+		gaps += uint32(len(r.gaps))
+	}
+
+	b.Write([]byte{softwareMaj, softwareMin, recordType, 0})
+	b.WriteString(padString(e.identifier(0), identifierSize))
+	b.WriteByte(0) // test flag
+	b.Write(make([]byte, 9))
+
+	// A single spacecraft session from the first to the last packet.
+	putUint16(&b, 1)
+	b.Write(e.first[:])
+	b.Write(e.last[:])
+
+	putUint64(&b, 0) // octets of fill data
+	putUint32(&b, e.mismatches)
+	b.Write(e.first[:])
+	b.Write(e.last[:])
+	b.Write(make([]byte, 2*timeSize))
+	putUint32(&b, 0) // packets corrected by Reed-Solomon
+	putUint32(&b, packets)
+	putUint64(&b, octets)
+	putUint32(&b, gaps)
+	tc := newTimeCode(completion)
+	b.Write(tc[:])
+	b.Write(make([]byte, 7))
+	b.WriteByte(uint8(len(records)))
+
+	for _, r := range records {
+		b.Write([]byte{0, r.scid})
+		putUint16(&b, r.apid)
+		putUint64(&b, r.offset)
+		b.Write(make([]byte, 3))
+
+		vcids := make([]int, 0, len(r.vcids))
+		for vcid := range r.vcids {
+			vcids = append(vcids, int(vcid))
+		}
+		sort.Ints(vcids)
+
+		b.WriteByte(uint8(len(vcids)))
+		for _, vcid := range vcids {
+			putUint16(&b, 0)
+			putUint16(&b, 0x4000|uint16(r.scid)<<6|uint16(vcid))
+		}
+
+		putUint32(&b, uint32(len(r.gaps)))
+		for _, g := range r.gaps {
+			putUint32(&b, g.firstMissing)
+			putUint64(&b, g.offset)
+			putUint32(&b, g.missing)
+			b.Write(g.preceding[:])
+			b.Write(g.following[:])
+			b.Write(make([]byte, 2*timeSize))
+		}
+
+		putUint32(&b, 0) // fill data entries
+		putUint64(&b, 0) // octets of fill data
+		putUint32(&b, r.mismatches)
+		b.Write(r.first[:])
+		b.Write(r.last[:])
+		b.Write(make([]byte, 2*timeSize))
+		putUint32(&b, 0) // packets corrected by Reed-Solomon
+		putUint32(&b, r.packets)
+		putUint64(&b, r.octets)
+		b.Write(make([]byte, 8))
+	}
+
+	b.Write(make([]byte, 3))
+	b.WriteByte(2)
+
+	// The construction record lists itself without APIDs.
+	b.WriteString(padString(e.fileName(0), fileNameSize))
+	b.Write(make([]byte, 3))
+	b.WriteByte(0)
+	b.Write(make([]byte, 4+2*timeSize+4))
+
+	b.WriteString(padString(e.fileName(1), fileNameSize))
+	b.Write(make([]byte, 3))
+	b.WriteByte(uint8(len(records)))
+	for _, r := range records {
+		b.Write([]byte{0, r.scid})
+		putUint16(&b, r.apid)
+		b.Write(r.first[:])
+		b.Write(r.last[:])
+		b.Write(make([]byte, 4))
+	}
+
+	return b.Bytes()
+}
+
+func padString(s string, size int) string {
+	if len(s) > size {
+		return s[:size]
+	}
+	return s + string(make([]byte, size-len(s)))
+}
+
+func putUint16(b *bytes.Buffer, v uint16) {
+	var buf [2]byte
+	binary.BigEndian.PutUint16(buf[:], v)
+	b.Write(buf[:])
+}
+
+func putUint32(b *bytes.Buffer, v uint32) {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], v)
+	b.Write(buf[:])
+}
+
+func putUint64(b *bytes.Buffer, v uint64) {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+	b.Write(buf[:])
+}
@@ -0,0 +1,33 @@
+package ccsds
+
+import (
+	"io"
+	"weatherdump/src/ccsds/frames"
+)
+
+// ProbeFrames returns the fraction of the transfer frames read from the input
+// that are accepted by the function and continue the frame counter of their
+// virtual channel. It's used to recognize files of already decoded frames.
+func ProbeFrames(input io.Reader, accept func(frame *frames.TransferFrame) bool) (float64, error) {
+	last := make(map[VirtualChannel]uint32)
+	valid, total := 0, 0
+
+	err := ReadFrames(input, func(frame *frames.TransferFrame) {
+		total++
+		if !accept(frame) {
+			return
+		}
+
+		vc := VirtualChannel{frame.GetSCID(), frame.GetVCID()}
+		count := frame.GetVirtualChannelCount()
+		if prev, ok := last[vc]; !ok || count == (prev+1)&(counterModulo-1) {
+			valid++
+		}
+		last[vc] = count
+	})
+
+	if err != nil || total == 0 {
+		return 0, err
+	}
+	return float64(valid) / float64(total), nil
+}
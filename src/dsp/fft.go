@@ -0,0 +1,58 @@
+package dsp
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// FFTSize returns the size of the transform of n samples, the next power of two.
+func FFTSize(n int) int {
+	size := 1
+	for size < n {
+		size <<= 1
+	}
+	return size
+}
+
+// FFT returns the discrete Fourier transform of the samples. The input
+// is zero padded to the next power of two, the size of the output.
+func FFT(samples []complex64) []complex64 {
+	n := FFTSize(len(samples))
+
+	buf := make([]complex128, n)
+	for i, s := range samples {
+		buf[i] = complex128(s)
+	}
+
+	// Bit reversal permutation.
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+
+		if i < j {
+			buf[i], buf[j] = buf[j], buf[i]
+		}
+	}
+
+	// Iterative radix-2 butterflies.
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				a, b := buf[start+k], buf[start+k+size/2]*w
+				buf[start+k], buf[start+k+size/2] = a+b, a-b
+				w *= step
+			}
+		}
+	}
+
+	res := make([]complex64, n)
+	for i, v := range buf {
+		res[i] = complex64(v)
+	}
+	return res
+}
@@ -0,0 +1,122 @@
+package dsp
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// AGC keeps the average magnitude of the samples close to the reference.
+type AGC struct {
+	rate      float32
+	reference float32
+	gain      float32
+	maxGain   float32
+}
+
+// NewAGC creates a feedback automatic gain control.
+func NewAGC(rate, reference float32) *AGC {
+	return &AGC{rate: rate, reference: reference, gain: 1, maxGain: 65536}
+}
+
+// Work applies the gain to the samples in place.
+func (e *AGC) Work(samples []complex64) {
+	for i, s := range samples {
+		s *= complex(e.gain, 0)
+		samples[i] = s
+
+		e.gain += e.rate * (e.reference - float32(cmplx.Abs(complex128(s))))
+		if e.gain > e.maxGain {
+			e.gain = e.maxGain
+		}
+		if e.gain < 0 {
+			e.gain = 1e-5
+		}
+	}
+}
+
+// FIR filter with real taps for complex samples.
+type FIR struct {
+	taps    []float32
+	history []complex64
+}
+
+// NewFIR creates a filter with the taps informed.
+func NewFIR(taps []float32) *FIR {
+	return &FIR{
+		taps:    taps,
+		history: make([]complex64, len(taps)-1),
+	}
+}
+
+// NewRRC creates a root-raised-cosine matched filter spanning the given number of symbols.
+func NewRRC(sampleRate, symbolRate, alpha float64, span int) *FIR {
+	return NewFIR(RRCTaps(sampleRate, symbolRate, alpha, int(float64(span)*sampleRate/symbolRate)))
+}
+
+// Work returns the filtered samples. The filter keeps its state between calls.
+func (e *FIR) Work(samples []complex64) []complex64 {
+	buf := append(e.history, samples...)
+	out := make([]complex64, len(samples))
+	ntaps := len(e.taps)
+
+	for i := range out {
+		var re, im float32
+		window := buf[i : i+ntaps]
+		for j, t := range e.taps {
+			s := window[ntaps-1-j]
+			re += real(s) * t
+			im += imag(s) * t
+		}
+		out[i] = complex(re, im)
+	}
+
+	e.history = append(e.history[:0], buf[len(buf)-ntaps+1:]...)
+	return out
+}
+
+// RRCTaps designs a root-raised-cosine filter with unity DC gain.
+// This follows the same design as the GNU Radio firdes.root_raised_cosine.
+func RRCTaps(sampleRate, symbolRate, alpha float64, ntaps int) []float32 {
+	ntaps |= 1
+	spb := sampleRate / symbolRate
+	taps := make([]float64, ntaps)
+	scale := 0.0
+
+	for i := 0; i < ntaps; i++ {
+		xindx := float64(i - ntaps/2)
+		x1 := math.Pi * xindx / spb
+		x2 := 4 * alpha * xindx / spb
+		x3 := x2*x2 - 1
+
+		var num, den float64
+		if math.Abs(x3) >= 0.000001 {
+			if i != ntaps/2 {
+				num = math.Cos((1+alpha)*x1) + math.Sin((1-alpha)*x1)/(4*alpha*xindx/spb)
+			} else {
+				num = math.Cos((1+alpha)*x1) + (1-alpha)*math.Pi/(4*alpha)
+			}
+			den = x3 * math.Pi
+		} else {
+			if alpha == 1 {
+				taps[i] = -1
+				scale += taps[i]
+				continue
+			}
+			x3 = (1 - alpha) * x1
+			x2 = (1 + alpha) * x1
+			num = math.Sin(x2)*(1+alpha)*math.Pi -
+				math.Cos(x3)*((1-alpha)*math.Pi*spb)/(4*alpha*xindx) +
+				math.Sin(x3)*spb*spb/(4*alpha*xindx*xindx)
+			den = -32 * math.Pi * alpha * alpha * xindx / spb
+		}
+
+		taps[i] = 4 * alpha * num / den
+		scale += taps[i]
+	}
+
+	out := make([]float32, ntaps)
+	for i := range taps {
+		out[i] = float32(taps[i] / scale)
+	}
+	return out
+}
@@ -0,0 +1,164 @@
+package dsp
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+)
+
+// SampleFormat of the interleaved IQ samples inside a recording.
+type SampleFormat int
+
+// Supported sample formats.
+const (
+	Float32 SampleFormat = iota
+	Int16
+	Int8
+	Uint8
+)
+
+var sampleSize = map[SampleFormat]int{
+	Float32: 8,
+	Int16:   4,
+	Int8:    2,
+	Uint8:   2,
+}
+
+var rawExtensions = map[string]SampleFormat{
+	".cf32": Float32,
+	".fc32": Float32,
+	".cs16": Int16,
+	".sc16": Int16,
+	".cs8":  Int8,
+	".sc8":  Int8,
+	".cu8":  Uint8,
+}
+
+// ErrUnknownSampleRate is returned when a raw recording is opened without a sample rate.
+var ErrUnknownSampleRate = errors.New("sample rate of the raw IQ recording is unknown")
+
+// IQReader reads complex samples from a WAV or raw baseband recording.
+type IQReader struct {
+	input      io.Reader
+	format     SampleFormat
+	SampleRate float64
+	buf        []byte
+}
+
+// NewIQReader creates a reader for the recording. WAV files are detected by the
+// extension and carry their own format and sample rate. Raw files use the extension
+// to find the sample format (cf32, cs16, cs8 or cu8, defaulting to cf32) and need
+// the sample rate to be informed.
+func NewIQReader(input io.Reader, path string, sampleRate float64) (*IQReader, error) {
+	e := IQReader{input: input, format: Float32, SampleRate: sampleRate}
+	ext := strings.ToLower(filepath.Ext(path))
+
+	if ext == ".wav" {
+		if err := e.parseWAVHeader(); err != nil {
+			return nil, err
+		}
+	} else if format, ok := rawExtensions[ext]; ok {
+		e.format = format
+	}
+
+	if e.SampleRate <= 0 {
+		return nil, ErrUnknownSampleRate
+	}
+	return &e, nil
+}
+
+func (e *IQReader) parseWAVHeader() error {
+	var header [12]byte
+	if _, err := io.ReadFull(e.input, header[:]); err != nil {
+		return err
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return errors.New("invalid WAV header")
+	}
+
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(e.input, chunk[:]); err != nil {
+			return err
+		}
+		size := int64(binary.LittleEndian.Uint32(chunk[4:]))
+
+		switch string(chunk[0:4]) {
+		case "fmt ":
+			fmtChunk := make([]byte, size+size%2)
+			if _, err := io.ReadFull(e.input, fmtChunk); err != nil {
+				return err
+			}
+			if len(fmtChunk) < 16 {
+				return errors.New("invalid WAV format chunk")
+			}
+
+			audioFormat := binary.LittleEndian.Uint16(fmtChunk[0:])
+			channels := binary.LittleEndian.Uint16(fmtChunk[2:])
+			bitsPerSample := binary.LittleEndian.Uint16(fmtChunk[14:])
+
+			if audioFormat == 0xFFFE && len(fmtChunk) >= 26 {
+				audioFormat = binary.LittleEndian.Uint16(fmtChunk[24:])
+			}
+			if channels != 2 {
+				return errors.New("WAV recording should have two channels (I and Q)")
+			}
+
+			switch {
+			case audioFormat == 3 && bitsPerSample == 32:
+				e.format = Float32
+			case audioFormat == 1 && bitsPerSample == 16:
+				e.format = Int16
+			case audioFormat == 1 && bitsPerSample == 8:
+				e.format = Uint8
+			default:
+				return errors.New("unsupported WAV sample format")
+			}
+
+			e.SampleRate = float64(binary.LittleEndian.Uint32(fmtChunk[4:]))
+		case "data":
+			return nil
+		default:
+			if _, err := io.CopyN(ioutil.Discard, e.input, size+size%2); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ReadSamples fills the slice with the next samples normalized to the [-1, 1] range.
+func (e *IQReader) ReadSamples(dst []complex64) (int, error) {
+	size := sampleSize[e.format]
+	if cap(e.buf) < len(dst)*size {
+		e.buf = make([]byte, len(dst)*size)
+	}
+
+	n, err := io.ReadFull(e.input, e.buf[:len(dst)*size])
+	if err == io.ErrUnexpectedEOF && n >= size {
+		err = nil
+	}
+
+	n /= size
+	buf := e.buf
+	for i := 0; i < n; i++ {
+		switch e.format {
+		case Float32:
+			dst[i] = complex(
+				math.Float32frombits(binary.LittleEndian.Uint32(buf[i*8:])),
+				math.Float32frombits(binary.LittleEndian.Uint32(buf[i*8+4:])))
+		case Int16:
+			dst[i] = complex(
+				float32(int16(binary.LittleEndian.Uint16(buf[i*4:])))/32768,
+				float32(int16(binary.LittleEndian.Uint16(buf[i*4+2:])))/32768)
+		case Int8:
+			dst[i] = complex(float32(int8(buf[i*2]))/128, float32(int8(buf[i*2+1]))/128)
+		case Uint8:
+			dst[i] = complex((float32(buf[i*2])-127.5)/128, (float32(buf[i*2+1])-127.5)/128)
+		}
+	}
+	return n, err
+}
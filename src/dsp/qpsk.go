@@ -0,0 +1,82 @@
+package dsp
+
+const (
+	defaultBlockSize     = 8192
+	defaultAGCRate       = 1e-4
+	defaultSymbolAGCRate = 1e-3
+	defaultRRCSpan       = 31
+	defaultCostasBW      = 0.0175
+	defaultClockAlpha    = 0.0035
+	defaultClockLimit    = 0.005
+	defaultSoftSymbolAmp = 90
+)
+
+// QPSKDemodulator turns baseband IQ samples into soft-symbols. The output is the same
+// interleaved signed 8-bit I and Q stream written by the GNU Radio demodulators.
+type QPSKDemodulator struct {
+	input   *IQReader
+	agc     *AGC
+	rrc     *FIR
+	symAGC  *AGC
+	clock   *Gardner
+	costas  *Costas
+	samples []complex64
+	output  []byte
+	err     error
+}
+
+// NewQPSKDemodulator creates a demodulator with AGC, RRC matched filter,
+// Gardner timing recovery and a Costas loop, in this order. A second AGC
+// normalizes the symbols since the matched filter changes their amplitude.
+func NewQPSKDemodulator(input *IQReader, symbolRate, alpha float64) *QPSKDemodulator {
+	sps := input.SampleRate / symbolRate
+
+	return &QPSKDemodulator{
+		input:   input,
+		agc:     NewAGC(defaultAGCRate, 1),
+		rrc:     NewRRC(input.SampleRate, symbolRate, alpha, defaultRRCSpan),
+		symAGC:  NewAGC(defaultSymbolAGCRate, 1),
+		clock:   NewGardner(sps, defaultClockAlpha, defaultClockAlpha*defaultClockAlpha/4, defaultClockLimit),
+		costas:  NewCostas(defaultCostasBW),
+		samples: make([]complex64, defaultBlockSize),
+	}
+}
+
+// Read fills the buffer with the next soft-symbols.
+func (e *QPSKDemodulator) Read(p []byte) (int, error) {
+	for len(e.output) == 0 {
+		if e.err != nil {
+			return 0, e.err
+		}
+
+		n, err := e.input.ReadSamples(e.samples)
+		e.err = err
+
+		samples := e.samples[:n]
+		e.agc.Work(samples)
+		symbols := e.clock.Work(e.rrc.Work(samples))
+		e.symAGC.Work(symbols)
+		e.costas.Work(symbols)
+
+		e.output = make([]byte, len(symbols)*2)
+		for i, s := range symbols {
+			e.output[i*2+0] = toSoftSymbol(real(s))
+			e.output[i*2+1] = toSoftSymbol(imag(s))
+		}
+	}
+
+	n := copy(p, e.output)
+	e.output = e.output[n:]
+	return n, nil
+}
+
+func toSoftSymbol(v float32) byte {
+	v *= defaultSoftSymbolAmp
+	if v > 127 {
+		v = 127
+	}
+	if v < -127 {
+		v = -127
+	}
+	return byte(int8(v))
+}
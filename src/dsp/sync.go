@@ -0,0 +1,120 @@
+package dsp
+
+import (
+	"math"
+)
+
+// Gardner timing recovery for PSK signals. The samples between symbols are
+// found with a cubic interpolator and the error of the midpoint sample drives
+// both the phase and the rate of the symbol clock.
+type Gardner struct {
+	omega      float64
+	omegaMid   float64
+	omegaLimit float64
+	gainOmega  float64
+	gainMu     float64
+	position   float64
+	last       complex64
+	buf        []complex64
+}
+
+// NewGardner creates a clock recovery loop for the samples per symbol informed.
+func NewGardner(samplesPerSymbol, gainMu, gainOmega, omegaLimit float64) *Gardner {
+	return &Gardner{
+		omega:      samplesPerSymbol,
+		omegaMid:   samplesPerSymbol,
+		omegaLimit: omegaLimit * samplesPerSymbol,
+		gainOmega:  gainOmega,
+		gainMu:     gainMu,
+		position:   samplesPerSymbol,
+	}
+}
+
+// Work returns the symbols found inside the samples. Samples not yet used are
+// kept for the next call.
+func (e *Gardner) Work(samples []complex64) []complex64 {
+	e.buf = append(e.buf, samples...)
+	out := make([]complex64, 0, int(float64(len(samples))/e.omega)+2)
+
+	for e.position+2 < float64(len(e.buf)) {
+		symbol := interpolate(e.buf, e.position)
+		mid := interpolate(e.buf, e.position-e.omega/2)
+
+		err := float64(real(mid)*(real(e.last)-real(symbol)) + imag(mid)*(imag(e.last)-imag(symbol)))
+		err = math.Max(-1, math.Min(1, err))
+
+		e.omega += e.gainOmega * err
+		e.omega = math.Max(e.omegaMid-e.omegaLimit, math.Min(e.omegaMid+e.omegaLimit, e.omega))
+		e.position += e.omega + e.gainMu*err
+
+		e.last = symbol
+		out = append(out, symbol)
+	}
+
+	// Keep enough samples behind the current position for the interpolator.
+	drop := int(e.position-e.omega) - 2
+	if drop > 0 {
+		e.buf = append(e.buf[:0], e.buf[drop:]...)
+		e.position -= float64(drop)
+	}
+	return out
+}
+
+// interpolate the sample at the fractional position with a 4-point Lagrange polynomial.
+func interpolate(buf []complex64, position float64) complex64 {
+	i := int(position)
+	if i < 1 {
+		return buf[0]
+	}
+	mu := float32(position - float64(i))
+
+	y0, y1, y2, y3 := buf[i-1], buf[i], buf[i+1], buf[i+2]
+	c0 := -mu * (mu - 1) * (mu - 2) / 6
+	c1 := (mu + 1) * (mu - 1) * (mu - 2) / 2
+	c2 := -(mu + 1) * mu * (mu - 2) / 2
+	c3 := (mu + 1) * mu * (mu - 1) / 6
+
+	return y0*complex(c0, 0) + y1*complex(c1, 0) + y2*complex(c2, 0) + y3*complex(c3, 0)
+}
+
+// Costas loop for QPSK, removing the residual carrier frequency and phase.
+type Costas struct {
+	alpha     float64
+	beta      float64
+	phase     float64
+	frequency float64
+}
+
+// NewCostas creates a fourth order Costas loop with the loop bandwidth informed.
+func NewCostas(loopBandwidth float64) *Costas {
+	damping := math.Sqrt2 / 2
+	denom := 1 + 2*damping*loopBandwidth + loopBandwidth*loopBandwidth
+
+	return &Costas{
+		alpha: (4 * damping * loopBandwidth) / denom,
+		beta:  (4 * loopBandwidth * loopBandwidth) / denom,
+	}
+}
+
+// Work rotates the symbols in place to the locked carrier phase.
+func (e *Costas) Work(symbols []complex64) {
+	for i, s := range symbols {
+		sin, cos := math.Sincos(-e.phase)
+		s *= complex(float32(cos), float32(sin))
+		symbols[i] = s
+
+		err := float64(sign(real(s))*imag(s) - sign(imag(s))*real(s))
+		err = math.Max(-1, math.Min(1, err))
+
+		e.frequency += e.beta * err
+		e.frequency = math.Max(-1, math.Min(1, e.frequency))
+		e.phase = math.Mod(e.phase+e.frequency+e.alpha*err, 2*math.Pi)
+	}
+}
+
+func sign(v float32) float32 {
+	if v > 0 {
+		return 1
+	}
+	return -1
+}
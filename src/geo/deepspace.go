@@ -0,0 +1,474 @@
+package geo
+
+import "math"
+
+// dscomTerms are the lunar and solar terms shared by dscom and dsinit.
+type dscomTerms struct {
+	sinim, cosim, emsq, em, nm                   float64
+	s1, s2, s3, s4, s5, ss1, ss2, ss3, ss4, ss5  float64
+	sz1, sz3, sz11, sz13, sz21, sz23, sz31, sz33 float64
+	z1, z3, z11, z13, z21, z23, z31, z33         float64
+}
+
+// dscom computes the deep space lunar and solar terms at the epoch.
+func (e *Satellite) dscom(epoch, ep, argpp, tc, inclp, nodep, np float64) dscomTerms {
+	const (
+		zes    = 0.01675
+		zel    = 0.05490
+		c1ss   = 2.9864797e-6
+		c1l    = 4.7968065e-7
+		zsinis = 0.39785416
+		zcosis = 0.91744867
+		zcosgs = 0.1945905
+		zsings = -0.98088458
+	)
+
+	var d dscomTerms
+	d.nm = np
+	d.em = ep
+	snodm, cnodm := math.Sin(nodep), math.Cos(nodep)
+	sinomm, cosomm := math.Sin(argpp), math.Cos(argpp)
+	d.sinim, d.cosim = math.Sin(inclp), math.Cos(inclp)
+	d.emsq = d.em * d.em
+	betasq := 1 - d.emsq
+	rtemsq := math.Sqrt(betasq)
+
+	e.peo, e.pinco, e.plo, e.pgho, e.pho = 0, 0, 0, 0, 0
+	day := epoch + 18261.5 + tc/1440
+	xnodce := math.Mod(4.5236020-9.2422029e-4*day, twoPi)
+	stem, ctem := math.Sin(xnodce), math.Cos(xnodce)
+	zcosil := 0.91375164 - 0.03568096*ctem
+	zsinil := math.Sqrt(1 - zcosil*zcosil)
+	zsinhl := 0.089683511 * stem / zsinil
+	zcoshl := math.Sqrt(1 - zsinhl*zsinhl)
+	gam := 5.8351514 + 0.0019443680*day
+	zx := 0.39785416 * stem / zsinil
+	zy := zcoshl*ctem + 0.91744867*zsinhl*stem
+	zx = math.Atan2(zx, zy)
+	zx = gam + zx - xnodce
+	zcosgl, zsingl := math.Cos(zx), math.Sin(zx)
+
+	// Solar terms first, then the lunar terms.
+	zcosg, zsing := zcosgs, zsings
+	zcosi, zsini := zcosis, zsinis
+	zcosh, zsinh := cnodm, snodm
+	cc := c1ss
+	xnoi := 1 / d.nm
+
+	var s1, s2, s3, s4, s5, s6, s7 float64
+	var z1, z2, z3, z11, z12, z13, z21, z22, z23, z31, z32, z33 float64
+	var ss6, ss7, sz2, sz12, sz22, sz32 float64
+
+	for lsflg := 1; lsflg <= 2; lsflg++ {
+		a1 := zcosg*zcosh + zsing*zcosi*zsinh
+		a3 := -zsing*zcosh + zcosg*zcosi*zsinh
+		a7 := -zcosg*zsinh + zsing*zcosi*zcosh
+		a8 := zsing * zsini
+		a9 := zsing*zsinh + zcosg*zcosi*zcosh
+		a10 := zcosg * zsini
+		a2 := d.cosim*a7 + d.sinim*a8
+		a4 := d.cosim*a9 + d.sinim*a10
+		a5 := -d.sinim*a7 + d.cosim*a8
+		a6 := -d.sinim*a9 + d.cosim*a10
+
+		x1 := a1*cosomm + a2*sinomm
+		x2 := a3*cosomm + a4*sinomm
+		x3 := -a1*sinomm + a2*cosomm
+		x4 := -a3*sinomm + a4*cosomm
+		x5 := a5 * sinomm
+		x6 := a6 * sinomm
+		x7 := a5 * cosomm
+		x8 := a6 * cosomm
+
+		z31 = 12*x1*x1 - 3*x3*x3
+		z32 = 24*x1*x2 - 6*x3*x4
+		z33 = 12*x2*x2 - 3*x4*x4
+		z1 = 3*(a1*a1+a2*a2) + z31*d.emsq
+		z2 = 6*(a1*a3+a2*a4) + z32*d.emsq
+		z3 = 3*(a3*a3+a4*a4) + z33*d.emsq
+		z11 = -6*a1*a5 + d.emsq*(-24*x1*x7-6*x3*x5)
+		z12 = -6*(a1*a6+a3*a5) + d.emsq*(-24*(x2*x7+x1*x8)-6*(x3*x6+x4*x5))
+		z13 = -6*a3*a6 + d.emsq*(-24*x2*x8-6*x4*x6)
+		z21 = 6*a2*a5 + d.emsq*(24*x1*x5-6*x3*x7)
+		z22 = 6*(a4*a5+a2*a6) + d.emsq*(24*(x2*x5+x1*x6)-6*(x4*x7+x3*x8))
+		z23 = 6*a4*a6 + d.emsq*(24*x2*x6-6*x4*x8)
+		z1 = z1 + z1 + betasq*z31
+		z2 = z2 + z2 + betasq*z32
+		z3 = z3 + z3 + betasq*z33
+		s3 = cc * xnoi
+		s2 = -0.5 * s3 / rtemsq
+		s4 = s3 * rtemsq
+		s1 = -15 * d.em * s4
+		s5 = x1*x3 + x2*x4
+		s6 = x2*x3 + x1*x4
+		s7 = x2*x4 - x1*x3
+
+		if lsflg == 1 {
+			d.ss1, d.ss2, d.ss3, d.ss4, d.ss5, ss6, ss7 = s1, s2, s3, s4, s5, s6, s7
+			d.sz1, sz2, d.sz3 = z1, z2, z3
+			d.sz11, sz12, d.sz13 = z11, z12, z13
+			d.sz21, sz22, d.sz23 = z21, z22, z23
+			d.sz31, sz32, d.sz33 = z31, z32, z33
+
+			zcosg, zsing = zcosgl, zsingl
+			zcosi, zsini = zcosil, zsinil
+			zcosh = zcoshl*cnodm + zsinhl*snodm
+			zsinh = snodm*zcoshl - cnodm*zsinhl
+			cc = c1l
+		}
+	}
+
+	e.zmol = math.Mod(4.7199672+0.22997150*day-gam, twoPi)
+	e.zmos = math.Mod(6.2565837+0.017201977*day, twoPi)
+
+	// Solar terms.
+	e.se2 = 2 * d.ss1 * ss6
+	e.se3 = 2 * d.ss1 * ss7
+	e.si2 = 2 * d.ss2 * sz12
+	e.si3 = 2 * d.ss2 * (d.sz13 - d.sz11)
+	e.sl2 = -2 * d.ss3 * sz2
+	e.sl3 = -2 * d.ss3 * (d.sz3 - d.sz1)
+	e.sl4 = -2 * d.ss3 * (-21 - 9*d.emsq) * zes
+	e.sgh2 = 2 * d.ss4 * sz32
+	e.sgh3 = 2 * d.ss4 * (d.sz33 - d.sz31)
+	e.sgh4 = -18 * d.ss4 * zes
+	e.sh2 = -2 * d.ss2 * sz22
+	e.sh3 = -2 * d.ss2 * (d.sz23 - d.sz21)
+
+	// Lunar terms.
+	e.ee2 = 2 * s1 * s6
+	e.e3 = 2 * s1 * s7
+	e.xi2 = 2 * s2 * z12
+	e.xi3 = 2 * s2 * (z13 - z11)
+	e.xl2 = -2 * s3 * z2
+	e.xl3 = -2 * s3 * (z3 - z1)
+	e.xl4 = -2 * s3 * (-21 - 9*d.emsq) * zel
+	e.xgh2 = 2 * s4 * z32
+	e.xgh3 = 2 * s4 * (z33 - z31)
+	e.xgh4 = -18 * s4 * zel
+	e.xh2 = -2 * s2 * z22
+	e.xh3 = -2 * s2 * (z23 - z21)
+
+	d.s1, d.s2, d.s3, d.s4, d.s5 = s1, s2, s3, s4, s5
+	d.z1, d.z3, d.z11, d.z13 = z1, z3, z11, z13
+	d.z21, d.z23, d.z31, d.z33 = z21, z23, z31, z33
+	return d
+}
+
+// dpper applies the lunar and solar periodics at t minutes after the epoch.
+func (e Satellite) dpper(t, ep, inclp, nodep, argpp, mp float64) (float64, float64, float64, float64, float64) {
+	const (
+		zns = 1.19459e-5
+		zes = 0.01675
+		znl = 1.5835218e-4
+		zel = 0.05490
+	)
+
+	zm := e.zmos + zns*t
+	zf := zm + 2*zes*math.Sin(zm)
+	sinzf := math.Sin(zf)
+	f2 := 0.5*sinzf*sinzf - 0.25
+	f3 := -0.5 * sinzf * math.Cos(zf)
+	ses := e.se2*f2 + e.se3*f3
+	sis := e.si2*f2 + e.si3*f3
+	sls := e.sl2*f2 + e.sl3*f3 + e.sl4*sinzf
+	sghs := e.sgh2*f2 + e.sgh3*f3 + e.sgh4*sinzf
+	shs := e.sh2*f2 + e.sh3*f3
+
+	zm = e.zmol + znl*t
+	zf = zm + 2*zel*math.Sin(zm)
+	sinzf = math.Sin(zf)
+	f2 = 0.5*sinzf*sinzf - 0.25
+	f3 = -0.5 * sinzf * math.Cos(zf)
+	sel := e.ee2*f2 + e.e3*f3
+	sil := e.xi2*f2 + e.xi3*f3
+	sll := e.xl2*f2 + e.xl3*f3 + e.xl4*sinzf
+	sghl := e.xgh2*f2 + e.xgh3*f3 + e.xgh4*sinzf
+	shll := e.xh2*f2 + e.xh3*f3
+
+	pe := ses + sel - e.peo
+	pinc := sis + sil - e.pinco
+	pl := sls + sll - e.plo
+	pgh := sghs + sghl - e.pgho
+	ph := shs + shll - e.pho
+
+	inclp = inclp + pinc
+	ep = ep + pe
+	sinip, cosip := math.Sin(inclp), math.Cos(inclp)
+
+	if inclp >= 0.2 {
+		ph = ph / sinip
+		pgh = pgh - cosip*ph
+		argpp = argpp + pgh
+		nodep = nodep + ph
+		mp = mp + pl
+		return ep, inclp, nodep, argpp, mp
+	}
+
+	// Lyddane modification for low inclinations.
+	sinop, cosop := math.Sin(nodep), math.Cos(nodep)
+	alfdp := sinip*sinop + ph*cosop + pinc*cosip*sinop
+	betdp := sinip*cosop - ph*sinop + pinc*cosip*cosop
+	nodep = math.Mod(nodep, twoPi)
+	xls := mp + argpp + cosip*nodep
+	dls := pl + pgh - pinc*nodep*sinip
+	xls = xls + dls
+	xnoh := nodep
+	nodep = math.Atan2(alfdp, betdp)
+	if math.Abs(xnoh-nodep) > math.Pi {
+		if nodep < xnoh {
+			nodep = nodep + twoPi
+		} else {
+			nodep = nodep - twoPi
+		}
+	}
+	mp = mp + pl
+	argpp = xls - mp - cosip*nodep
+	return ep, inclp, nodep, argpp, mp
+}
+
+// dsinit computes the deep space secular rates and the resonance terms
+// of the 12 hours and one day orbits.
+func (e *Satellite) dsinit(d dscomTerms, t, tc, ecco, eccsq, xpidot float64) {
+	const (
+		q22    = 1.7891679e-6
+		q31    = 2.1460748e-6
+		q33    = 2.2123015e-7
+		root22 = 1.7891679e-6
+		root44 = 7.3636953e-9
+		root54 = 2.1765803e-9
+		rptim  = 4.37526908801129966e-3
+		root32 = 3.7393792e-7
+		root52 = 1.1428639e-7
+		znl    = 1.5835218e-4
+		zns    = 1.19459e-5
+	)
+
+	el := e.el
+	nm, em, emsq := d.nm, d.em, d.emsq
+	sinim, cosim := d.sinim, d.cosim
+
+	e.irez = 0
+	if nm < 0.0052359877 && nm > 0.0034906585 {
+		e.irez = 1
+	}
+	if nm >= 8.26e-3 && nm <= 9.24e-3 && em >= 0.5 {
+		e.irez = 2
+	}
+
+	// Solar secular terms.
+	ses := d.ss1 * zns * d.ss5
+	sis := d.ss2 * zns * (d.sz11 + d.sz13)
+	sls := -zns * d.ss3 * (d.sz1 + d.sz3 - 14 - 6*emsq)
+	sghs := d.ss4 * zns * (d.sz31 + d.sz33 - 6)
+	shs := -zns * d.ss2 * (d.sz21 + d.sz23)
+	if el.incl < 5.2359877e-2 || el.incl > math.Pi-5.2359877e-2 {
+		shs = 0
+	}
+	if sinim != 0 {
+		shs = shs / sinim
+	}
+	sgs := sghs - cosim*shs
+
+	// Lunar secular terms.
+	e.dedt = ses + d.s1*znl*d.s5
+	e.didt = sis + d.s2*znl*(d.z11+d.z13)
+	e.dmdt = sls - znl*d.s3*(d.z1+d.z3-14-6*emsq)
+	sghl := d.s4 * znl * (d.z31 + d.z33 - 6)
+	shll := -znl * d.s2 * (d.z21 + d.z23)
+	if el.incl < 5.2359877e-2 || el.incl > math.Pi-5.2359877e-2 {
+		shll = 0
+	}
+	e.domdt = sgs + sghl
+	e.dnodt = shs
+	if sinim != 0 {
+		e.domdt = e.domdt - cosim/sinim*shll
+		e.dnodt = e.dnodt + shll/sinim
+	}
+
+	if e.irez == 0 {
+		return
+	}
+
+	theta := math.Mod(e.gsto+tc*rptim, twoPi)
+	em = em + e.dedt*t
+	aonv := math.Pow(nm/xke, x2o3)
+
+	// Geopotential resonance for 12 hour orbits.
+	if e.irez == 2 {
+		cosisq := cosim * cosim
+		em = ecco
+		emsq = eccsq
+		eoc := em * emsq
+		g201 := -0.306 - (em-0.64)*0.440
+
+		var g211, g310, g322, g410, g422, g520, g521, g532, g533 float64
+		if em <= 0.65 {
+			g211 = 3.616 - 13.2470*em + 16.2900*emsq
+			g310 = -19.302 + 117.3900*em - 228.4190*emsq + 156.5910*eoc
+			g322 = -18.9068 + 109.7927*em - 214.6334*emsq + 146.5816*eoc
+			g410 = -41.122 + 242.6940*em - 471.0940*emsq + 313.9530*eoc
+			g422 = -146.407 + 841.8800*em - 1629.014*emsq + 1083.4350*eoc
+			g520 = -532.114 + 3017.977*em - 5740.032*emsq + 3708.2760*eoc
+		} else {
+			g211 = -72.099 + 331.819*em - 508.738*emsq + 266.724*eoc
+			g310 = -346.844 + 1582.851*em - 2415.925*emsq + 1246.113*eoc
+			g322 = -342.585 + 1554.908*em - 2366.899*emsq + 1215.972*eoc
+			g410 = -1052.797 + 4758.686*em - 7193.992*emsq + 3651.957*eoc
+			g422 = -3581.690 + 16178.110*em - 24462.770*emsq + 12422.520*eoc
+			if em > 0.715 {
+				g520 = -5149.66 + 29936.92*em - 54087.36*emsq + 31324.56*eoc
+			} else {
+				g520 = 1464.74 - 4664.75*em + 3763.64*emsq
+			}
+		}
+
+		if em < 0.7 {
+			g533 = -919.22770 + 4988.6100*em - 9064.7700*emsq + 5542.21*eoc
+			g521 = -822.71072 + 4568.6173*em - 8491.4146*emsq + 5337.524*eoc
+			g532 = -853.66600 + 4690.2500*em - 8624.7700*emsq + 5341.4*eoc
+		} else {
+			g533 = -37995.780 + 161616.52*em - 229838.20*emsq + 109377.94*eoc
+			g521 = -51752.104 + 218913.95*em - 309468.16*emsq + 146349.42*eoc
+			g532 = -40023.880 + 170470.89*em - 242699.48*emsq + 115605.82*eoc
+		}
+
+		sini2 := sinim * sinim
+		f220 := 0.75 * (1 + 2*cosim + cosisq)
+		f221 := 1.5 * sini2
+		f321 := 1.875 * sinim * (1 - 2*cosim - 3*cosisq)
+		f322 := -1.875 * sinim * (1 + 2*cosim - 3*cosisq)
+		f441 := 35 * sini2 * f220
+		f442 := 39.3750 * sini2 * sini2
+		f522 := 9.84375 * sinim * (sini2*(1-2*cosim-5*cosisq) + 0.33333333*(-2+4*cosim+6*cosisq))
+		f523 := sinim * (4.92187512*sini2*(-2-4*cosim+10*cosisq) + 6.56250012*(1+2*cosim-3*cosisq))
+		f542 := 29.53125 * sinim * (2 - 8*cosim + cosisq*(-12+8*cosim+10*cosisq))
+		f543 := 29.53125 * sinim * (-2 - 8*cosim + cosisq*(12+8*cosim-10*cosisq))
+
+		xno2 := nm * nm
+		ainv2 := aonv * aonv
+		temp1 := 3 * xno2 * ainv2
+		temp := temp1 * root22
+		e.d2201 = temp * f220 * g201
+		e.d2211 = temp * f221 * g211
+		temp1 = temp1 * aonv
+		temp = temp1 * root32
+		e.d3210 = temp * f321 * g310
+		e.d3222 = temp * f322 * g322
+		temp1 = temp1 * aonv
+		temp = 2 * temp1 * root44
+		e.d4410 = temp * f441 * g410
+		e.d4422 = temp * f442 * g422
+		temp1 = temp1 * aonv
+		temp = temp1 * root52
+		e.d5220 = temp * f522 * g520
+		e.d5232 = temp * f523 * g532
+		temp = 2 * temp1 * root54
+		e.d5421 = temp * f542 * g521
+		e.d5433 = temp * f543 * g533
+
+		e.xlamo = math.Mod(el.mo+el.node+el.node-theta-theta, twoPi)
+		e.xfact = e.mdot + e.dmdt + 2*(e.nodedot+e.dnodt-rptim) - e.noUnkozai
+	}
+
+	// Synchronous resonance for one day orbits.
+	if e.irez == 1 {
+		g200 := 1 + emsq*(-2.5+0.8125*emsq)
+		g310 := 1 + 2*emsq
+		g300 := 1 + emsq*(-6+6.60937*emsq)
+		f220 := 0.75 * (1 + cosim) * (1 + cosim)
+		f311 := 0.9375*sinim*sinim*(1+3*cosim) - 0.75*(1+cosim)
+		f330 := 1 + cosim
+		f330 = 1.875 * f330 * f330 * f330
+		e.del1 = 3 * nm * nm * aonv * aonv
+		e.del2 = 2 * e.del1 * f220 * g200 * q22
+		e.del3 = 3 * e.del1 * f330 * g300 * q33 * aonv
+		e.del1 = e.del1 * f311 * g310 * q31 * aonv
+		e.xlamo = math.Mod(el.mo+el.node+el.argp-theta, twoPi)
+		e.xfact = e.mdot + xpidot - rptim + e.dmdt + e.domdt + e.dnodt - e.noUnkozai
+	}
+}
+
+// dspace applies the deep space secular effects and integrates the
+// resonance terms up to t minutes after the epoch.
+func (e Satellite) dspace(t, em, argpm, inclm, mm, nodem float64) (float64, float64, float64, float64, float64, float64) {
+	const (
+		fasx2 = 0.13130908
+		fasx4 = 2.8843198
+		fasx6 = 0.37448087
+		g22   = 5.7686396
+		g32   = 0.95240898
+		g44   = 1.8014998
+		g52   = 1.0508330
+		g54   = 4.4108898
+		rptim = 4.37526908801129966e-3
+		stepp = 720.0
+		stepn = -720.0
+		step2 = 259200.0
+	)
+
+	theta := math.Mod(e.gsto+t*rptim, twoPi)
+	em = em + e.dedt*t
+	inclm = inclm + e.didt*t
+	argpm = argpm + e.domdt*t
+	nodem = nodem + e.dnodt*t
+	mm = mm + e.dmdt*t
+	nm := e.noUnkozai
+
+	if e.irez == 0 {
+		return em, argpm, inclm, mm, nodem, nm
+	}
+
+	// The integration always starts at the epoch, so the propagation is stateless.
+	atime := 0.0
+	xni := e.noUnkozai
+	xli := e.xlamo
+	delt := stepp
+	if t < 0 {
+		delt = stepn
+	}
+
+	var xndt, xldot, xnddt, ft float64
+	for {
+		if e.irez != 2 {
+			xndt = e.del1*math.Sin(xli-fasx2) + e.del2*math.Sin(2*(xli-fasx4)) + e.del3*math.Sin(3*(xli-fasx6))
+			xldot = xni + e.xfact
+			xnddt = e.del1*math.Cos(xli-fasx2) + 2*e.del2*math.Cos(2*(xli-fasx4)) + 3*e.del3*math.Cos(3*(xli-fasx6))
+			xnddt = xnddt * xldot
+		} else {
+			xomi := e.el.argp + e.argpdot*atime
+			x2omi := xomi + xomi
+			x2li := xli + xli
+			xndt = e.d2201*math.Sin(x2omi+xli-g22) + e.d2211*math.Sin(xli-g22) +
+				e.d3210*math.Sin(xomi+xli-g32) + e.d3222*math.Sin(-xomi+xli-g32) +
+				e.d4410*math.Sin(x2omi+x2li-g44) + e.d4422*math.Sin(x2li-g44) +
+				e.d5220*math.Sin(xomi+xli-g52) + e.d5232*math.Sin(-xomi+xli-g52) +
+				e.d5421*math.Sin(xomi+x2li-g54) + e.d5433*math.Sin(-xomi+x2li-g54)
+			xldot = xni + e.xfact
+			xnddt = e.d2201*math.Cos(x2omi+xli-g22) + e.d2211*math.Cos(xli-g22) +
+				e.d3210*math.Cos(xomi+xli-g32) + e.d3222*math.Cos(-xomi+xli-g32) +
+				e.d5220*math.Cos(xomi+xli-g52) + e.d5232*math.Cos(-xomi+xli-g52) +
+				2*(e.d4410*math.Cos(x2omi+x2li-g44)+e.d4422*math.Cos(x2li-g44)+
+					e.d5421*math.Cos(xomi+x2li-g54)+e.d5433*math.Cos(-xomi+x2li-g54))
+			xnddt = xnddt * xldot
+		}
+
+		if math.Abs(t-atime) < stepp {
+			ft = t - atime
+			break
+		}
+
+		xli = xli + xldot*delt + xndt*step2
+		xni = xni + xndt*delt + xnddt*step2
+		atime = atime + delt
+	}
+
+	nm = xni + xndt*ft + xnddt*ft*ft*0.5
+	xl := xli + xldot*ft + xndt*ft*ft*0.5
+	if e.irez != 1 {
+		mm = xl - 2*nodem + 2*theta
+	} else {
+		mm = xl - nodem - argpm + theta
+	}
+	return em, argpm, inclm, mm, nodem, nm
+}
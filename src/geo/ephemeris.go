@@ -0,0 +1,153 @@
+// Package geo has the navigation of the spacecrafts: their ephemeris,
+// sub-satellite tracks and the geolocation of the products.
+package geo
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// StateVector of the spacecraft at a time. The position in meters and the velocity
+// in meters per second are Earth-fixed (ECEF). The attitude is the quaternion of
+// the spacecraft body with the scalar last, zero when unknown.
+type StateVector struct {
+	Time     time.Time
+	Position [3]float64
+	Velocity [3]float64
+	Attitude [4]float64
+}
+
+// Ephemeris is a series of state vectors sorted by time.
+type Ephemeris []StateVector
+
+// Add the state vector to the series, ignoring repeated times.
+func (e *Ephemeris) Add(sv StateVector) {
+	i := sort.Search(len(*e), func(i int) bool {
+		return !(*e)[i].Time.Before(sv.Time)
+	})
+
+	if i < len(*e) && (*e)[i].Time.Equal(sv.Time) {
+		return
+	}
+
+	*e = append(*e, StateVector{})
+	copy((*e)[i+1:], (*e)[i:])
+	(*e)[i] = sv
+}
+
+// GetDirection returns the direction of the spacecraft at the middle of the series.
+func (e Ephemeris) GetDirection() (Direction, bool) {
+	if len(e) == 0 {
+		return Ascending, false
+	}
+	return e[len(e)/2].GetDirection(), true
+}
+
+// maxExtrapolation of the state vectors before the first and after the last ones of a series.
+const maxExtrapolation = 2 * time.Second
+
+// Interpolate returns the state vector at the time with the cubic Hermite interpolation
+// of the positions and velocities of the neighboring state vectors, the attitude is the
+// closest one. It's false outside the series.
+func (e Ephemeris) Interpolate(t time.Time) (StateVector, bool) {
+	if len(e) == 0 {
+		return StateVector{}, false
+	}
+
+	i := sort.Search(len(e), func(i int) bool {
+		return !e[i].Time.Before(t)
+	})
+
+	switch {
+	case i == 0:
+		return e[0].extrapolate(t)
+	case i == len(e):
+		return e[len(e)-1].extrapolate(t)
+	}
+
+	a, b := e[i-1], e[i]
+	h := b.Time.Sub(a.Time).Seconds()
+	s := t.Sub(a.Time).Seconds() / h
+	s2, s3 := s*s, s*s*s
+
+	sv := StateVector{Time: t, Attitude: a.Attitude}
+	if s > 0.5 {
+		sv.Attitude = b.Attitude
+	}
+
+	for k := 0; k < 3; k++ {
+		sv.Position[k] = (2*s3-3*s2+1)*a.Position[k] + (s3-2*s2+s)*h*a.Velocity[k] +
+			(-2*s3+3*s2)*b.Position[k] + (s3-s2)*h*b.Velocity[k]
+		sv.Velocity[k] = (6*s2-6*s)/h*a.Position[k] + (3*s2-4*s+1)*a.Velocity[k] +
+			(-6*s2+6*s)/h*b.Position[k] + (3*s2-2*s)*b.Velocity[k]
+	}
+	return sv, true
+}
+
+// extrapolate the state vector to the time with its velocity, up to the maximum extrapolation.
+func (e StateVector) extrapolate(t time.Time) (StateVector, bool) {
+	dt := t.Sub(e.Time)
+	if dt > maxExtrapolation || dt < -maxExtrapolation {
+		return StateVector{}, false
+	}
+
+	sv := e
+	sv.Time = t
+	for k := 0; k < 3; k++ {
+		sv.Position[k] += e.Velocity[k] * dt.Seconds()
+	}
+	return sv, true
+}
+
+// SaveCSV writes the series with a header line. Times are RFC 3339 in UTC, positions
+// and velocities have millimeter resolution and the quaternions eight decimals.
+func (e Ephemeris) SaveCSV(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	w.Write([]string{"time", "x", "y", "z", "vx", "vy", "vz", "q1", "q2", "q3", "q4"})
+
+	for _, sv := range e {
+		line := []string{sv.Time.UTC().Format(time.RFC3339Nano)}
+		for _, v := range sv.Position {
+			line = append(line, strconv.FormatFloat(v, 'f', 3, 64))
+		}
+		for _, v := range sv.Velocity {
+			line = append(line, strconv.FormatFloat(v, 'f', 3, 64))
+		}
+		for _, v := range sv.Attitude {
+			line = append(line, strconv.FormatFloat(v, 'f', 8, 64))
+		}
+		w.Write(line)
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+// SaveJSON writes the series as an array of state vectors.
+func (e Ephemeris) SaveJSON(path string) error {
+	buf, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buf, 0644)
+}
+
+// Save writes the CSV and JSON files of the series with the base name.
+func (e Ephemeris) Save(name string) error {
+	if err := e.SaveCSV(fmt.Sprintf("%s.csv", name)); err != nil {
+		return err
+	}
+	return e.SaveJSON(fmt.Sprintf("%s.json", name))
+}
@@ -0,0 +1,58 @@
+package geo
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// circularOrbit returns the state vector of a 7000 km circular equatorial orbit.
+func circularOrbit(epoch time.Time, t time.Duration) StateVector {
+	const radius, period = 7e6, 5829.0
+	w := 2 * math.Pi / period
+	s, c := math.Sincos(w * t.Seconds())
+	return StateVector{
+		Time:     epoch.Add(t),
+		Position: [3]float64{radius * c, radius * s, 0},
+		Velocity: [3]float64{-radius * w * s, radius * w * c, 0},
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	epoch := time.Date(2020, 1, 31, 12, 0, 0, 0, time.UTC)
+
+	// One state vector per second, like the spacecraft diary.
+	var ephemeris Ephemeris
+	for i := 0; i < 10; i++ {
+		ephemeris.Add(circularOrbit(epoch, time.Duration(i)*time.Second))
+	}
+
+	for _, tc := range []struct {
+		offset             time.Duration
+		position, velocity float64
+	}{
+		{2500 * time.Millisecond, 1e-3, 1e-3},
+		{7 * time.Second, 1e-6, 1e-6},
+		{-time.Second, 5, 10},
+		{9*time.Second + 800*time.Millisecond, 5, 10},
+	} {
+		want := circularOrbit(epoch, tc.offset)
+		got, ok := ephemeris.Interpolate(want.Time)
+		if !ok {
+			t.Fatalf("%s: outside the ephemeris", tc.offset)
+		}
+
+		var dr, dv float64
+		for k := 0; k < 3; k++ {
+			dr = math.Max(dr, math.Abs(got.Position[k]-want.Position[k]))
+			dv = math.Max(dv, math.Abs(got.Velocity[k]-want.Velocity[k]))
+		}
+		if dr > tc.position || dv > tc.velocity {
+			t.Errorf("%s: position off by %g m, velocity by %g m/s", tc.offset, dr, dv)
+		}
+	}
+
+	if _, ok := ephemeris.Interpolate(epoch.Add(-time.Minute)); ok {
+		t.Error("time a minute before the ephemeris interpolated")
+	}
+}
@@ -0,0 +1,132 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Projection of a regular raster grid.
+type Projection int
+
+// Grid projections. Equirectangular grids are in degrees of latitude and longitude,
+// Web-Mercator grids in meters of the spherical projection (EPSG:3857).
+const (
+	Equirectangular Projection = iota
+	WebMercator
+)
+
+// mercatorMaxLatitude is the latitude of the square Web-Mercator world.
+const mercatorMaxLatitude = 85.05112878
+
+// ParseProjection returns the projection with the name equirectangular or mercator.
+func ParseProjection(name string) (Projection, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "equirectangular", "platecarree":
+		return Equirectangular, nil
+	case "mercator", "webmercator":
+		return WebMercator, nil
+	}
+	return 0, fmt.Errorf("unknown projection %q", name)
+}
+
+func (p Projection) String() string {
+	if p == WebMercator {
+		return "mercator"
+	}
+	return "equirectangular"
+}
+
+// Grid is a north-up raster in a projection. The bounding box is in degrees, the east
+// longitude may be larger than 180° when the grid crosses the antimeridian. The resolution
+// is the size of the pixels in the units of the projection.
+type Grid struct {
+	Projection Projection
+	West       float64
+	South      float64
+	East       float64
+	North      float64
+	Resolution float64
+}
+
+// HasBounds reports if the bounding box of the grid is set.
+func (e Grid) HasBounds() bool {
+	return e.East > e.West && e.North > e.South
+}
+
+// Fit returns the grid with the bounding box of the points and the resolution matching
+// the ground resolution in meters, when they aren't set.
+func (e Grid) Fit(points []GeoPoint, groundResolution float64) Grid {
+	if !e.HasBounds() && len(points) > 0 {
+		ref := points[0].Longitude
+		e.West, e.East = ref, ref
+		e.South, e.North = points[0].Latitude, points[0].Latitude
+
+		for _, p := range points {
+			lon := ref + wrapLongitude(p.Longitude-ref)
+			e.West = math.Min(e.West, lon)
+			e.East = math.Max(e.East, lon)
+			e.South = math.Min(e.South, p.Latitude)
+			e.North = math.Max(e.North, p.Latitude)
+		}
+
+		if e.West < -180 {
+			e.West += 360
+			e.East += 360
+		}
+	}
+
+	if e.Resolution <= 0 {
+		if e.Projection == Equirectangular {
+			e.Resolution = groundResolution / (wgs84Radius * math.Pi / 180)
+		} else {
+			e.Resolution = groundResolution / math.Cos((e.North+e.South)/2*math.Pi/180)
+		}
+	}
+
+	return e
+}
+
+// GetOrigin returns the projected coordinates of the upper left corner of the grid.
+func (e Grid) GetOrigin() (float64, float64) {
+	return e.project(e.North, e.West)
+}
+
+// GetDimensions returns the width and height of the grid in pixels.
+func (e Grid) GetDimensions() (int, int) {
+	x0, y0 := e.project(e.North, e.West)
+	x1, y1 := e.project(e.South, e.East)
+	return int(math.Ceil((x1 - x0) / e.Resolution)), int(math.Ceil((y0 - y1) / e.Resolution))
+}
+
+// GetPeriod returns the width in pixels of 360° of longitude.
+func (e Grid) GetPeriod() float64 {
+	x0, _ := e.project(0, 0)
+	x1, _ := e.project(0, 360)
+	return (x1 - x0) / e.Resolution
+}
+
+// Forward returns the position of the geodetic coordinates inside the grid in pixels,
+// the upper left corner of the grid is (0, 0).
+func (e Grid) Forward(latitude, longitude float64) (float64, float64) {
+	longitude = e.West + math.Mod(math.Mod(longitude-e.West, 360)+360, 360)
+	x0, y0 := e.GetOrigin()
+	x, y := e.project(latitude, longitude)
+	return (x - x0) / e.Resolution, (y0 - y) / e.Resolution
+}
+
+func (e Grid) project(latitude, longitude float64) (float64, float64) {
+	if e.Projection == Equirectangular {
+		return longitude, latitude
+	}
+
+	latitude = math.Max(-mercatorMaxLatitude, math.Min(mercatorMaxLatitude, latitude))
+	x := wgs84Radius * longitude * math.Pi / 180
+	y := wgs84Radius * math.Log(math.Tan(math.Pi/4+latitude*math.Pi/360))
+	return x, y
+}
+
+// wrapLongitude returns the longitude difference inside [-180°, 180°).
+func wrapLongitude(d float64) float64 {
+	return math.Mod(math.Mod(d+180, 360)+360, 360) - 180
+}
@@ -0,0 +1,251 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// ScanModel is the viewing geometry of a cross-track scanning radiometer: the scan
+// angle in radians of the center of each column of the image. The first column is
+// at the right of the spacecraft motion, with positive angles.
+type ScanModel []float64
+
+// NewScanModel returns the model of a scanner sampling the scan angles uniformly,
+// like MSU-MR. The half angle of the swath is in degrees.
+func NewScanModel(halfAngle float64, width int) ScanModel {
+	model := make(ScanModel, width)
+	step := 2 * halfAngle * math.Pi / 180 / float64(width)
+	for i := range model {
+		model[i] = halfAngle*math.Pi/180 - (float64(i)+0.5)*step
+	}
+	return model
+}
+
+// NewAggregatedScanModel returns the model of a scanner aggregating a different number
+// of uniform samples into each pixel of the zones of the scan, like VIIRS. The half
+// angle of the swath is in degrees.
+func NewAggregatedScanModel(halfAngle float64, zoneWidths, aggregation []int) ScanModel {
+	var samples int
+	for i, w := range zoneWidths {
+		samples += w * aggregation[i]
+	}
+
+	var model ScanModel
+	step := 2 * halfAngle * math.Pi / 180 / float64(samples)
+	angle := halfAngle * math.Pi / 180
+	for i, w := range zoneWidths {
+		size := float64(aggregation[i]) * step
+		for j := 0; j < w; j++ {
+			model = append(model, angle-size/2)
+			angle -= size
+		}
+	}
+	return model
+}
+
+// Locate returns the point of the WGS-84 ellipsoid seen by the scanner at the scan angle
+// from the state vector, assuming a nadir pointing spacecraft. It's false when the line
+// of sight doesn't intersect the ellipsoid.
+func (e ScanModel) Locate(sv StateVector, angle float64) (GeoPoint, bool) {
+	return e.LocateDetector(sv, angle, 0)
+}
+
+// LocateDetector is the same as Locate for a detector looking ahead of the scan plane by
+// the along-track angle in radians, like the detectors of a scanner sweeping many rows.
+func (e ScanModel) LocateDetector(sv StateVector, angle, along float64) (GeoPoint, bool) {
+	p := sv.Position
+	nadir := scale(normalize(p), -1)
+	right := normalize(cross(sv.Velocity, scale(nadir, -1)))
+	forward := cross(scale(nadir, -1), right)
+
+	s, c := math.Sincos(angle)
+	sa, ca := math.Sincos(along)
+	los := [3]float64{
+		ca*(c*nadir[0]+s*right[0]) + sa*forward[0],
+		ca*(c*nadir[1]+s*right[1]) + sa*forward[1],
+		ca*(c*nadir[2]+s*right[2]) + sa*forward[2],
+	}
+
+	// Intersect the line of sight with the ellipsoid scaled into a sphere.
+	k := 1 / (1 - wgs84Flattening)
+	ps := [3]float64{p[0], p[1], p[2] * k}
+	ls := [3]float64{los[0], los[1], los[2] * k}
+
+	a := dot(ls, ls)
+	b := 2 * dot(ps, ls)
+	cc := dot(ps, ps) - wgs84Radius*wgs84Radius
+	disc := b*b - 4*a*cc
+	if disc < 0 {
+		return GeoPoint{}, false
+	}
+
+	t := (-b - math.Sqrt(disc)) / (2 * a)
+	ground := StateVector{Time: sv.Time, Position: [3]float64{
+		p[0] + t*los[0],
+		p[1] + t*los[1],
+		p[2] + t*los[2],
+	}}
+	return ground.GetGeoPoint(), true
+}
+
+// GCP is a ground control point tying the position of the raster to a geodetic position
+// in degrees. The raster position of the center of the first pixel is (0.5, 0.5).
+type GCP struct {
+	Pixel     float64
+	Line      float64
+	Latitude  float64
+	Longitude float64
+}
+
+// Swath geolocates the pixels of an image with the state vector of each line and the scan model.
+type Swath struct {
+	Lines []StateVector
+	Model ScanModel
+}
+
+// NewSwath propagates the orbit to the time of each of the lines, the first one at the start time.
+func NewSwath(sat *Satellite, start time.Time, interval time.Duration, lines int, model ScanModel) (Swath, error) {
+	swath := Swath{Model: model}
+	for i := 0; i < lines; i++ {
+		sv, err := sat.Propagate(start.Add(time.Duration(i) * interval))
+		if err != nil {
+			return Swath{}, err
+		}
+		swath.Lines = append(swath.Lines, sv)
+	}
+	return swath, nil
+}
+
+// NewEphemerisSwath interpolates the ephemeris at the time of each of the lines, the first
+// one at the start time. It fails when a line is outside the ephemeris.
+func NewEphemerisSwath(ephemeris Ephemeris, start time.Time, interval time.Duration, lines int, model ScanModel) (Swath, error) {
+	swath := Swath{Model: model}
+	for i := 0; i < lines; i++ {
+		t := start.Add(time.Duration(i) * interval)
+		sv, ok := ephemeris.Interpolate(t)
+		if !ok {
+			return Swath{}, fmt.Errorf("the ephemeris doesn't cover %s", t.UTC().Format(time.RFC3339))
+		}
+		swath.Lines = append(swath.Lines, sv)
+	}
+	return swath, nil
+}
+
+// Locate returns the geodetic position of the center of the pixel in the acquisition geometry.
+func (e Swath) Locate(x, y int) (GeoPoint, bool) {
+	if y < 0 || y >= len(e.Lines) || x < 0 || x >= len(e.Model) {
+		return GeoPoint{}, false
+	}
+	return e.Model.Locate(e.Lines[y], e.Model[x])
+}
+
+// GetResolution returns the distance in meters between the centers of the nadir
+// pixels of the middle line, or zero if they can't be located.
+func (e Swath) GetResolution() float64 {
+	x, y := len(e.Model)/2, len(e.Lines)/2
+	a, ok := e.Locate(x-1, y)
+	if !ok {
+		return 0
+	}
+	b, ok := e.Locate(x, y)
+	if !ok {
+		return 0
+	}
+
+	lat := (a.Latitude + b.Latitude) / 2 * math.Pi / 180
+	dlat := (b.Latitude - a.Latitude) * math.Pi / 180
+	dlon := wrapLongitude(b.Longitude-a.Longitude) * math.Pi / 180 * math.Cos(lat)
+	return wgs84Radius * math.Hypot(dlat, dlon)
+}
+
+// Geolocation is the geodetic position of the pixels of a swath sampled on a grid
+// of columns and lines. Points that can't be located aren't valid.
+type Geolocation struct {
+	Columns []int
+	Lines   []int
+	Points  []GeoPoint
+	Valid   []bool
+}
+
+// GetGeolocation locates the pixels every step pixels in both directions, including
+// the last column and line. The points are ordered by line.
+func (e Swath) GetGeolocation(step int) Geolocation {
+	g := Geolocation{
+		Columns: gridSteps(len(e.Model), step),
+		Lines:   gridSteps(len(e.Lines), step),
+	}
+
+	for _, y := range g.Lines {
+		for _, x := range g.Columns {
+			p, ok := e.Locate(x, y)
+			g.Points = append(g.Points, p)
+			g.Valid = append(g.Valid, ok)
+		}
+	}
+	return g
+}
+
+// GetValidPoints returns the points that were located.
+func (e Geolocation) GetValidPoints() []GeoPoint {
+	var points []GeoPoint
+	for i, p := range e.Points {
+		if e.Valid[i] {
+			points = append(points, p)
+		}
+	}
+	return points
+}
+
+// GetGCPs returns a grid of ground control points every step pixels in both directions,
+// including the last column and line.
+func (e Swath) GetGCPs(step int) []GCP {
+	var gcps []GCP
+	g := e.GetGeolocation(step)
+	for i, p := range g.Points {
+		if g.Valid[i] {
+			gcps = append(gcps, GCP{
+				Pixel:     float64(g.Columns[i%len(g.Columns)]) + 0.5,
+				Line:      float64(g.Lines[i/len(g.Columns)]) + 0.5,
+				Latitude:  p.Latitude,
+				Longitude: p.Longitude,
+			})
+		}
+	}
+	return gcps
+}
+
+func gridSteps(n, step int) []int {
+	if step < 1 {
+		step = 1
+	}
+
+	var steps []int
+	for i := 0; i < n; i += step {
+		steps = append(steps, i)
+	}
+	if n > 0 && steps[len(steps)-1] != n-1 {
+		steps = append(steps, n-1)
+	}
+	return steps
+}
+
+func dot(a, b [3]float64) float64 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+func cross(a, b [3]float64) [3]float64 {
+	return [3]float64{
+		a[1]*b[2] - a[2]*b[1],
+		a[2]*b[0] - a[0]*b[2],
+		a[0]*b[1] - a[1]*b[0],
+	}
+}
+
+func scale(a [3]float64, k float64) [3]float64 {
+	return [3]float64{a[0] * k, a[1] * k, a[2] * k}
+}
+
+func normalize(a [3]float64) [3]float64 {
+	return scale(a, 1/math.Sqrt(dot(a, a)))
+}
@@ -0,0 +1,52 @@
+package geo
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestLocateDetector(t *testing.T) {
+	epoch := time.Date(2020, 1, 31, 12, 0, 0, 0, time.UTC)
+	sv := circularOrbit(epoch, 0)
+	model := NewScanModel(56, 101)
+
+	center, ok := model.Locate(sv, 0)
+	if !ok {
+		t.Fatal("nadir not located")
+	}
+	if p, _ := model.LocateDetector(sv, 0, 0); p != center {
+		t.Errorf("detector in the scan plane at %+v, want %+v", p, center)
+	}
+
+	// The spacecraft moves eastward over the equator, 622 km above it.
+	const along = 1e-3
+	ahead, ok := model.LocateDetector(sv, 0, along)
+	if !ok {
+		t.Fatal("detector ahead not located")
+	}
+	if ahead.Longitude <= center.Longitude || math.Abs(ahead.Latitude) > 1e-9 {
+		t.Errorf("detector ahead at %+v, the scan plane at %+v", ahead, center)
+	}
+
+	height := 7e6 - wgs84Radius
+	if d := Distance(center, ahead); math.Abs(d-height*along) > height*along*0.01 {
+		t.Errorf("detector ahead %.1f m from the scan plane, want about %.1f m", d, height*along)
+	}
+
+	// Off nadir the same angle covers more ground, the growth of the bow-tie.
+	edge, _ := model.LocateDetector(sv, model[0], 0)
+	edgeAhead, _ := model.LocateDetector(sv, model[0], along)
+	if Distance(edge, edgeAhead) < 1.5*Distance(center, ahead) {
+		t.Errorf("detector spacing at the edge %.1f m, at nadir %.1f m",
+			Distance(edge, edgeAhead), Distance(center, ahead))
+	}
+}
+
+func TestDistance(t *testing.T) {
+	a := GeoPoint{Latitude: 0, Longitude: 179.5}
+	b := GeoPoint{Latitude: 0, Longitude: -179.5}
+	if d, want := Distance(a, b), wgs84Radius*math.Pi/180; math.Abs(d-want) > 1e-6 {
+		t.Errorf("distance across the antimeridian %f m, want %f m", d, want)
+	}
+}
@@ -0,0 +1,402 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// WGS-72 constants of the SGP4 model, in Earth radii and minutes.
+const (
+	earthRadius = 6378.135
+	earthMu     = 398600.8
+	j2          = 0.001082616
+	j3          = -0.00000253881
+	j4          = -0.00000165597
+	j3oj2       = j3 / j2
+	twoPi       = 2 * math.Pi
+	x2o3        = 2.0 / 3.0
+)
+
+var (
+	xke       = 60 / math.Sqrt(earthRadius*earthRadius*earthRadius/earthMu)
+	vkmpersec = earthRadius * xke / 60
+)
+
+// Satellite propagates the orbit of a TLE with the SGP4 model, switching to the
+// SDP4 deep space model for periods of 225 minutes or longer. It's a port of the
+// revised model by Vallado et al. (AIAA 2006-6753) with the improved mode.
+type Satellite struct {
+	Name    string
+	Catalog int
+	Epoch   time.Time
+
+	el          elements
+	isimp, deep bool
+
+	// Near Earth.
+	aycof, con41, cc1, cc4, cc5, d2, d3, d4, delmo, eta, argpdot, omgcof,
+	sinmao, t2cof, t3cof, t4cof, t5cof, x1mth2, x7thm1, mdot, nodedot,
+	xlcof, xmcof, nodecf, noUnkozai float64
+
+	// Deep space.
+	irez int
+	d2201, d2211, d3210, d3222, d4410, d4422, d5220, d5232, d5421, d5433,
+	dedt, del1, del2, del3, didt, dmdt, dnodt, domdt, e3, ee2, peo, pgho,
+	pho, pinco, plo, se2, se3, sgh2, sgh3, sgh4, sh2, sh3, si2, si3, sl2,
+	sl3, sl4, gsto, xfact, xgh2, xgh3, xgh4, xh2, xh3, xi2, xi3, xl2, xl3,
+	xl4, xlamo, zmol, zmos float64
+}
+
+// NewSatellite initializes the propagator with the element set.
+func NewSatellite(tle TLE) (*Satellite, error) {
+	el, err := tle.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Satellite{Name: tle.Name, Catalog: tle.Catalog(), Epoch: el.epoch, el: el}
+	if err := e.init(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Propagate returns the Earth-fixed state vector of the satellite at the time.
+func (e Satellite) Propagate(t time.Time) (StateVector, error) {
+	r, v, err := e.propagate(t.Sub(e.Epoch).Minutes())
+	if err != nil {
+		return StateVector{}, err
+	}
+
+	gmst := gmst(julianDate(t))
+	s, c := math.Sincos(gmst)
+
+	sv := StateVector{Time: t}
+	sv.Position = [3]float64{
+		(c*r[0] + s*r[1]) * 1000,
+		(-s*r[0] + c*r[1]) * 1000,
+		r[2] * 1000,
+	}
+
+	// The velocity is relative to the rotating frame.
+	sv.Velocity = [3]float64{
+		(c*v[0]+s*v[1])*1000 + earthRotation*sv.Position[1],
+		(-s*v[0]+c*v[1])*1000 - earthRotation*sv.Position[0],
+		v[2] * 1000,
+	}
+	return sv, nil
+}
+
+// earthRotation rate in radians per second.
+const earthRotation = 7.292115146706979e-5
+
+// julianDate of the time.
+func julianDate(t time.Time) float64 {
+	return float64(t.UnixNano())/float64(24*time.Hour) + 2440587.5
+}
+
+// gmst returns the Greenwich mean sidereal time in radians of the UT1 Julian date.
+func gmst(jd float64) float64 {
+	tut1 := (jd - 2451545) / 36525
+	temp := -6.2e-6*tut1*tut1*tut1 + 0.093104*tut1*tut1 + (876600*3600+8640184.812866)*tut1 + 67310.54841
+	temp = math.Mod(temp*math.Pi/180/240, twoPi)
+	if temp < 0 {
+		temp += twoPi
+	}
+	return temp
+}
+
+func (e *Satellite) init() error {
+	const ss = 78/earthRadius + 1
+	qzms2t := math.Pow((120-78)/earthRadius, 4)
+
+	el := e.el
+	epoch := julianDate(el.epoch) - 2433281.5
+
+	// Recover the original mean motion and semi-major axis from the Kozai mean motion.
+	eccsq := el.ecc * el.ecc
+	omeosq := 1 - eccsq
+	rteosq := math.Sqrt(omeosq)
+	cosio := math.Cos(el.incl)
+	cosio2 := cosio * cosio
+
+	ak := math.Pow(xke/el.no, x2o3)
+	d1 := 0.75 * j2 * (3*cosio2 - 1) / (rteosq * omeosq)
+	del := d1 / (ak * ak)
+	adel := ak * (1 - del*del - del*(1.0/3.0+134*del*del/81))
+	del = d1 / (adel * adel)
+	e.noUnkozai = el.no / (1 + del)
+
+	ao := math.Pow(xke/e.noUnkozai, x2o3)
+	sinio := math.Sin(el.incl)
+	po := ao * omeosq
+	con42 := 1 - 5*cosio2
+	e.con41 = -con42 - cosio2 - cosio2
+	posq := po * po
+	rp := ao * (1 - el.ecc)
+	e.gsto = gmst(epoch + 2433281.5)
+
+	if omeosq < 0 && e.noUnkozai < 0 {
+		return fmt.Errorf("invalid orbit elements")
+	}
+
+	e.isimp = rp < 220/earthRadius+1
+
+	sfour := ss
+	qzms24 := qzms2t
+	perige := (rp - 1) * earthRadius
+
+	// Lower the atmospheric density parameter for perigees under 156 km.
+	if perige < 156 {
+		sfour = perige - 78
+		if perige < 98 {
+			sfour = 20
+		}
+		qzms24 = math.Pow((120-sfour)/earthRadius, 4)
+		sfour = sfour/earthRadius + 1
+	}
+
+	pinvsq := 1 / posq
+	tsi := 1 / (ao - sfour)
+	e.eta = ao * el.ecc * tsi
+	etasq := e.eta * e.eta
+	eeta := el.ecc * e.eta
+	psisq := math.Abs(1 - etasq)
+	coef := qzms24 * math.Pow(tsi, 4)
+	coef1 := coef / math.Pow(psisq, 3.5)
+	cc2 := coef1 * e.noUnkozai * (ao*(1+1.5*etasq+eeta*(4+etasq)) +
+		0.375*j2*tsi/psisq*e.con41*(8+3*etasq*(8+etasq)))
+	e.cc1 = el.bstar * cc2
+
+	var cc3 float64
+	if el.ecc > 1e-4 {
+		cc3 = -2 * coef * tsi * j3oj2 * e.noUnkozai * sinio / el.ecc
+	}
+
+	e.x1mth2 = 1 - cosio2
+	e.cc4 = 2 * e.noUnkozai * coef1 * ao * omeosq * (e.eta*(2+0.5*etasq) + el.ecc*(0.5+2*etasq) -
+		j2*tsi/(ao*psisq)*(-3*e.con41*(1-2*eeta+etasq*(1.5-0.5*eeta))+
+			0.75*e.x1mth2*(2*etasq-eeta*(1+etasq))*math.Cos(2*el.argp)))
+	e.cc5 = 2 * coef1 * ao * omeosq * (1 + 2.75*(etasq+eeta) + eeta*etasq)
+
+	cosio4 := cosio2 * cosio2
+	temp1 := 1.5 * j2 * pinvsq * e.noUnkozai
+	temp2 := 0.5 * temp1 * j2 * pinvsq
+	temp3 := -0.46875 * j4 * pinvsq * pinvsq * e.noUnkozai
+	e.mdot = e.noUnkozai + 0.5*temp1*rteosq*e.con41 + 0.0625*temp2*rteosq*(13-78*cosio2+137*cosio4)
+	e.argpdot = -0.5*temp1*con42 + 0.0625*temp2*(7-114*cosio2+395*cosio4) + temp3*(3-36*cosio2+49*cosio4)
+	xhdot1 := -temp1 * cosio
+	e.nodedot = xhdot1 + (0.5*temp2*(4-19*cosio2)+2*temp3*(3-7*cosio2))*cosio
+	xpidot := e.argpdot + e.nodedot
+	e.omgcof = el.bstar * cc3 * math.Cos(el.argp)
+
+	if el.ecc > 1e-4 {
+		e.xmcof = -x2o3 * coef * el.bstar / eeta
+	}
+
+	e.nodecf = 3.5 * omeosq * xhdot1 * e.cc1
+	e.t2cof = 1.5 * e.cc1
+	e.xlcof = xlcof(sinio, cosio)
+	e.aycof = -0.5 * j3oj2 * sinio
+	e.delmo = math.Pow(1+e.eta*math.Cos(el.mo), 3)
+	e.sinmao = math.Sin(el.mo)
+	e.x7thm1 = 7*cosio2 - 1
+
+	if twoPi/e.noUnkozai >= 225 {
+		e.deep = true
+		e.isimp = true
+
+		ds := e.dscom(epoch, el.ecc, el.argp, 0, el.incl, el.node, e.noUnkozai)
+		e.dsinit(ds, 0, 0, el.ecc, eccsq, xpidot)
+	}
+
+	if !e.isimp {
+		cc1sq := e.cc1 * e.cc1
+		e.d2 = 4 * ao * tsi * cc1sq
+		temp := e.d2 * tsi * e.cc1 / 3
+		e.d3 = (17*ao + sfour) * temp
+		e.d4 = 0.5 * temp * ao * tsi * (221*ao + 31*sfour) * e.cc1
+		e.t3cof = e.d2 + 2*cc1sq
+		e.t4cof = 0.25 * (3*e.d3 + e.cc1*(12*e.d2+10*cc1sq))
+		e.t5cof = 0.2 * (3*e.d4 + 12*e.cc1*e.d3 + 6*e.d2*e.d2 + 15*cc1sq*(2*e.d2+cc1sq))
+	}
+
+	_, _, err := e.propagate(0)
+	return err
+}
+
+func xlcof(sinio, cosio float64) float64 {
+	if math.Abs(cosio+1) > 1.5e-12 {
+		return -0.25 * j3oj2 * sinio * (3 + 5*cosio) / (1 + cosio)
+	}
+	return -0.25 * j3oj2 * sinio * (3 + 5*cosio) / 1.5e-12
+}
+
+// propagate returns the position in km and the velocity in km/s in the
+// TEME frame, the minutes after the epoch.
+func (e Satellite) propagate(t float64) ([3]float64, [3]float64, error) {
+	var r, v [3]float64
+	el := e.el
+
+	// Secular gravity and atmospheric drag.
+	xmdf := el.mo + e.mdot*t
+	argpdf := el.argp + e.argpdot*t
+	nodedf := el.node + e.nodedot*t
+	argpm := argpdf
+	mm := xmdf
+	t2 := t * t
+	nodem := nodedf + e.nodecf*t2
+	tempa := 1 - e.cc1*t
+	tempe := el.bstar * e.cc4 * t
+	templ := e.t2cof * t2
+
+	if !e.isimp {
+		delomg := e.omgcof * t
+		delm := e.xmcof * (math.Pow(1+e.eta*math.Cos(xmdf), 3) - e.delmo)
+		temp := delomg + delm
+		mm = xmdf + temp
+		argpm = argpdf - temp
+		t3 := t2 * t
+		t4 := t3 * t
+		tempa = tempa - e.d2*t2 - e.d3*t3 - e.d4*t4
+		tempe = tempe + el.bstar*e.cc5*(math.Sin(mm)-e.sinmao)
+		templ = templ + e.t3cof*t3 + t4*(e.t4cof+t*e.t5cof)
+	}
+
+	nm := e.noUnkozai
+	em := el.ecc
+	inclm := el.incl
+
+	if e.deep {
+		em, argpm, inclm, mm, nodem, nm = e.dspace(t, em, argpm, inclm, mm, nodem)
+	}
+
+	if nm <= 0 {
+		return r, v, fmt.Errorf("mean motion is less than zero")
+	}
+
+	am := math.Pow(xke/nm, x2o3) * tempa * tempa
+	nm = xke / math.Pow(am, 1.5)
+	em = em - tempe
+
+	if em >= 1 || em < -0.001 {
+		return r, v, fmt.Errorf("eccentricity is out of range")
+	}
+	if em < 1e-6 {
+		em = 1e-6
+	}
+
+	mm = mm + e.noUnkozai*templ
+	xlm := mm + argpm + nodem
+	nodem = math.Mod(nodem, twoPi)
+	argpm = math.Mod(argpm, twoPi)
+	xlm = math.Mod(xlm, twoPi)
+	mm = math.Mod(xlm-argpm-nodem, twoPi)
+
+	// Lunar-solar periodics.
+	ep, xincp, argpp, nodep, mp := em, inclm, argpm, nodem, mm
+	sinip, cosip := math.Sin(inclm), math.Cos(inclm)
+	aycof, xlcofp := e.aycof, e.xlcof
+	con41, x1mth2, x7thm1 := e.con41, e.x1mth2, e.x7thm1
+
+	if e.deep {
+		ep, xincp, nodep, argpp, mp = e.dpper(t, ep, xincp, nodep, argpp, mp)
+		if xincp < 0 {
+			xincp = -xincp
+			nodep = nodep + math.Pi
+			argpp = argpp - math.Pi
+		}
+		if ep < 0 || ep > 1 {
+			return r, v, fmt.Errorf("perturbed eccentricity is out of range")
+		}
+
+		sinip, cosip = math.Sin(xincp), math.Cos(xincp)
+		aycof = -0.5 * j3oj2 * sinip
+		xlcofp = xlcof(sinip, cosip)
+	}
+
+	// Long period periodics.
+	axnl := ep * math.Cos(argpp)
+	temp := 1 / (am * (1 - ep*ep))
+	aynl := ep*math.Sin(argpp) + temp*aycof
+	xl := mp + argpp + nodep + temp*xlcofp*axnl
+
+	// Kepler's equation.
+	u := math.Mod(xl-nodep, twoPi)
+	eo1 := u
+	tem5 := 9999.9
+	var sineo1, coseo1 float64
+	for ktr := 1; math.Abs(tem5) >= 1e-12 && ktr <= 10; ktr++ {
+		sineo1, coseo1 = math.Sin(eo1), math.Cos(eo1)
+		tem5 = 1 - coseo1*axnl - sineo1*aynl
+		tem5 = (u - aynl*coseo1 + axnl*sineo1 - eo1) / tem5
+		if math.Abs(tem5) >= 0.95 {
+			tem5 = math.Copysign(0.95, tem5)
+		}
+		eo1 = eo1 + tem5
+	}
+
+	// Short period periodics.
+	ecose := axnl*coseo1 + aynl*sineo1
+	esine := axnl*sineo1 - aynl*coseo1
+	el2 := axnl*axnl + aynl*aynl
+	pl := am * (1 - el2)
+	if pl < 0 {
+		return r, v, fmt.Errorf("semi-latus rectum is less than zero")
+	}
+
+	rl := am * (1 - ecose)
+	rdotl := math.Sqrt(am) * esine / rl
+	rvdotl := math.Sqrt(pl) / rl
+	betal := math.Sqrt(1 - el2)
+	temp = esine / (1 + betal)
+	sinu := am / rl * (sineo1 - aynl - axnl*temp)
+	cosu := am / rl * (coseo1 - axnl + aynl*temp)
+	su := math.Atan2(sinu, cosu)
+	sin2u := (cosu + cosu) * sinu
+	cos2u := 1 - 2*sinu*sinu
+	temp = 1 / pl
+	temp1 := 0.5 * j2 * temp
+	temp2 := temp1 * temp
+
+	if e.deep {
+		cosisq := cosip * cosip
+		con41 = 3*cosisq - 1
+		x1mth2 = 1 - cosisq
+		x7thm1 = 7*cosisq - 1
+	}
+
+	mrt := rl*(1-1.5*temp2*betal*con41) + 0.5*temp1*x1mth2*cos2u
+	su = su - 0.25*temp2*x7thm1*sin2u
+	xnode := nodep + 1.5*temp2*cosip*sin2u
+	xinc := xincp + 1.5*temp2*cosip*sinip*cos2u
+	mvt := rdotl - nm*temp1*x1mth2*sin2u/xke
+	rvdot := rvdotl + nm*temp1*(x1mth2*cos2u+1.5*con41)/xke
+
+	// Orientation vectors.
+	sinsu, cossu := math.Sin(su), math.Cos(su)
+	snod, cnod := math.Sin(xnode), math.Cos(xnode)
+	sini, cosi := math.Sin(xinc), math.Cos(xinc)
+	xmx := -snod * cosi
+	xmy := cnod * cosi
+	ux := xmx*sinsu + cnod*cossu
+	uy := xmy*sinsu + snod*cossu
+	uz := sini * sinsu
+	vx := xmx*cossu - cnod*sinsu
+	vy := xmy*cossu - snod*sinsu
+	vz := sini * cossu
+
+	mr := mrt * earthRadius
+	r = [3]float64{mr * ux, mr * uy, mr * uz}
+	v = [3]float64{
+		(mvt*ux + rvdot*vx) * vkmpersec,
+		(mvt*uy + rvdot*vy) * vkmpersec,
+		(mvt*uz + rvdot*vz) * vkmpersec,
+	}
+
+	if mrt < 1 {
+		return r, v, fmt.Errorf("satellite has decayed")
+	}
+	return r, v, nil
+}
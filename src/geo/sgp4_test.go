@@ -0,0 +1,57 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+// Verification cases of Vallado et al. (AIAA 2006-6753), the TEME position in km and
+// velocity in km/s at the minutes after the epoch published in tcppver.out. 00005 is
+// a near Earth orbit, 08195 and 09880 are 12-hour resonant deep space orbits.
+var verificationCases = []struct {
+	tle     TLE
+	minutes float64
+	r, v    [3]float64
+}{
+	{
+		TLE{Line1: "1 00005U 58002B   00179.78495062  .00000023  00000-0  28098-4 0  4753",
+			Line2: "2 00005  34.2682 348.7242 1859667 331.7664  19.3264 10.82419157413667"},
+		0, [3]float64{7022.46529266, -1400.08296755, 0.03995155}, [3]float64{1.893841015, 6.405893759, 4.534807250},
+	},
+	{
+		TLE{Line1: "1 00005U 58002B   00179.78495062  .00000023  00000-0  28098-4 0  4753",
+			Line2: "2 00005  34.2682 348.7242 1859667 331.7664  19.3264 10.82419157413667"},
+		360, [3]float64{-7154.03120202, -3783.17682504, -3536.19412294}, [3]float64{4.741887409, -4.151817765, -2.093935425},
+	},
+	{
+		TLE{Line1: "1 08195U 75081A   06176.33215444  .00000099  00000-0  11873-3 0   813",
+			Line2: "2 08195  64.1586 279.0717 6877146 264.7651  20.2257  2.00491383225656"},
+		0, [3]float64{2349.89483350, -14785.93811562, 0.02119378}, [3]float64{2.721488096, -3.256811655, 4.498416672},
+	},
+	{
+		TLE{Line1: "1 09880U 77021A   06176.56157475  .00000421  00000-0  10000-3 0  9814",
+			Line2: "2 09880  64.5968 349.3786 7069051 270.0229  16.3320  2.00813614112380"},
+		0, [3]float64{13020.06750784, -2449.07193500, 1.15896030}, [3]float64{4.247363935, 1.597178501, 4.956708611},
+	},
+}
+
+func TestVerificationCases(t *testing.T) {
+	for _, tc := range verificationCases {
+		sat, err := NewSatellite(tc.tle)
+		if err != nil {
+			t.Fatalf("%05d: %s", tc.tle.Catalog(), err)
+		}
+
+		r, v, err := sat.propagate(tc.minutes)
+		if err != nil {
+			t.Fatalf("%05d at %g min: %s", tc.tle.Catalog(), tc.minutes, err)
+		}
+
+		for i := 0; i < 3; i++ {
+			if math.Abs(r[i]-tc.r[i]) > 1e-6 || math.Abs(v[i]-tc.v[i]) > 1e-9 {
+				t.Errorf("%05d at %g min: r %.8f v %.9f, want %.8f %.9f",
+					tc.tle.Catalog(), tc.minutes, r[i], v[i], tc.r[i], tc.v[i])
+			}
+		}
+	}
+}
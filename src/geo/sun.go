@@ -0,0 +1,39 @@
+package geo
+
+import (
+	"math"
+	"time"
+)
+
+// sunPosition returns the right ascension and declination in radians and the distance
+// in astronomical units of the Sun, with the low precision formulas of the Astronomical
+// Almanac (section C), accurate to 0.01° between 1950 and 2050.
+func sunPosition(t time.Time) (float64, float64, float64) {
+	n := julianDate(t) - 2451545
+	l := (280.460 + 0.9856474*n) * math.Pi / 180
+	g := (357.528 + 0.9856003*n) * math.Pi / 180
+	lambda := l + (1.915*math.Sin(g)+0.020*math.Sin(2*g))*math.Pi/180
+	epsilon := (23.439 - 0.0000004*n) * math.Pi / 180
+
+	alpha := math.Atan2(math.Cos(epsilon)*math.Sin(lambda), math.Cos(lambda))
+	delta := math.Asin(math.Sin(epsilon) * math.Sin(lambda))
+	distance := 1.00014 - 0.01671*math.Cos(g) - 0.00014*math.Cos(2*g)
+	return alpha, delta, distance
+}
+
+// SunDistance returns the Earth-Sun distance in astronomical units at the time.
+func SunDistance(t time.Time) float64 {
+	_, _, distance := sunPosition(t)
+	return distance
+}
+
+// SolarZenith returns the angle in degrees between the zenith of the point and
+// the Sun at the time of the point.
+func SolarZenith(p GeoPoint) float64 {
+	alpha, delta, _ := sunPosition(p.Time)
+	hour := gmst(julianDate(p.Time)) + p.Longitude*math.Pi/180 - alpha
+	lat := p.Latitude * math.Pi / 180
+
+	cos := math.Sin(lat)*math.Sin(delta) + math.Cos(lat)*math.Cos(delta)*math.Cos(hour)
+	return math.Acos(math.Max(-1, math.Min(1, cos))) * 180 / math.Pi
+}
@@ -0,0 +1,34 @@
+package geo
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSolarZenith(t *testing.T) {
+	// The Sun crosses the equator near the March equinox, at noon of the longitude
+	// corrected by the equation of time.
+	noon := GeoPoint{Time: time.Date(2020, 3, 20, 12, 7, 30, 0, time.UTC), Latitude: 0, Longitude: 0}
+	if z := SolarZenith(noon); z > 0.5 {
+		t.Errorf("solar zenith angle at the equinox noon is %f°, want less than 0.5°", z)
+	}
+
+	midnight := noon
+	midnight.Longitude = 180
+	if z := SolarZenith(midnight); z < 179.5 {
+		t.Errorf("solar zenith angle at the equinox midnight is %f°, want more than 179.5°", z)
+	}
+
+	for _, tc := range []struct {
+		date     time.Time
+		distance float64
+	}{
+		{time.Date(2020, 1, 5, 0, 0, 0, 0, time.UTC), 0.9833},
+		{time.Date(2020, 7, 4, 0, 0, 0, 0, time.UTC), 1.0167},
+	} {
+		if d := SunDistance(tc.date); math.Abs(d-tc.distance) > 1e-3 {
+			t.Errorf("Earth-Sun distance on %s is %f AU, want %f AU", tc.date.Format("2006-01-02"), d, tc.distance)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package geo
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TLE is the two-line element set of a spacecraft. The name is the
+// title line of three-line files, empty when the file has none.
+type TLE struct {
+	Name  string
+	Line1 string
+	Line2 string
+}
+
+// Catalog returns the NORAD catalog number of the element set.
+func (e TLE) Catalog() int {
+	n, _ := strconv.Atoi(strings.TrimSpace(e.Line1[2:7]))
+	return n
+}
+
+// ReadTLE returns the element set of the catalog number inside a file with
+// two or three-line element sets, like the CelesTrak weather.txt. A zero
+// catalog number returns the first element set of the file.
+func ReadTLE(path string, catalog int) (TLE, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return TLE{}, err
+	}
+	defer file.Close()
+
+	var name, line1 string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \r")
+
+		switch {
+		case strings.HasPrefix(line, "1 ") && len(line) >= 69:
+			line1 = line
+		case strings.HasPrefix(line, "2 ") && len(line) >= 69 && line1 != "":
+			tle := TLE{Name: name, Line1: line1, Line2: line}
+			if catalog == 0 || tle.Catalog() == catalog {
+				return tle, nil
+			}
+			name, line1 = "", ""
+		default:
+			name = strings.TrimSpace(line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return TLE{}, err
+	}
+	return TLE{}, fmt.Errorf("no element set of catalog number %d inside %s", catalog, path)
+}
+
+// elements of the TLE in the units of the propagator: radians and minutes.
+type elements struct {
+	epoch time.Time
+	bstar float64
+	ndot  float64
+	nddot float64
+	incl  float64
+	node  float64
+	ecc   float64
+	argp  float64
+	mo    float64
+	no    float64
+}
+
+func (e TLE) parse() (elements, error) {
+	var el elements
+	if len(e.Line1) < 69 || len(e.Line2) < 69 {
+		return el, fmt.Errorf("element set lines are too short")
+	}
+
+	var errs []error
+	field := func(line string, first, last int) float64 {
+		s := strings.TrimSpace(line[first:last])
+		if s == "" {
+			return 0
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		return v
+	}
+
+	// Fields with an assumed leading decimal point and an exponent, like " 12345-4".
+	exponential := func(line string, first, last int) float64 {
+		s := strings.TrimSpace(line[first:last])
+		if s == "" {
+			return 0
+		}
+		sign := 1.0
+		if s[0] == '-' || s[0] == '+' {
+			if s[0] == '-' {
+				sign = -1
+			}
+			s = s[1:]
+		}
+		i := strings.LastIndexAny(s, "+-")
+		if i <= 0 {
+			return sign * field("."+s, 0, len(s)+1)
+		}
+		mantissa := field("."+s[:i], 0, i+1)
+		exponent := field(s[i:], 0, len(s)-i)
+		return sign * mantissa * math.Pow(10, exponent)
+	}
+
+	year := int(field(e.Line1, 18, 20))
+	if year < 57 {
+		year += 2000
+	} else {
+		year += 1900
+	}
+	days := field(e.Line1, 20, 32)
+	el.epoch = time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration((days - 1) * 86400 * float64(time.Second)))
+
+	const revPerDay = 2 * math.Pi / 1440
+	const deg = math.Pi / 180
+
+	el.ndot = field(e.Line1, 33, 43) * revPerDay / 1440
+	el.nddot = exponential(e.Line1, 44, 52) * revPerDay / (1440 * 1440)
+	el.bstar = exponential(e.Line1, 53, 61)
+
+	el.incl = field(e.Line2, 8, 16) * deg
+	el.node = field(e.Line2, 17, 25) * deg
+	el.ecc = field(e.Line2, 26, 33) * 1e-7
+	el.argp = field(e.Line2, 34, 42) * deg
+	el.mo = field(e.Line2, 43, 51) * deg
+	el.no = field(e.Line2, 52, 63) * revPerDay
+
+	if len(errs) > 0 {
+		return el, fmt.Errorf("invalid element set: %s", errs[0])
+	}
+	if el.no <= 0 {
+		return el, fmt.Errorf("invalid element set: zero mean motion")
+	}
+	return el, nil
+}
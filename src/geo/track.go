@@ -0,0 +1,131 @@
+package geo
+
+import (
+	"encoding/csv"
+	"math"
+	"os"
+	"strconv"
+	"time"
+)
+
+// WGS-84 ellipsoid of the geodetic coordinates.
+const (
+	wgs84Radius     = 6378137.0
+	wgs84Flattening = 1 / 298.257223563
+	wgs84Ecc2       = wgs84Flattening * (2 - wgs84Flattening)
+)
+
+// Direction of the spacecraft along the orbit.
+type Direction int
+
+// Orbit directions. Descending passes are southbound.
+const (
+	Ascending Direction = iota
+	Descending
+)
+
+func (d Direction) String() string {
+	if d == Descending {
+		return "descending"
+	}
+	return "ascending"
+}
+
+// GetDirection returns the direction of the spacecraft at the state vector.
+func (e StateVector) GetDirection() Direction {
+	if e.Velocity[2] < 0 {
+		return Descending
+	}
+	return Ascending
+}
+
+// GeoPoint is a geodetic position on the WGS-84 ellipsoid at a time. The
+// latitude and longitude are in degrees and the altitude in meters.
+type GeoPoint struct {
+	Time      time.Time
+	Latitude  float64
+	Longitude float64
+	Altitude  float64
+}
+
+// Distance returns the great circle distance in meters between the points.
+func Distance(a, b GeoPoint) float64 {
+	lat1, lat2 := a.Latitude*math.Pi/180, b.Latitude*math.Pi/180
+	dlat := lat2 - lat1
+	dlon := wrapLongitude(b.Longitude-a.Longitude) * math.Pi / 180
+
+	h := math.Pow(math.Sin(dlat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dlon/2), 2)
+	return 2 * wgs84Radius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// GetGeoPoint returns the sub-satellite point of the state vector.
+func (e StateVector) GetGeoPoint() GeoPoint {
+	x, y, z := e.Position[0], e.Position[1], e.Position[2]
+	p := math.Hypot(x, y)
+
+	lat := math.Atan2(z, p*(1-wgs84Ecc2))
+	var alt float64
+	for i := 0; i < 5; i++ {
+		sin := math.Sin(lat)
+		n := wgs84Radius / math.Sqrt(1-wgs84Ecc2*sin*sin)
+		alt = p/math.Cos(lat) - n
+		lat = math.Atan2(z, p*(1-wgs84Ecc2*n/(n+alt)))
+	}
+
+	return GeoPoint{
+		Time:      e.Time,
+		Latitude:  lat * 180 / math.Pi,
+		Longitude: math.Atan2(y, x) * 180 / math.Pi,
+		Altitude:  alt,
+	}
+}
+
+// Track is the series of sub-satellite points of the scan lines of an image.
+type Track struct {
+	Points    []GeoPoint
+	Direction Direction
+}
+
+// NewTrack propagates the orbit to the time of each of the lines, the first one at
+// the start time. The direction is the one of the spacecraft at the middle line.
+func NewTrack(sat *Satellite, start time.Time, interval time.Duration, lines int) (Track, error) {
+	var track Track
+	for i := 0; i < lines; i++ {
+		sv, err := sat.Propagate(start.Add(time.Duration(i) * interval))
+		if err != nil {
+			return Track{}, err
+		}
+
+		if i == lines/2 {
+			track.Direction = sv.GetDirection()
+		}
+		track.Points = append(track.Points, sv.GetGeoPoint())
+	}
+	return track, nil
+}
+
+// SaveCSV writes the track as a CSV file with a header line, a row per scan line.
+// Times are RFC 3339 in UTC and the altitudes have millimeter resolution.
+func (e Track) SaveCSV(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	w.Write([]string{"line", "time", "latitude", "longitude", "altitude", "direction"})
+	for i, p := range e.Points {
+		w.Write([]string{
+			strconv.Itoa(i),
+			p.Time.UTC().Format(time.RFC3339Nano),
+			strconv.FormatFloat(p.Latitude, 'f', 6, 64),
+			strconv.FormatFloat(p.Longitude, 'f', 6, 64),
+			strconv.FormatFloat(p.Altitude, 'f', 3, 64),
+			e.Direction.String(),
+		})
+	}
+
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,225 @@
+package geo
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+)
+
+// Polyline is a line through geodetic positions. Polygons are saved as the polylines of their rings.
+type Polyline []GeoPoint
+
+// Densify returns the polyline with points added between the ones farther than the
+// step in degrees, along the shortest longitude difference.
+func (e Polyline) Densify(step float64) Polyline {
+	if len(e) < 2 || step <= 0 {
+		return e
+	}
+
+	res := Polyline{e[0]}
+	for i := 1; i < len(e); i++ {
+		a, b := e[i-1], e[i]
+		dlat := b.Latitude - a.Latitude
+		dlon := wrapLongitude(b.Longitude - a.Longitude)
+
+		n := int(math.Ceil(math.Max(math.Abs(dlat), math.Abs(dlon)) / step))
+		for j := 1; j < n; j++ {
+			f := float64(j) / float64(n)
+			res = append(res, GeoPoint{Latitude: a.Latitude + dlat*f, Longitude: a.Longitude + dlon*f})
+		}
+		res = append(res, b)
+	}
+	return res
+}
+
+// ReadVector returns the lines, polygons and their rings inside a GeoJSON (.geojson or
+// .json) or ESRI shapefile (.shp) in WGS-84 latitude and longitude. Points are ignored.
+func ReadVector(path string) ([]Polyline, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".geojson", ".json":
+		return parseGeoJSON(buf)
+	case ".shp":
+		return parseShapefile(buf)
+	}
+	return nil, fmt.Errorf("unknown vector format of %s", filepath.Base(path))
+}
+
+// Graticule returns the meridians and parallels every step in degrees.
+func Graticule(step float64) []Polyline {
+	var lines []Polyline
+	if step <= 0 {
+		return lines
+	}
+
+	for lon := -180.0; lon < 180; lon += step {
+		var line Polyline
+		for lat := -90.0; lat < 90; lat += math.Min(step, 1) {
+			line = append(line, GeoPoint{Latitude: lat, Longitude: lon})
+		}
+		lines = append(lines, append(line, GeoPoint{Latitude: 90, Longitude: lon}))
+	}
+
+	for lat := -90 + step; lat < 90; lat += step {
+		var line Polyline
+		for lon := -180.0; lon < 180; lon += math.Min(step, 1) {
+			line = append(line, GeoPoint{Latitude: lat, Longitude: lon})
+		}
+		lines = append(lines, append(line, GeoPoint{Latitude: lat, Longitude: 180}))
+	}
+
+	return lines
+}
+
+// geoJSON is any object of a GeoJSON document.
+type geoJSON struct {
+	Type        string          `json:"type"`
+	Features    []geoJSON       `json:"features"`
+	Geometry    *geoJSON        `json:"geometry"`
+	Geometries  []geoJSON       `json:"geometries"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+func parseGeoJSON(buf []byte) ([]Polyline, error) {
+	var doc geoJSON
+	if err := json.Unmarshal(buf, &doc); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %s", err)
+	}
+
+	var lines []Polyline
+	if err := doc.collect(&lines); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %s", err)
+	}
+	return lines, nil
+}
+
+func (e geoJSON) collect(lines *[]Polyline) error {
+	switch e.Type {
+	case "FeatureCollection":
+		for _, f := range e.Features {
+			if err := f.collect(lines); err != nil {
+				return err
+			}
+		}
+	case "Feature":
+		if e.Geometry != nil {
+			return e.Geometry.collect(lines)
+		}
+	case "GeometryCollection":
+		for _, g := range e.Geometries {
+			if err := g.collect(lines); err != nil {
+				return err
+			}
+		}
+	case "LineString":
+		var c [][]float64
+		if err := json.Unmarshal(e.Coordinates, &c); err != nil {
+			return err
+		}
+		*lines = append(*lines, newPolyline(c))
+	case "MultiLineString", "Polygon":
+		var c [][][]float64
+		if err := json.Unmarshal(e.Coordinates, &c); err != nil {
+			return err
+		}
+		for _, l := range c {
+			*lines = append(*lines, newPolyline(l))
+		}
+	case "MultiPolygon":
+		var c [][][][]float64
+		if err := json.Unmarshal(e.Coordinates, &c); err != nil {
+			return err
+		}
+		for _, p := range c {
+			for _, l := range p {
+				*lines = append(*lines, newPolyline(l))
+			}
+		}
+	}
+	return nil
+}
+
+// newPolyline returns the polyline of the GeoJSON positions, ordered as longitude and latitude.
+func newPolyline(positions [][]float64) Polyline {
+	line := make(Polyline, 0, len(positions))
+	for _, p := range positions {
+		if len(p) >= 2 {
+			line = append(line, GeoPoint{Latitude: p[1], Longitude: p[0]})
+		}
+	}
+	return line
+}
+
+// Shapefile shape types with parts of points. The Z and M types add values after the points.
+var shapefileLines = map[int32]bool{
+	3: true, 5: true, 13: true, 15: true, 23: true, 25: true,
+}
+
+// parseShapefile returns the polylines and polygons of the main file of a shapefile.
+// The coordinates must be geographic, the projection file isn't read.
+func parseShapefile(buf []byte) ([]Polyline, error) {
+	if len(buf) < 100 || binary.BigEndian.Uint32(buf) != 9994 {
+		return nil, errors.New("invalid shapefile header")
+	}
+
+	var lines []Polyline
+	end := int(binary.BigEndian.Uint32(buf[24:])) * 2
+	if end > len(buf) {
+		end = len(buf)
+	}
+
+	for p := 100; p+8 <= end; {
+		length := int(binary.BigEndian.Uint32(buf[p+4:])) * 2
+		record := buf[p+8:]
+		p += 8 + length
+		if p > end || length < 4 {
+			break
+		}
+		record = record[:length]
+
+		shape := int32(binary.LittleEndian.Uint32(record))
+		if !shapefileLines[shape] {
+			continue
+		}
+		if length < 44 {
+			return nil, errors.New("invalid shapefile record")
+		}
+
+		numParts := int(binary.LittleEndian.Uint32(record[36:]))
+		numPoints := int(binary.LittleEndian.Uint32(record[40:]))
+		points := 44 + numParts*4
+		if numParts < 0 || numPoints < 0 || points+numPoints*16 > length {
+			return nil, errors.New("invalid shapefile record")
+		}
+
+		for i := 0; i < numParts; i++ {
+			first := int(binary.LittleEndian.Uint32(record[44+i*4:]))
+			last := numPoints
+			if i+1 < numParts {
+				last = int(binary.LittleEndian.Uint32(record[44+(i+1)*4:]))
+			}
+			if first < 0 || last > numPoints || first > last {
+				return nil, errors.New("invalid shapefile record")
+			}
+
+			line := make(Polyline, 0, last-first)
+			for j := first; j < last; j++ {
+				x := math.Float64frombits(binary.LittleEndian.Uint64(record[points+j*16:]))
+				y := math.Float64frombits(binary.LittleEndian.Uint64(record[points+j*16+8:]))
+				line = append(line, GeoPoint{Latitude: y, Longitude: x})
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, nil
+}
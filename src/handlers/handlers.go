@@ -5,22 +5,30 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 	"weatherdump/src/handlers/interfaces"
+	"weatherdump/src/protocols/helpers"
 	npoessDecoder "weatherdump/src/protocols/hrd/decoder"
 	npoessProcessor "weatherdump/src/protocols/hrd/processor"
 	meteorDecoder "weatherdump/src/protocols/lrpt/decoder"
 	meteorProcessor "weatherdump/src/protocols/lrpt/processor"
+
+	"github.com/fatih/color"
 )
 
 // AvailableDecoders shows the currently available decoders for this build.
 var AvailableDecoders = interfaces.DecoderMakers{
 	"lrpt": {
-		"soft": meteorDecoder.NewDecoder,
+		"soft":           meteorDecoder.NewDecoder,
+		"oqpsk":          meteorDecoder.NewOQPSKDecoder,
+		"interleaved80k": meteorDecoder.NewInterleaved80kDecoder,
+		"iq":             meteorDecoder.NewIQDecoder,
 	},
 	"hrd": {
 		"soft": npoessDecoder.NewSoftSymbolDecoder,
 		"cadu": npoessDecoder.NewCaduDecoder,
 		"asm":  npoessDecoder.NewAsmDecoder,
+		"iq":   npoessDecoder.NewIQDecoder,
 	},
 }
 
@@ -30,12 +38,69 @@ var AvailableProcessors = interfaces.ProcessorMakers{
 	"hrd":  npoessProcessor.NewProcessor,
 }
 
+// ProcessorOptions of the optional outputs saved next to the products.
+type ProcessorOptions struct {
+	DumpAPIDs      []uint16
+	ExportPDS      bool
+	Interferograms bool
+	Spectrum       []float64
+	TLE            string
+	PassDate       time.Time
+}
+
+// ConfigureProcessor enables the optional outputs supported by the processor.
+// It should be called before the processor Work.
+func ConfigureProcessor(processor interfaces.Processor, outputPath string, opts ProcessorOptions) {
+	if dumper, ok := processor.(interfaces.PacketDumper); ok && len(opts.DumpAPIDs) > 0 {
+		dumper.DumpPackets(outputPath, opts.DumpAPIDs)
+	}
+
+	if opts.ExportPDS {
+		if exporter, ok := processor.(interfaces.PDSExporter); ok {
+			exporter.ExportPDS(outputPath)
+		} else {
+			color.Yellow("[CLI] The processor doesn't support production data sets, ignoring.")
+		}
+	}
+
+	if opts.Interferograms || opts.Spectrum != nil {
+		if exporter, ok := processor.(interfaces.InterferogramExporter); ok {
+			exporter.ExportInterferograms(outputPath, opts.Spectrum)
+		} else {
+			color.Yellow("[CLI] The processor doesn't support interferograms, ignoring.")
+		}
+	}
+
+	if !opts.PassDate.IsZero() {
+		if dater, ok := processor.(interfaces.PassDater); ok {
+			dater.SetPassDate(opts.PassDate)
+		} else {
+			color.Yellow("[CLI] The processor reads the date of the pass from the packets, ignoring.")
+		}
+	}
+
+	if opts.TLE != "" {
+		if propagator, ok := processor.(interfaces.OrbitPropagator); ok {
+			propagator.SetTLE(opts.TLE)
+		} else {
+			color.Yellow("[CLI] The processor doesn't support orbit propagation, ignoring.")
+		}
+	}
+}
+
+var liveInputReplacer = strings.NewReplacer("://", "_", ":", "_", "/", "_")
+
 // GenerateDirectories takes user paths and returns the standard output scheme.
 func GenerateDirectories(inputFile string, outputPath string) (string, string) {
 	inputFileName := filepath.Base(inputFile)
 	inputFileName = strings.TrimSuffix(inputFileName, filepath.Ext(inputFile))
 	workingPath := filepath.Dir(inputFile)
 
+	if helpers.IsLiveInput(inputFile) {
+		inputFileName = liveInputReplacer.Replace(inputFile)
+		workingPath = "."
+	}
+
 	if outputPath != "" {
 		workingPath = outputPath
 		if _, err := os.Stat(workingPath); os.IsNotExist(err) {
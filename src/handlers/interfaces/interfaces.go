@@ -1,6 +1,8 @@
 package interfaces
 
 import (
+	"io"
+	"time"
 	"weatherdump/src/img"
 	"weatherdump/src/protocols/helpers"
 )
@@ -16,3 +18,58 @@ type DecoderMakers map[string]map[string]func(string) Decoder
 type Decoder interface {
 	Work(string, string, chan bool)
 }
+
+// Demodulator is implemented by decoders reading baseband IQ recordings.
+// The sample rate is only required by raw recordings without a header.
+type Demodulator interface {
+	SetSampleRate(float64)
+}
+
+// Reporter is implemented by decoders able to write their progress and
+// messages into another output than the standard one.
+type Reporter interface {
+	SetOutput(io.Writer)
+}
+
+// Prober is implemented by decoders and processors able to recognize their input.
+// The confidence returned is between zero and one.
+type Prober interface {
+	Probe(string) float64
+}
+
+// Inspector is implemented by processors able to summarize the
+// contents of a decoded file without processing the images.
+type Inspector interface {
+	Inspect(string) (*helpers.Summary, error)
+}
+
+// PacketDumper is implemented by processors able to save the raw packets
+// of the selected APIDs to the output path. It should be called before Work.
+type PacketDumper interface {
+	DumpPackets(string, []uint16)
+}
+
+// PDSExporter is implemented by processors able to save the instrument packets as
+// NASA Production Data Sets inside the output path. It should be called before Work.
+type PDSExporter interface {
+	ExportPDS(string)
+}
+
+// InterferogramExporter is implemented by processors able to save the interferograms
+// of an infrared sounder inside the output path. The wavenumber range in cm⁻¹ enables
+// the spectra and the brightness image, nil disables them. It should be called before Work.
+type InterferogramExporter interface {
+	ExportInterferograms(string, []float64)
+}
+
+// PassDater is implemented by processors of datalinks carrying only the time of the
+// day, the date of the pass is set instead of guessed. It should be called before Work.
+type PassDater interface {
+	SetPassDate(time.Time)
+}
+
+// OrbitPropagator is implemented by processors able to propagate the orbit of the
+// spacecraft with the element sets of a TLE file. It should be called before Work.
+type OrbitPropagator interface {
+	SetTLE(string)
+}
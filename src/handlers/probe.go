@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"sort"
+	"weatherdump/src/handlers/interfaces"
+)
+
+// Detection is the confidence of the workflow being able to handle an input.
+// The decoder is "none" when the input already holds decoded transfer frames.
+type Detection struct {
+	Datalink   string
+	Decoder    string
+	Confidence float64
+}
+
+// Probe checks the head of the input file against every available decoder
+// and processor. The detections are sorted by the most confident first.
+func Probe(inputFile string, sampleRate float64) []Detection {
+	var detections []Detection
+
+	for datalink, decoders := range AvailableDecoders {
+		for decoderType, maker := range decoders {
+			worker := maker("")
+			if demodulator, ok := worker.(interfaces.Demodulator); ok {
+				demodulator.SetSampleRate(sampleRate)
+			}
+			if prober, ok := worker.(interfaces.Prober); ok {
+				detections = append(detections, Detection{datalink, decoderType, prober.Probe(inputFile)})
+			}
+		}
+	}
+
+	for datalink, maker := range AvailableProcessors {
+		if prober, ok := maker("", nil).(interfaces.Prober); ok {
+			detections = append(detections, Detection{datalink, "none", prober.Probe(inputFile)})
+		}
+	}
+
+	sort.Slice(detections, func(i, j int) bool {
+		if detections[i].Confidence != detections[j].Confidence {
+			return detections[i].Confidence > detections[j].Confidence
+		}
+		if detections[i].Datalink != detections[j].Datalink {
+			return detections[i].Datalink < detections[j].Datalink
+		}
+		return detections[i].Decoder < detections[j].Decoder
+	})
+
+	return detections
+}
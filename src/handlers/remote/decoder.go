@@ -8,13 +8,16 @@ import (
 	"os"
 	"strings"
 	"weatherdump/src/handlers"
+	"weatherdump/src/handlers/interfaces"
+	"weatherdump/src/protocols/helpers"
 )
 
 type decoderRequest struct {
-	InputFile  string `schema:"inputFile,required"`
-	Datalink   string `schema:"datalink,required"`
-	Decoder    string `schema:"decoder,required"`
-	OutputPath string `schema:"outputPath"`
+	InputFile  string  `schema:"inputFile,required"`
+	Datalink   string  `schema:"datalink,required"`
+	Decoder    string  `schema:"decoder,required"`
+	OutputPath string  `schema:"outputPath"`
+	SampleRate float64 `schema:"sampleRate"`
 }
 
 func (s *Remote) decoderHandler(w http.ResponseWriter, r *http.Request) {
@@ -26,7 +29,7 @@ func (s *Remote) decoderHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if _, err := os.Stat(req.InputFile); os.IsNotExist(err) {
+	if _, err := os.Stat(req.InputFile); os.IsNotExist(err) && !helpers.IsLiveInput(req.InputFile) {
 		ResError(w, "INPUT_FILE_NOT_FOUND", "")
 		return
 	}
@@ -37,7 +40,11 @@ func (s *Remote) decoderHandler(w http.ResponseWriter, r *http.Request) {
 
 	go func() {
 		s.routines[id] = make(chan bool)
-		handlers.AvailableDecoders[req.Datalink][req.Decoder](id.String()).Work(req.InputFile, decodedFile, s.routines[id])
+		worker := handlers.AvailableDecoders[req.Datalink][req.Decoder](id.String())
+		if demodulator, ok := worker.(interfaces.Demodulator); ok {
+			demodulator.SetSampleRate(req.SampleRate)
+		}
+		worker.Work(req.InputFile, decodedFile, s.routines[id])
 		delete(s.routines, id)
 		color.Magenta("[RMT] Decoder %s exited.\n", id.String())
 	}()
@@ -12,11 +12,27 @@ import (
 )
 
 type processorRequest struct {
-	InputFile  string `schema:"inputPath,required"`
-	Datalink   string `schema:"datalink,required"`
-	Pipeline   string `schema:"pipeline,required"`
-	Manifest   string `schema:"manifest,required"`
-	OutputPath string `schema:"outputPath"`
+	InputFile       string  `schema:"inputPath,required"`
+	Datalink        string  `schema:"datalink,required"`
+	Pipeline        string  `schema:"pipeline,required"`
+	Manifest        string  `schema:"manifest,required"`
+	OutputPath      string  `schema:"outputPath"`
+	DumpAPIDs       string  `schema:"dumpAPIDs"`
+	ExportPDS       bool    `schema:"exportPDS"`
+	CrIS            bool    `schema:"cris"`
+	Spectrum        string  `schema:"crisSpectrum"`
+	TLE             string  `schema:"tle"`
+	PassDate        string  `schema:"passDate"`
+	Projection      string  `schema:"projection"`
+	BBox            string  `schema:"bbox"`
+	Resolution      float64 `schema:"resolution"`
+	Resampling      string  `schema:"resampling"`
+	Coastlines      string  `schema:"coastlines"`
+	CoastlinesStyle string  `schema:"coastlinesStyle"`
+	Borders         string  `schema:"borders"`
+	BordersStyle    string  `schema:"bordersStyle"`
+	Graticule       float64 `schema:"graticule"`
+	GraticuleStyle  string  `schema:"graticuleStyle"`
 }
 
 func (s *Remote) processorHandler(w http.ResponseWriter, r *http.Request) {
@@ -33,9 +49,42 @@ func (s *Remote) processorHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	id := s.register()
-	wf := img.NewPipeline()
-	req.OutputPath, _ = handlers.GenerateDirectories(req.InputFile, req.OutputPath)
+	apids, err := helpers.ParseAPIDs(req.DumpAPIDs)
+	if err != nil {
+		ResError(w, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	spectrum, err := helpers.ParseRange(req.Spectrum)
+	if err != nil {
+		ResError(w, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	date, err := helpers.ParseDate(req.PassDate)
+	if err != nil {
+		ResError(w, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	reprojection, err := helpers.ParseReprojection(req.Projection, req.BBox, req.Resolution, req.Resampling)
+	if err != nil {
+		ResError(w, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	overlay, err := helpers.LoadOverlay(helpers.OverlayOptions{
+		Coastlines:      req.Coastlines,
+		CoastlinesStyle: req.CoastlinesStyle,
+		Borders:         req.Borders,
+		BordersStyle:    req.BordersStyle,
+		Graticule:       req.Graticule,
+		GraticuleStyle:  req.GraticuleStyle,
+	})
+	if err != nil {
+		ResError(w, "INVALID_REQUEST", err.Error())
+		return
+	}
 
 	var p map[string]struct {
 		Name      string
@@ -43,15 +92,32 @@ func (s *Remote) processorHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	json.Unmarshal([]byte(req.Pipeline), &p)
 
+	wf := img.NewPipeline()
 	for key, task := range p {
-		wf.AddPipe(key, task.Activated)
+		if err := wf.AddPipe(key, task.Activated); err != nil {
+			ResError(w, "INVALID_REQUEST", err.Error())
+			return
+		}
 	}
+	wf.SetReprojection(reprojection)
+	wf.SetOverlay(overlay)
+
+	id := s.register()
+	req.OutputPath, _ = handlers.GenerateDirectories(req.InputFile, req.OutputPath)
 
 	go func() {
 		var m helpers.ProcessingManifest
 		json.Unmarshal([]byte(req.Manifest), &m)
 
 		processor := handlers.AvailableProcessors[req.Datalink](id.String(), &m)
+		handlers.ConfigureProcessor(processor, req.OutputPath, handlers.ProcessorOptions{
+			DumpAPIDs:      apids,
+			ExportPDS:      req.ExportPDS,
+			Interferograms: req.CrIS,
+			Spectrum:       spectrum,
+			TLE:            req.TLE,
+			PassDate:       date,
+		})
 
 		processor.Work(req.InputFile)
 		processor.Export(req.OutputPath, wf)
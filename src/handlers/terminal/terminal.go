@@ -1,19 +1,55 @@
 package terminal
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
 	"os"
 	"strings"
 	"weatherdump/src/handlers"
+	"weatherdump/src/handlers/interfaces"
 	"weatherdump/src/img"
+	"weatherdump/src/protocols/helpers"
 
 	"github.com/fatih/color"
 )
 
+const minConfidence = 0.5
+
+// HandleAuto probes the input file and activates the workflow
+// of the most likely datalink and decoder.
+func HandleAuto(inputFile, outputPath string, sampleRate float64, opts handlers.ProcessorOptions, wf img.Pipeline) {
+	fmt.Println("[CLI] Probing the input file format.")
+
+	detections := handlers.Probe(inputFile, sampleRate)
+	for _, d := range detections {
+		if d.Confidence > 0 {
+			fmt.Printf("[CLI] %-4s %-14s %5.1f%%\n", strings.ToUpper(d.Datalink), d.Decoder, d.Confidence*100)
+		}
+	}
+
+	if len(detections) == 0 || detections[0].Confidence < minConfidence {
+		color.Yellow("[CLI] Couldn't recognize the input file. Try 'weatherdump <datalink> <decoder> <file>' instead.")
+		return
+	}
+
+	best := detections[0]
+	color.Green("[CLI] Input recognized as %s with the %s decoder (%.1f%% confidence).", strings.ToUpper(best.Datalink), best.Decoder, best.Confidence*100)
+	HandleInput(best.Datalink, inputFile, outputPath, best.Decoder, sampleRate, opts, wf)
+}
+
 // HandleInput with user defined functions gathered by the CLI tool.
-func HandleInput(datalink, inputFile, outputPath, decoderType string, wf img.Pipeline) {
+// The optional outputs of the processor are saved next to the products.
+func HandleInput(datalink, inputFile, outputPath, decoderType string, sampleRate float64, opts handlers.ProcessorOptions, wf img.Pipeline) {
 	fmt.Printf("[CLI] Activating %s workflow.\n", strings.ToUpper(datalink))
 
+	if _, err := os.Stat(inputFile); os.IsNotExist(err) && !helpers.IsLiveInput(inputFile) {
+		fmt.Println("[CLI] Input file not found. Exiting...\nError:", err)
+		os.Exit(0)
+	}
+
 	workingPath, fileName := handlers.GenerateDirectories(inputFile, outputPath)
 
 	if decoderType != "none" {
@@ -22,9 +58,7 @@ func HandleInput(datalink, inputFile, outputPath, decoderType string, wf img.Pip
 			return
 		}
 
-		decodedFile := fmt.Sprintf("%s/decoded_%s.bin", workingPath, strings.ToLower(fileName))
-		handlers.AvailableDecoders[datalink][decoderType]("").Work(inputFile, decodedFile, nil)
-		inputFile = decodedFile
+		inputFile = decode(datalink, decoderType, inputFile, workingPath, fileName, sampleRate)
 	}
 
 	if _, err := os.Stat(inputFile); os.IsNotExist(err) || inputFile == "" {
@@ -33,6 +67,80 @@ func HandleInput(datalink, inputFile, outputPath, decoderType string, wf img.Pip
 	}
 
 	processor := handlers.AvailableProcessors[datalink]("", nil)
+	handlers.ConfigureProcessor(processor, workingPath, opts)
+
 	processor.Work(inputFile)
 	processor.Export(workingPath, wf)
 }
+
+// HandleInfo prints a summary of the frames and packets of the input file.
+// Recordings are decoded into a temporary file before being inspected. The JSON
+// output is the only message written to stdout, everything else goes to stderr.
+func HandleInfo(inputFile string, sampleRate float64, asJSON bool) {
+	messages := io.Writer(os.Stdout)
+	if asJSON {
+		messages = os.Stderr
+	}
+
+	summary, err := inspect(inputFile, sampleRate, messages)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "[CLI] Couldn't inspect the input file. Exiting...\nError:", err)
+		os.Exit(1)
+	}
+
+	if asJSON {
+		if err := summary.PrintJSON(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	summary.Print(os.Stdout)
+}
+
+func inspect(inputFile string, sampleRate float64, messages io.Writer) (*helpers.Summary, error) {
+	detections := handlers.Probe(inputFile, sampleRate)
+	if len(detections) == 0 || detections[0].Confidence < minConfidence {
+		return nil, errors.New("unknown input format")
+	}
+
+	best := detections[0]
+	fmt.Fprintf(messages, "[CLI] Input recognized as %s with the %s decoder (%.1f%% confidence).\n", strings.ToUpper(best.Datalink), best.Decoder, best.Confidence*100)
+
+	if best.Decoder != "none" {
+		decodedFile, err := ioutil.TempFile("", "weatherdump_*.bin")
+		if err != nil {
+			return nil, err
+		}
+		decodedFile.Close()
+		defer os.Remove(decodedFile.Name())
+
+		worker := newDecoder(best.Datalink, best.Decoder, sampleRate, messages)
+		worker.Work(inputFile, decodedFile.Name(), nil)
+		inputFile = decodedFile.Name()
+	}
+
+	inspector, ok := handlers.AvailableProcessors[best.Datalink]("", nil).(interfaces.Inspector)
+	if !ok {
+		return nil, fmt.Errorf("the %s processor can't inspect files", best.Datalink)
+	}
+	return inspector.Inspect(inputFile)
+}
+
+// decode the input file with the decoder and return the decoded file path.
+func decode(datalink, decoderType, inputFile, workingPath, fileName string, sampleRate float64) string {
+	decodedFile := fmt.Sprintf("%s/decoded_%s.bin", workingPath, strings.ToLower(fileName))
+	newDecoder(datalink, decoderType, sampleRate, os.Stdout).Work(inputFile, decodedFile, nil)
+	return decodedFile
+}
+
+// newDecoder returns the decoder writing its progress and messages into the writer.
+func newDecoder(datalink, decoderType string, sampleRate float64, messages io.Writer) interfaces.Decoder {
+	worker := handlers.AvailableDecoders[datalink][decoderType]("")
+	if demodulator, ok := worker.(interfaces.Demodulator); ok {
+		demodulator.SetSampleRate(sampleRate)
+	}
+	if reporter, ok := worker.(interfaces.Reporter); ok {
+		reporter.SetOutput(messages)
+	}
+	return worker
+}
@@ -0,0 +1,54 @@
+package img
+
+import "weatherdump/src/geo"
+
+// gcpGrid is the number of ground control points across the width of the images.
+const gcpGrid = 24
+
+// georef ties the pixels of an image to the ground. The swath locates the pixels in
+// the acquisition geometry and the flips follow the orientation of the image since
+// then. Reprojected images are tied by their grid instead.
+type georef struct {
+	swath *geo.Swath
+	grid  *geo.Grid
+	flipX bool
+	flipY bool
+}
+
+func (e *georef) flop() {
+	e.flipX = !e.flipX
+}
+
+func (e *georef) rotate() {
+	e.flipX = !e.flipX
+	e.flipY = !e.flipY
+}
+
+// acquisition returns the position of the pixel of the image in the acquisition geometry.
+func (e georef) acquisition(x, y float64, width, height int) (float64, float64) {
+	if e.flipX {
+		x = float64(width-1) - x
+	}
+	if e.flipY {
+		y = float64(height-1) - y
+	}
+	return x, y
+}
+
+// getGCPs returns the ground control points of the image, or none without the swath.
+func (e georef) getGCPs(width, height int) []geo.GCP {
+	if e.swath == nil {
+		return nil
+	}
+
+	gcps := e.swath.GetGCPs(width / gcpGrid)
+	for i := range gcps {
+		if e.flipX {
+			gcps[i].Pixel = float64(width) - gcps[i].Pixel
+		}
+		if e.flipY {
+			gcps[i].Line = float64(height) - gcps[i].Line
+		}
+	}
+	return gcps
+}
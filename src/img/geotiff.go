@@ -0,0 +1,230 @@
+package img
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"weatherdump/src/geo"
+)
+
+// TIFF tag types.
+const (
+	tiffShort  = 3
+	tiffLong   = 4
+	tiffDouble = 12
+)
+
+// GeoTIFF keys of the geographic WGS-84 model, with the tiepoints at the pixel areas.
+var geographicKeys = []uint16{
+	1, 1, 0, 4,
+	1024, 0, 1, 2, // GTModelTypeGeoKey: geographic.
+	1025, 0, 1, 1, // GTRasterTypeGeoKey: pixel is area.
+	2048, 0, 1, 4326, // GeographicTypeGeoKey: WGS 84.
+	2054, 0, 1, 9102, // GeogAngularUnitsGeoKey: degree.
+}
+
+// GeoTIFF keys of the Web-Mercator model, with the tiepoints at the pixel areas.
+var mercatorKeys = []uint16{
+	1, 1, 0, 4,
+	1024, 0, 1, 1, // GTModelTypeGeoKey: projected.
+	1025, 0, 1, 1, // GTRasterTypeGeoKey: pixel is area.
+	3072, 0, 1, 3857, // ProjectedCSTypeGeoKey: WGS 84 / Pseudo-Mercator.
+	3076, 0, 1, 9001, // ProjLinearUnitsGeoKey: metre.
+}
+
+type tiffEntry struct {
+	tag    uint16
+	kind   uint16
+	values []uint32
+	double []float64
+}
+
+func (e tiffEntry) count() int {
+	if e.kind == tiffDouble {
+		return len(e.double)
+	}
+	return len(e.values)
+}
+
+func (e tiffEntry) size() int {
+	switch e.kind {
+	case tiffShort:
+		return 2 * e.count()
+	case tiffDouble:
+		return 8 * e.count()
+	}
+	return 4 * e.count()
+}
+
+func (e tiffEntry) encode() []byte {
+	buf := make([]byte, e.size())
+	for i, v := range e.values {
+		if e.kind == tiffShort {
+			binary.BigEndian.PutUint16(buf[i*2:], uint16(v))
+		} else {
+			binary.BigEndian.PutUint32(buf[i*4:], v)
+		}
+	}
+	for i, v := range e.double {
+		binary.BigEndian.PutUint64(buf[i*8:], math.Float64bits(v))
+	}
+	return buf
+}
+
+// geoTags returns the GeoTIFF tags of the image. Swaths are saved as tiepoints of the
+// WGS-84 latitude and longitude of the GCPs, grids as the tiepoint of the upper left
+// corner and the pixel scale. Without them the file is a plain TIFF.
+func (e georef) geoTags(width, height int) []tiffEntry {
+	var tiepoints, scale []float64
+	keys := geographicKeys
+
+	if e.grid != nil {
+		x, y := e.grid.GetOrigin()
+		tiepoints = []float64{0, 0, 0, x, y, 0}
+		scale = []float64{e.grid.Resolution, e.grid.Resolution, 0}
+		if e.grid.Projection == geo.WebMercator {
+			keys = mercatorKeys
+		}
+	} else {
+		for _, p := range e.getGCPs(width, height) {
+			tiepoints = append(tiepoints, p.Pixel, p.Line, 0, p.Longitude, p.Latitude, 0)
+		}
+	}
+
+	if len(tiepoints) == 0 {
+		return nil
+	}
+
+	directory := make([]uint32, len(keys))
+	for i, k := range keys {
+		directory[i] = uint32(k)
+	}
+
+	entries := []tiffEntry{
+		{tag: 33922, kind: tiffDouble, double: tiepoints},
+		{tag: 34735, kind: tiffShort, values: directory},
+	}
+	if scale != nil {
+		entries = append(entries, tiffEntry{tag: 33550, kind: tiffDouble, double: scale})
+	}
+	return entries
+}
+
+// saveGeoTIFF writes the GeoTIFF file of the pixels, printing the error instead of
+// stopping the export of the other products.
+func saveGeoTIFF(outputFile string, buf []byte, width, height, samples, bits int, ref georef) {
+	if err := writeGeoTIFF(outputFile, buf, width, height, samples, bits, ref); err != nil {
+		fmt.Printf("[PRC] %s wasn't saved as GeoTIFF: %s.\n", filepath.Base(outputFile), err)
+	}
+}
+
+// writeGeoTIFF saves the pixels as an uncompressed big-endian TIFF, the byte order of
+// the 16-bit buffers, with the GeoTIFF tags of the georeference. A transparent alpha
+// is the extra sample of four samples per pixel.
+func writeGeoTIFF(outputFile string, buf []byte, width, height, samples, bits int, ref georef) error {
+	photometric := uint32(1)
+	if samples >= 3 {
+		photometric = 2
+	}
+
+	bitsPerSample := make([]uint32, samples)
+	for i := range bitsPerSample {
+		bitsPerSample[i] = uint32(bits)
+	}
+
+	// The pixels follow the 8 bytes header.
+	const dataOffset = 8
+	entries := []tiffEntry{
+		{tag: 256, kind: tiffLong, values: []uint32{uint32(width)}},
+		{tag: 257, kind: tiffLong, values: []uint32{uint32(height)}},
+		{tag: 258, kind: tiffShort, values: bitsPerSample},
+		{tag: 259, kind: tiffShort, values: []uint32{1}},
+		{tag: 262, kind: tiffShort, values: []uint32{photometric}},
+		{tag: 273, kind: tiffLong, values: []uint32{dataOffset}},
+		{tag: 277, kind: tiffShort, values: []uint32{uint32(samples)}},
+		{tag: 278, kind: tiffLong, values: []uint32{uint32(height)}},
+		{tag: 279, kind: tiffLong, values: []uint32{uint32(len(buf))}},
+		{tag: 284, kind: tiffShort, values: []uint32{1}},
+	}
+
+	if samples == 4 {
+		entries = append(entries, tiffEntry{tag: 338, kind: tiffShort, values: []uint32{2}})
+	}
+
+	entries = append(entries, ref.geoTags(width, height)...)
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].tag < entries[j].tag
+	})
+
+	// The directory is after the pixels, word aligned, and the values
+	// larger than 4 bytes are after the directory.
+	ifdOffset := dataOffset + len(buf) + len(buf)%2
+	extraOffset := ifdOffset + 2 + len(entries)*12 + 4
+
+	o, err := os.Create(outputFile)
+	if err != nil {
+		return err
+	}
+	defer o.Close()
+
+	w := bufio.NewWriter(o)
+
+	header := []byte{'M', 'M', 0, 42, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(header[4:], uint32(ifdOffset))
+	w.Write(header)
+	w.Write(buf)
+	if len(buf)%2 != 0 {
+		w.WriteByte(0)
+	}
+
+	ifd := make([]byte, 2, 2+len(entries)*12+4)
+	binary.BigEndian.PutUint16(ifd, uint16(len(entries)))
+
+	var extra []byte
+	for _, entry := range entries {
+		field := make([]byte, 12)
+		binary.BigEndian.PutUint16(field[0:], entry.tag)
+		binary.BigEndian.PutUint16(field[2:], entry.kind)
+		binary.BigEndian.PutUint32(field[4:], uint32(entry.count()))
+
+		if value := entry.encode(); len(value) <= 4 {
+			copy(field[8:], value)
+		} else {
+			binary.BigEndian.PutUint32(field[8:], uint32(extraOffset+len(extra)))
+			extra = append(extra, value...)
+		}
+		ifd = append(ifd, field...)
+	}
+
+	ifd = append(ifd, 0, 0, 0, 0)
+	w.Write(ifd)
+	w.Write(extra)
+	return w.Flush()
+}
+
+// isOpaque reports if the alpha of all pixels with the size in bytes is the maximum.
+func isOpaque(buf []byte, size int) bool {
+	for p := size / 4 * 3; p < len(buf); p += size {
+		for i := 0; i < size/4; i++ {
+			if buf[p+i] != 0xFF {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// dropAlpha returns the RGB samples of the RGBA pixels with the size in bytes.
+func dropAlpha(buf []byte, size int) []byte {
+	sample := size / 4
+	res := make([]byte, 0, len(buf)/4*3)
+	for p := 0; p+size <= len(buf); p += size {
+		res = append(res, buf[p:p+sample*3]...)
+	}
+	return res
+}
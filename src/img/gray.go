@@ -6,6 +6,7 @@ import (
 	"image/png"
 	"math"
 	"os"
+	"weatherdump/src/geo"
 
 	"github.com/luigifreitas/gofast"
 )
@@ -14,10 +15,11 @@ type Gray struct {
 	buf    *[]byte
 	width  int
 	height int
+	georef georef
 }
 
 func NewGray(buf *[]byte, width, height int) Img {
-	return &Gray{buf, width, height}
+	return &Gray{buf: buf, width: width, height: height}
 }
 
 func (e *Gray) Flop() Img {
@@ -34,9 +36,39 @@ func (e *Gray) Flop() Img {
 			(*e.buf)[lp] = l
 		}
 	})
+	e.georef.flop()
 	return e
 }
 
+func (e *Gray) Rotate() Img {
+	rotatePixels(*e.buf, 1)
+	e.georef.rotate()
+	return e
+}
+
+// Georeference sets the swath locating the pixels in the acquisition geometry,
+// used by the GeoTIFF export and the reprojection.
+func (e *Gray) Georeference(swath *geo.Swath) Img {
+	e.georef = georef{swath: swath}
+	return e
+}
+
+// Reproject returns the image resampled onto the grid of the reprojection.
+func (e *Gray) Reproject(r Reprojection) (Img, error) {
+	buf, grid, err := reproject(*e.buf, e.width, e.height, 1, 1, e.georef, r)
+	if err != nil {
+		return nil, err
+	}
+
+	w, h := grid.GetDimensions()
+	return &Gray{buf: &buf, width: w, height: h, georef: georef{grid: &grid}}, nil
+}
+
+// Draw draws the layers of the overlay over the georeferenced image.
+func (e *Gray) Draw(o Overlay) (Img, error) {
+	return e, drawOverlay(*e.buf, e.width, e.height, 1, 1, e.georef, o)
+}
+
 func (e *Gray) Equalize() Img {
 	var hist [256]int
 	var nlvl [256]uint8
@@ -107,3 +139,8 @@ func (e *Gray) ExportJPEG(outputFile string, quality int) Img {
 	jpeg.Encode(o, img, &opt)
 	return e
 }
+
+func (e *Gray) ExportGeoTIFF(outputFile string, quality int) Img {
+	saveGeoTIFF(outputFile+".tif", *e.buf, e.width, e.height, 1, 8, e.georef)
+	return e
+}
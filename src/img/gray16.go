@@ -7,6 +7,7 @@ import (
 	"image/png"
 	"math"
 	"os"
+	"weatherdump/src/geo"
 
 	"github.com/luigifreitas/gofast"
 )
@@ -15,10 +16,11 @@ type Gray16 struct {
 	buf    *[]byte
 	width  int
 	height int
+	georef georef
 }
 
 func NewGray16(buf *[]byte, width, height int) Img {
-	return &Gray16{buf, width, height}
+	return &Gray16{buf: buf, width: width, height: height}
 }
 
 func (e *Gray16) Flop() Img {
@@ -41,9 +43,39 @@ func (e *Gray16) Flop() Img {
 			(*e.buf)[lp] = l2
 		}
 	})
+	e.georef.flop()
 	return e
 }
 
+func (e *Gray16) Rotate() Img {
+	rotatePixels(*e.buf, 2)
+	e.georef.rotate()
+	return e
+}
+
+// Georeference sets the swath locating the pixels in the acquisition geometry,
+// used by the GeoTIFF export and the reprojection.
+func (e *Gray16) Georeference(swath *geo.Swath) Img {
+	e.georef = georef{swath: swath}
+	return e
+}
+
+// Reproject returns the image resampled onto the grid of the reprojection.
+func (e *Gray16) Reproject(r Reprojection) (Img, error) {
+	buf, grid, err := reproject(*e.buf, e.width, e.height, 1, 2, e.georef, r)
+	if err != nil {
+		return nil, err
+	}
+
+	w, h := grid.GetDimensions()
+	return &Gray16{buf: &buf, width: w, height: h, georef: georef{grid: &grid}}, nil
+}
+
+// Draw draws the layers of the overlay over the georeferenced image.
+func (e *Gray16) Draw(o Overlay) (Img, error) {
+	return e, drawOverlay(*e.buf, e.width, e.height, 1, 2, e.georef, o)
+}
+
 func (e *Gray16) Equalize() Img {
 	var hist [65536]int
 	var nlvl [65536]uint16
@@ -114,3 +146,8 @@ func (e *Gray16) ExportJPEG(outputFile string, quality int) Img {
 	jpeg.Encode(o, img, &opt)
 	return e
 }
+
+func (e *Gray16) ExportGeoTIFF(outputFile string, quality int) Img {
+	saveGeoTIFF(outputFile+".tif", *e.buf, e.width, e.height, 1, 16, e.georef)
+	return e
+}
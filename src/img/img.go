@@ -1,9 +1,45 @@
 package img
 
+import (
+	"weatherdump/src/geo"
+
+	"github.com/luigifreitas/gofast"
+)
+
 type Img interface {
 	Invert() Img
 	Flop() Img
+	Rotate() Img
+	Georeference(*geo.Swath) Img
+	Reproject(Reprojection) (Img, error)
+	Draw(Overlay) (Img, error)
 	Equalize() Img
 	ExportPNG(string, int) Img
 	ExportJPEG(string, int) Img
+	ExportGeoTIFF(string, int) Img
+}
+
+// flopPixels mirrors each row of pixels with the size in bytes.
+func flopPixels(buf []byte, width, size int) {
+	stride := width * size
+	gofast.For(0, len(buf)/stride, 1, func(i int) {
+		row := buf[i*stride : (i+1)*stride]
+		for p := 0; p < width/2; p++ {
+			swapPixels(row, p, width-p-1, size)
+		}
+	})
+}
+
+// rotatePixels turns the pixels with the size in bytes by 180°, reversing their order.
+func rotatePixels(buf []byte, size int) {
+	n := len(buf) / size
+	gofast.For(0, n/2, 1, func(p int) {
+		swapPixels(buf, p, n-p-1, size)
+	})
+}
+
+func swapPixels(buf []byte, a, b, size int) {
+	for i := 0; i < size; i++ {
+		buf[a*size+i], buf[b*size+i] = buf[b*size+i], buf[a*size+i]
+	}
 }
@@ -0,0 +1,190 @@
+package img
+
+import (
+	"errors"
+	"image/color"
+	"math"
+	"weatherdump/src/geo"
+)
+
+// lookupScale is the size of the cells of the inverse geolocation of the swaths, in
+// nadir pixels. The lines are drawn straight between points this far apart.
+const lookupScale = 4
+
+// maxJump is the length in pixels of the longest segment drawn. Longer ones wrap
+// around the longitudes or jump across the edge of the swath.
+const maxJump = 64
+
+// Overlay of vector layers drawn over the georeferenced images when they're exported.
+type Overlay struct {
+	Layers []OverlayLayer
+}
+
+// OverlayLayer is a set of lines drawn with the color and the width in pixels.
+type OverlayLayer struct {
+	Lines []geo.Polyline
+	Color color.NRGBA
+	Width float64
+}
+
+// locator returns the position of the geodetic coordinates inside the image in pixels,
+// the center of the first pixel is (0, 0).
+type locator func(latitude, longitude float64) (float64, float64, bool)
+
+// newLocator returns the locator of the image and the distance in degrees between the
+// points that keeps the lines straight in the image.
+func (e georef) newLocator(width, height int) (locator, float64, error) {
+	if e.grid != nil {
+		grid := *e.grid
+		return func(latitude, longitude float64) (float64, float64, bool) {
+			x, y := grid.Forward(latitude, longitude)
+			return x - 0.5, y - 0.5, true
+		}, 360 / grid.GetPeriod() * lookupScale, nil
+	}
+
+	if e.swath == nil {
+		return nil, 0, errors.New("the product isn't georeferenced")
+	}
+
+	// The inverse geolocation is the position in the acquisition geometry of the
+	// centers of the cells of an equirectangular grid around the swath.
+	loc := e.swath.GetGeolocation(reprojectionStep)
+	grid := geo.Grid{}.Fit(loc.GetValidPoints(), e.swath.GetResolution()*lookupScale)
+	if !grid.HasBounds() || grid.Resolution <= 0 {
+		return nil, 0, errors.New("the swath doesn't intersect the Earth")
+	}
+
+	w, h := grid.GetDimensions()
+	if w*h > maxReprojectionPixels {
+		return nil, 0, errors.New("the swath is too large")
+	}
+
+	sx := make([]float32, w*h)
+	sy := make([]float32, w*h)
+	for i := range sx {
+		sx[i] = float32(math.NaN())
+	}
+
+	rasterize(loc, grid, func(x, y int, ax, ay float64) {
+		sx[y*w+x], sy[y*w+x] = float32(ax), float32(ay)
+	})
+
+	valid := func(x, y int) bool {
+		return x >= 0 && y >= 0 && x < w && y < h && !math.IsNaN(float64(sx[y*w+x]))
+	}
+
+	return func(latitude, longitude float64) (float64, float64, bool) {
+		gx, gy := grid.Forward(latitude, longitude)
+		fx, fy := gx-0.5, gy-0.5
+		x0, y0 := int(math.Floor(fx)), int(math.Floor(fy))
+
+		var ax, ay float64
+		if valid(x0, y0) && valid(x0+1, y0) && valid(x0, y0+1) && valid(x0+1, y0+1) {
+			dx, dy := fx-float64(x0), fy-float64(y0)
+			at := func(v []float32) float64 {
+				top := float64(v[y0*w+x0])*(1-dx) + float64(v[y0*w+x0+1])*dx
+				bottom := float64(v[(y0+1)*w+x0])*(1-dx) + float64(v[(y0+1)*w+x0+1])*dx
+				return top*(1-dy) + bottom*dy
+			}
+			ax, ay = at(sx), at(sy)
+		} else if x, y := int(math.Floor(fx+0.5)), int(math.Floor(fy+0.5)); valid(x, y) {
+			ax, ay = float64(sx[y*w+x]), float64(sy[y*w+x])
+		} else {
+			return 0, 0, false
+		}
+
+		ax, ay = e.acquisition(ax, ay, width, height)
+		return ax, ay, true
+	}, grid.Resolution, nil
+}
+
+// drawOverlay draws the layers of the overlay over the image with the channels of depth
+// bytes. Gray images take the luminance of the colors. RGBA images are premultiplied and
+// the lines are also drawn over their transparent pixels.
+func drawOverlay(buf []byte, width, height, channels, depth int, ref georef, o Overlay) error {
+	locate, step, err := ref.newLocator(width, height)
+	if err != nil {
+		return err
+	}
+
+	mask := make([]bool, width*height)
+	for _, layer := range o.Layers {
+		for i := range mask {
+			mask[i] = false
+		}
+
+		for _, line := range layer.Lines {
+			drawLine(mask, width, height, line.Densify(step), locate, layer.Width)
+		}
+
+		blend(buf, channels, depth, mask, layer.Color)
+	}
+	return nil
+}
+
+// drawLine marks the pixels of the mask covered by the line with the width.
+func drawLine(mask []bool, width, height int, line geo.Polyline, locate locator, lineWidth float64) {
+	r := math.Max(lineWidth/2, 0.5)
+
+	var px, py float64
+	var prev bool
+	for _, p := range line {
+		x, y, ok := locate(p.Latitude, p.Longitude)
+		if ok && prev && math.Hypot(x-px, y-py) <= maxJump {
+			drawSegment(mask, width, height, px, py, x, y, r)
+		}
+		px, py, prev = x, y, ok
+	}
+}
+
+// drawSegment marks the pixels of the mask within the radius of the segment.
+func drawSegment(mask []bool, width, height int, x0, y0, x1, y1, r float64) {
+	if math.Max(x0, x1) < -r || math.Min(x0, x1) > float64(width-1)+r ||
+		math.Max(y0, y1) < -r || math.Min(y0, y1) > float64(height-1)+r {
+		return
+	}
+
+	n := int(math.Ceil(math.Hypot(x1-x0, y1-y0)*2)) + 1
+	for i := 0; i <= n; i++ {
+		f := float64(i) / float64(n)
+		cx, cy := x0+(x1-x0)*f, y0+(y1-y0)*f
+
+		// The nearest pixel is always marked, so thin lines don't have gaps.
+		if x, y := int(math.Floor(cx+0.5)), int(math.Floor(cy+0.5)); x >= 0 && y >= 0 && x < width && y < height {
+			mask[y*width+x] = true
+		}
+
+		for y := int(math.Ceil(cy - r)); y <= int(math.Floor(cy+r)); y++ {
+			for x := int(math.Ceil(cx - r)); x <= int(math.Floor(cx+r)); x++ {
+				if x >= 0 && y >= 0 && x < width && y < height && (float64(x)-cx)*(float64(x)-cx)+(float64(y)-cy)*(float64(y)-cy) <= r*r {
+					mask[y*width+x] = true
+				}
+			}
+		}
+	}
+}
+
+// blend draws the color over the masked pixels.
+func blend(buf []byte, channels, depth int, mask []bool, c color.NRGBA) {
+	size := channels * depth
+	max := math.Pow(2, float64(8*depth)) - 1
+	a := float64(c.A) / 255
+
+	var values []float64
+	if channels == 1 {
+		values = []float64{(0.299*float64(c.R) + 0.587*float64(c.G) + 0.114*float64(c.B)) / 255 * a}
+	} else {
+		values = []float64{float64(c.R) / 255 * a, float64(c.G) / 255 * a, float64(c.B) / 255 * a, a}
+	}
+
+	for p, marked := range mask {
+		if !marked || (p+1)*size > len(buf) {
+			continue
+		}
+
+		for i, v := range values {
+			s := buf[p*size+i*depth:]
+			writeSample(s, depth, v*max+readSample(s, depth)*(1-a))
+		}
+	}
+}
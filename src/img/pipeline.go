@@ -1,18 +1,31 @@
 package img
 
 import (
+	"fmt"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"strings"
 )
 
 type Pipeline struct {
-	taskCount  int
-	target     *Img
-	exceptions map[string]int
-	currTasks  map[string]int
+	taskCount    int
+	target       *Img
+	exceptions   map[string]int
+	currTasks    map[string]int
+	reprojection *Reprojection
+	overlay      *Overlay
 }
 
+// Tasks of the pipeline. The image tasks are methods of the images called by
+// Process, the export tasks are called by Export and the processor tasks are
+// read by the processors with Has.
+var (
+	imageTasks     = map[string]bool{"Invert": true, "Flop": true, "Rotate": true, "Equalize": true}
+	exportTasks    = map[string]bool{"ExportPNG": true, "ExportJPEG": true, "ExportGeoTIFF": true}
+	processorTasks = map[string]bool{"FillBowTie": true, "CorrectBowTie": true, "Calibrate": true, "AutoRotate": true}
+)
+
 func NewPipeline() Pipeline {
 	return Pipeline{
 		currTasks:  make(map[string]int),
@@ -30,11 +43,36 @@ func (e *Pipeline) ResetExceptions() {
 	e.exceptions = make(map[string]int)
 }
 
-func (e *Pipeline) AddPipe(method string, enabled bool) {
+// AddPipe enables the task, unknown task names are rejected even if disabled.
+func (e *Pipeline) AddPipe(method string, enabled bool) error {
+	if !imageTasks[method] && !exportTasks[method] && !processorTasks[method] {
+		return fmt.Errorf("unknown pipeline task %q", method)
+	}
+
 	if enabled {
 		e.currTasks[method] = e.taskCount
 		e.taskCount++
 	}
+	return nil
+}
+
+// Has reports if the task is enabled and not disabled by an exception.
+// Tasks that aren't image methods are read by the processors with it.
+func (e Pipeline) Has(method string) bool {
+	_, ok := e.currTasks[method]
+	return ok && e.exceptions[method] == 0
+}
+
+// SetReprojection exports the georeferenced images also reprojected onto the grid,
+// with the name of the projection appended to their names.
+func (e *Pipeline) SetReprojection(r *Reprojection) {
+	e.reprojection = r
+}
+
+// SetOverlay draws the layers of the overlay over the georeferenced images before
+// they're exported.
+func (e *Pipeline) SetOverlay(o *Overlay) {
+	e.overlay = o
 }
 
 func (e *Pipeline) Target(img Img) *Pipeline {
@@ -44,7 +82,7 @@ func (e *Pipeline) Target(img Img) *Pipeline {
 
 func (e *Pipeline) Process() *Pipeline {
 	for _, task := range getKeys(e.currTasks) {
-		if !strings.Contains(task, "Export") && e.exceptions[task] == 0 {
+		if imageTasks[task] && e.exceptions[task] == 0 {
 			reflect.ValueOf(*e.target).MethodByName(task).Call([]reflect.Value{})
 		}
 	}
@@ -52,17 +90,59 @@ func (e *Pipeline) Process() *Pipeline {
 }
 
 func (e *Pipeline) Export(args ...interface{}) *Pipeline {
+	var outputName string
+	if len(args) > 0 {
+		outputName, _ = args[0].(string)
+	}
+
+	// The reprojection resamples the image before the overlay is drawn.
+	var res Img
+	var err error
+	if e.reprojection != nil && outputName != "" {
+		res, err = (*e.target).Reproject(*e.reprojection)
+	}
+
+	e.draw(*e.target, outputName)
+	e.export(*e.target, args)
+
+	if e.reprojection == nil || outputName == "" {
+		return e
+	}
+
+	if err != nil {
+		fmt.Printf("[PRC] %s wasn't reprojected: %s.\n", filepath.Base(outputName), err)
+		return e
+	}
+
+	inputs := append([]interface{}{}, args...)
+	inputs[0] = fmt.Sprintf("%s_%s", outputName, strings.ToUpper(e.reprojection.Grid.Projection.String()))
+	e.draw(res, inputs[0].(string))
+	e.export(res, inputs)
+	return e
+}
+
+// draw draws the overlay over the image.
+func (e *Pipeline) draw(target Img, outputName string) {
+	if e.overlay == nil {
+		return
+	}
+
+	if _, err := target.Draw(*e.overlay); err != nil {
+		fmt.Printf("[PRC] %s wasn't overlaid: %s.\n", filepath.Base(outputName), err)
+	}
+}
+
+func (e *Pipeline) export(target Img, args []interface{}) {
 	inputs := make([]reflect.Value, len(args))
 	for i := range args {
 		inputs[i] = reflect.ValueOf(args[i])
 	}
 
 	for _, task := range getKeys(e.currTasks) {
-		if strings.Contains(task, "Export") && e.exceptions[task] == 0 {
-			reflect.ValueOf(*e.target).MethodByName(task).Call(inputs)
+		if exportTasks[task] && e.exceptions[task] == 0 {
+			reflect.ValueOf(target).MethodByName(task).Call(inputs)
 		}
 	}
-	return e
 }
 
 func getKeys(tasks map[string]int) []string {
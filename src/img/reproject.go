@@ -0,0 +1,170 @@
+package img
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"weatherdump/src/geo"
+)
+
+// reprojectionStep is the distance in pixels between the located pixels of the swath,
+// the positions between them are interpolated.
+const reprojectionStep = 8
+
+// maxReprojectionPixels limits the size of the reprojected images.
+const maxReprojectionPixels = 1 << 28
+
+// Reprojection of the georeferenced images onto a grid. A grid without the bounding box
+// or the resolution takes the ones of each swath, at its nadir resolution.
+type Reprojection struct {
+	Grid     geo.Grid
+	Bilinear bool
+}
+
+// reproject resamples the pixels of the image with the channels of depth bytes onto the
+// grid of the reprojection. Pixels outside the swath are zero, transparent for the RGBA
+// images.
+func reproject(buf []byte, width, height, channels, depth int, ref georef, r Reprojection) ([]byte, geo.Grid, error) {
+	if ref.swath == nil {
+		return nil, r.Grid, errors.New("the product isn't georeferenced")
+	}
+
+	loc := ref.swath.GetGeolocation(reprojectionStep)
+	grid := r.Grid.Fit(loc.GetValidPoints(), ref.swath.GetResolution())
+	if !grid.HasBounds() || grid.Resolution <= 0 {
+		return nil, grid, errors.New("the swath doesn't intersect the Earth")
+	}
+
+	w, h := grid.GetDimensions()
+	if w*h > maxReprojectionPixels {
+		return nil, grid, errors.New("the grid is too large, set a smaller bounding box or a coarser resolution")
+	}
+
+	size := channels * depth
+	out := make([]byte, w*h*size)
+	rasterize(loc, grid, func(x, y int, sx, sy float64) {
+		ix, iy := ref.acquisition(sx, sy, width, height)
+		sample(buf, width, height, channels, depth, ix, iy, r.Bilinear, out[(y*w+x)*size:])
+	})
+
+	return out, grid, nil
+}
+
+// rasterize splits the grid into triangles between the located pixels of the swath and
+// calls the handler with each pixel of the grid inside them and the position it
+// interpolates in the acquisition geometry.
+func rasterize(loc geo.Geolocation, grid geo.Grid, handler func(x, y int, sx, sy float64)) {
+	w, h := grid.GetDimensions()
+	gx := make([]float64, len(loc.Points))
+	gy := make([]float64, len(loc.Points))
+	for i, p := range loc.Points {
+		gx[i], gy[i] = grid.Forward(p.Latitude, p.Longitude)
+	}
+
+	period := grid.GetPeriod()
+	cols := len(loc.Columns)
+
+	triangle := func(a, b, c int) {
+		if !loc.Valid[a] || !loc.Valid[b] || !loc.Valid[c] {
+			return
+		}
+
+		// Triangles crossing the edge of the longitudes wrap around the grid.
+		minX := math.Min(gx[a], math.Min(gx[b], gx[c]))
+		maxX := math.Max(gx[a], math.Max(gx[b], gx[c]))
+		minY := math.Min(gy[a], math.Min(gy[b], gy[c]))
+		maxY := math.Max(gy[a], math.Max(gy[b], gy[c]))
+		if maxX-minX > period/2 {
+			return
+		}
+
+		det := (gy[b]-gy[c])*(gx[a]-gx[c]) + (gx[c]-gx[b])*(gy[a]-gy[c])
+		if det == 0 {
+			return
+		}
+
+		sx := [3]float64{float64(loc.Columns[a%cols]), float64(loc.Columns[b%cols]), float64(loc.Columns[c%cols])}
+		sy := [3]float64{float64(loc.Lines[a/cols]), float64(loc.Lines[b/cols]), float64(loc.Lines[c/cols])}
+
+		for y := int(math.Max(0, math.Floor(minY))); y < h && float64(y) <= maxY; y++ {
+			for x := int(math.Max(0, math.Floor(minX))); x < w && float64(x) <= maxX; x++ {
+				px, py := float64(x)+0.5, float64(y)+0.5
+				l0 := ((gy[b]-gy[c])*(px-gx[c]) + (gx[c]-gx[b])*(py-gy[c])) / det
+				l1 := ((gy[c]-gy[a])*(px-gx[c]) + (gx[a]-gx[c])*(py-gy[c])) / det
+				l2 := 1 - l0 - l1
+				if l0 < -1e-9 || l1 < -1e-9 || l2 < -1e-9 {
+					continue
+				}
+
+				handler(x, y, l0*sx[0]+l1*sx[1]+l2*sx[2], l0*sy[0]+l1*sy[1]+l2*sy[2])
+			}
+		}
+	}
+
+	for j := 0; j+1 < len(loc.Lines); j++ {
+		for i := 0; i+1 < cols; i++ {
+			p := j*cols + i
+			triangle(p, p+1, p+cols+1)
+			triangle(p, p+cols+1, p+cols)
+		}
+	}
+}
+
+// sample writes the pixel at the position of the image into the destination, from the
+// nearest pixel or interpolated between the four around it. Samples of 2 bytes are
+// big-endian.
+func sample(buf []byte, width, height, channels, depth int, x, y float64, bilinear bool, dst []byte) {
+	size := channels * depth
+	if len(buf) < width*height*size {
+		return
+	}
+
+	if !bilinear {
+		ix := clampInt(int(math.Floor(x+0.5)), 0, width-1)
+		iy := clampInt(int(math.Floor(y+0.5)), 0, height-1)
+		copy(dst[:size], buf[(iy*width+ix)*size:])
+		return
+	}
+
+	x = math.Max(0, math.Min(float64(width-1), x))
+	y = math.Max(0, math.Min(float64(height-1), y))
+	x0, y0 := int(x), int(y)
+	x1, y1 := clampInt(x0+1, 0, width-1), clampInt(y0+1, 0, height-1)
+	fx, fy := x-float64(x0), y-float64(y0)
+
+	for c := 0; c < channels; c++ {
+		at := func(px, py int) float64 {
+			return readSample(buf[(py*width+px)*size+c*depth:], depth)
+		}
+
+		top := at(x0, y0)*(1-fx) + at(x1, y0)*fx
+		bottom := at(x0, y1)*(1-fx) + at(x1, y1)*fx
+		writeSample(dst[c*depth:], depth, top*(1-fy)+bottom*fy)
+	}
+}
+
+func readSample(buf []byte, depth int) float64 {
+	if depth == 2 {
+		return float64(binary.BigEndian.Uint16(buf))
+	}
+	return float64(buf[0])
+}
+
+func writeSample(buf []byte, depth int, v float64) {
+	v = math.Floor(v + 0.5)
+	if depth == 2 {
+		binary.BigEndian.PutUint16(buf, uint16(v))
+		return
+	}
+	buf[0] = byte(v)
+}
+
+func clampInt(v, low, high int) int {
+	if v < low {
+		return low
+	}
+	if v > high {
+		return high
+	}
+	return v
+}
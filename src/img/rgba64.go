@@ -5,22 +5,55 @@ import (
 	"image/jpeg"
 	"image/png"
 	"os"
+	"weatherdump/src/geo"
 )
 
 type RGBA64 struct {
 	buf    *[]byte
 	width  int
 	height int
+	georef georef
 }
 
 func NewRGBA64(buf *[]byte, width, height int) Img {
-	return &RGBA64{buf, width, height}
+	return &RGBA64{buf: buf, width: width, height: height}
 }
 
 func (e *RGBA64) Flop() Img {
+	flopPixels(*e.buf, e.width, 8)
+	e.georef.flop()
 	return e
 }
 
+func (e *RGBA64) Rotate() Img {
+	rotatePixels(*e.buf, 8)
+	e.georef.rotate()
+	return e
+}
+
+// Georeference sets the swath locating the pixels in the acquisition geometry,
+// used by the GeoTIFF export and the reprojection.
+func (e *RGBA64) Georeference(swath *geo.Swath) Img {
+	e.georef = georef{swath: swath}
+	return e
+}
+
+// Reproject returns the image resampled onto the grid of the reprojection.
+func (e *RGBA64) Reproject(r Reprojection) (Img, error) {
+	buf, grid, err := reproject(*e.buf, e.width, e.height, 4, 2, e.georef, r)
+	if err != nil {
+		return nil, err
+	}
+
+	w, h := grid.GetDimensions()
+	return &RGBA64{buf: &buf, width: w, height: h, georef: georef{grid: &grid}}, nil
+}
+
+// Draw draws the layers of the overlay over the georeferenced image.
+func (e *RGBA64) Draw(o Overlay) (Img, error) {
+	return e, drawOverlay(*e.buf, e.width, e.height, 4, 2, e.georef, o)
+}
+
 func (e *RGBA64) Equalize() Img {
 	return e
 }
@@ -55,3 +88,13 @@ func (e *RGBA64) ExportJPEG(outputFile string, quality int) Img {
 	jpeg.Encode(o, img, &opt)
 	return e
 }
+
+func (e *RGBA64) ExportGeoTIFF(outputFile string, quality int) Img {
+	if isOpaque(*e.buf, 8) {
+		saveGeoTIFF(outputFile+".tif", dropAlpha(*e.buf, 8), e.width, e.height, 3, 16, e.georef)
+		return e
+	}
+
+	saveGeoTIFF(outputFile+".tif", *e.buf, e.width, e.height, 4, 16, e.georef)
+	return e
+}
@@ -1,5 +1,11 @@
 package helpers
 
+import (
+	"fmt"
+	"io"
+	"weatherdump/src/ccsds"
+)
+
 var (
 	averageLastNSamples = 8192
 )
@@ -23,9 +29,12 @@ type Statistics struct {
 	SyncWord                  [4]uint8
 	FrameLock                 bool
 	Finished                  bool
+	Live                      bool
 	TaskName                  string
 	Constellation             []byte
 	SocketConnection
+
+	counters [256]ccsds.FrameCounter
 }
 
 // Update the client with the current data.
@@ -33,9 +42,31 @@ func (e *Statistics) Update() {
 	e.SocketConnection.SendJSON(e)
 }
 
+// CountFrame registers a received frame of the virtual channel and
+// the frames lost since the previous one.
+func (e *Statistics) CountFrame(vcid uint8, counter uint32) {
+	lost, _ := e.counters[vcid].Next(counter)
+	e.LostPackets += uint64(lost)
+	e.LostPacketsPerChannel[vcid] += int64(lost)
+	e.ReceivedPacketsPerChannel[vcid]++
+}
+
+// PrintSummary of the received and lost frames of each virtual channel into the writer.
+func (e Statistics) PrintSummary(w io.Writer) {
+	for vcid, received := range e.ReceivedPacketsPerChannel {
+		if received > 0 {
+			fmt.Fprintf(w, "[DEC] VCID %2d: %d frames received, %d frames lost.\n", vcid, received, e.LostPacketsPerChannel[vcid])
+		}
+	}
+}
+
 // Finish decoding process.
 func (e *Statistics) Finish() {
 	e.Finished = true
-	e.TotalBytesRead = e.TotalBytes
+	if e.Live {
+		e.TotalBytes = e.TotalBytesRead
+	} else {
+		e.TotalBytesRead = e.TotalBytes
+	}
 	e.Update()
 }
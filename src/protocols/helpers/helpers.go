@@ -2,10 +2,15 @@ package helpers
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
+	"weatherdump/src/ccsds"
 )
 
 func ShiftWithConstantSize(arr *[]byte, pos int, length int) {
@@ -68,3 +73,110 @@ func CaptureOutput(f func()) string {
 	writer.Close()
 	return <-out
 }
+
+// ParseAPIDs parses a comma-separated list of APIDs and ranges like "11,800-823".
+// The word "all" selects every APID except the idle packets.
+func ParseAPIDs(list string) ([]uint16, error) {
+	var apids []uint16
+	if strings.TrimSpace(list) == "" {
+		return apids, nil
+	}
+
+	if strings.TrimSpace(list) == "all" {
+		for apid := uint16(0); apid < ccsds.IdleAPID; apid++ {
+			apids = append(apids, apid)
+		}
+		return apids, nil
+	}
+
+	for _, item := range strings.Split(list, ",") {
+		bounds := strings.SplitN(strings.TrimSpace(item), "-", 2)
+		first, err := strconv.ParseUint(bounds[0], 10, 11)
+		if err != nil {
+			return nil, fmt.Errorf("invalid APID %q", item)
+		}
+
+		last := first
+		if len(bounds) == 2 {
+			if last, err = strconv.ParseUint(bounds[1], 10, 11); err != nil || last < first {
+				return nil, fmt.Errorf("invalid APID range %q", item)
+			}
+		}
+
+		for apid := first; apid <= last; apid++ {
+			apids = append(apids, uint16(apid))
+		}
+	}
+
+	return apids, nil
+}
+
+// ParseRange parses a range of numbers like "650-700". An empty string returns nil.
+func ParseRange(value string) ([]float64, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	bounds := strings.SplitN(strings.TrimSpace(value), "-", 2)
+	if len(bounds) != 2 {
+		return nil, fmt.Errorf("invalid range %q", value)
+	}
+
+	low, err := strconv.ParseFloat(bounds[0], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid range %q", value)
+	}
+
+	high, err := strconv.ParseFloat(bounds[1], 64)
+	if err != nil || high < low {
+		return nil, fmt.Errorf("invalid range %q", value)
+	}
+
+	return []float64{low, high}, nil
+}
+
+// ParseDate parses a UTC date like "2020-01-31". An empty string returns the zero time.
+func ParseDate(value string) (time.Time, error) {
+	if strings.TrimSpace(value) == "" {
+		return time.Time{}, nil
+	}
+
+	date, err := time.Parse("2006-01-02", strings.TrimSpace(value))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	return date, nil
+}
+
+// ParseBoundingBox parses a bounding box in degrees like "-10,35,30,60" ordered as west,
+// south, east and north. An east longitude smaller than the west one crosses the
+// antimeridian. An empty string returns nil.
+func ParseBoundingBox(value string) ([]float64, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	items := strings.Split(value, ",")
+	if len(items) != 4 {
+		return nil, fmt.Errorf("invalid bounding box %q", value)
+	}
+
+	bbox := make([]float64, 4)
+	for i, item := range items {
+		v, err := strconv.ParseFloat(strings.TrimSpace(item), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bounding box %q", value)
+		}
+		bbox[i] = v
+	}
+
+	if bbox[2] < bbox[0] {
+		bbox[2] += 360
+	}
+
+	if bbox[0] < -180 || bbox[0] > 180 || bbox[3] <= bbox[1] || bbox[1] < -90 || bbox[3] > 90 || bbox[2] == bbox[0] {
+		return nil, fmt.Errorf("invalid bounding box %q", value)
+	}
+
+	return bbox, nil
+}
@@ -0,0 +1,106 @@
+package helpers
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"os"
+	"strings"
+)
+
+const maxDatagramSize = 65536
+
+var liveSchemes = []string{"tcp://", "udp://"}
+
+// IsLiveInput checks if the input path is a network socket address
+// (tcp://host:port or udp://host:port) instead of a regular file.
+func IsLiveInput(path string) bool {
+	for _, scheme := range liveSchemes {
+		if strings.HasPrefix(path, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenInput returns a reader for a regular file or a live socket.
+// TCP addresses are dialed (e.g. GNU Radio TCP Sink in server mode) and UDP
+// addresses are listened on for incoming datagrams. The returned size is zero
+// for live inputs since their length isn't known beforehand.
+func OpenInput(path string) (io.ReadCloser, int64, error) {
+	switch {
+	case strings.HasPrefix(path, "tcp://"):
+		conn, err := net.Dial("tcp", strings.TrimPrefix(path, "tcp://"))
+		if err != nil {
+			return nil, 0, err
+		}
+		return conn, 0, nil
+	case strings.HasPrefix(path, "udp://"):
+		addr, err := net.ResolveUDPAddr("udp", strings.TrimPrefix(path, "udp://"))
+		if err != nil {
+			return nil, 0, err
+		}
+		conn, err := net.ListenUDP("udp", addr)
+		if err != nil {
+			return nil, 0, err
+		}
+		// A datagram is discarded if the read buffer is smaller than it.
+		return &datagramReader{bufio.NewReaderSize(conn, maxDatagramSize), conn}, 0, nil
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	return file, fi.Size(), nil
+}
+
+// CloseOnCancel closes the input once the cancel signal is received, so a read blocked
+// on a live socket returns instead of waiting for the next data. The returned function
+// stops watching the signal and reports whether the input was closed by it.
+func CloseOnCancel(signal chan bool, input io.Closer) func() bool {
+	done := make(chan struct{})
+	canceled := make(chan bool, 1)
+
+	go func() {
+		select {
+		case <-signal:
+			input.Close()
+			canceled <- true
+		case <-done:
+			canceled <- false
+		}
+	}()
+
+	return func() bool {
+		close(done)
+		return <-canceled
+	}
+}
+
+type datagramReader struct {
+	*bufio.Reader
+	conn *net.UDPConn
+}
+
+func (e *datagramReader) Close() error {
+	return e.conn.Close()
+}
+
+// CountingReader keeps track of the raw bytes read from the input before any
+// demodulation or decoding step takes place. It's used to report the progress.
+type CountingReader struct {
+	io.Reader
+	Count uint64
+}
+
+func (e *CountingReader) Read(p []byte) (int, error) {
+	n, err := e.Reader.Read(p)
+	e.Count += uint64(n)
+	return n, err
+}
@@ -0,0 +1,54 @@
+package helpers
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+func TestCloseOnCancel(t *testing.T) {
+	input, _, err := OpenInput("udp://127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	signal := make(chan bool)
+	stop := CloseOnCancel(signal, input)
+
+	read := make(chan error)
+	go func() {
+		_, err := io.ReadFull(input, make([]byte, 1024))
+		read <- err
+	}()
+
+	select {
+	case signal <- true:
+	case <-time.After(time.Second):
+		t.Fatal("cancel not received")
+	}
+
+	select {
+	case err := <-read:
+		if err == nil {
+			t.Error("read of the canceled input succeeded")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("read still blocked after the cancel")
+	}
+
+	if !stop() {
+		t.Error("cancel not reported")
+	}
+}
+
+func TestCloseOnCancelStop(t *testing.T) {
+	input, _, err := OpenInput("udp://127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer input.Close()
+
+	if CloseOnCancel(make(chan bool), input)() {
+		t.Error("cancel reported without a signal")
+	}
+}
@@ -0,0 +1,115 @@
+package helpers
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+	"weatherdump/src/geo"
+	"weatherdump/src/img"
+
+	"github.com/fatih/color"
+)
+
+// maxTLEAge in days between the pass and the TLE epoch before the propagation
+// errors grow past a few kilometers.
+const maxTLEAge = 14
+
+// LoadOrbit returns the propagator of the catalog number inside the TLE file and
+// prints the direction of the pass. Errors are printed and return nil, so the
+// products are still exported without the orbit.
+func LoadOrbit(path string, catalog int, passTime time.Time) *geo.Satellite {
+	tle, err := geo.ReadTLE(path, catalog)
+	if err != nil {
+		fmt.Printf("[PRC] The orbit won't be propagated: %s.\n", err)
+		return nil
+	}
+
+	sat, err := geo.NewSatellite(tle)
+	if err != nil {
+		fmt.Printf("[PRC] The orbit won't be propagated: %s.\n", err)
+		return nil
+	}
+
+	sv, err := sat.Propagate(passTime)
+	if err != nil {
+		fmt.Printf("[PRC] The orbit won't be propagated: %s.\n", err)
+		return nil
+	}
+
+	name := sat.Name
+	if name == "" {
+		name = fmt.Sprintf("NORAD %d", sat.Catalog)
+	}
+
+	age := passTime.Sub(sat.Epoch).Hours() / 24
+	fmt.Printf("[PRC] Propagating the orbit of %s from a %.1f days old TLE, %s pass.\n", name, age, sv.GetDirection())
+	if math.Abs(age) > maxTLEAge {
+		color.Yellow("[PRC] WARNING! The pass is %.0f days away from the TLE epoch, check the date of the pass or use a recent TLE.", math.Abs(age))
+	}
+	return sat
+}
+
+// SaveTrack saves the sub-satellite point of each line of a product as a CSV file.
+func SaveTrack(sat *geo.Satellite, start time.Time, interval time.Duration, lines int, path string) {
+	track, err := geo.NewTrack(sat, start, interval, lines)
+	if err != nil {
+		fmt.Printf("[PRC] The track of %s wasn't saved: %s.\n", path, err)
+		return
+	}
+
+	if err := track.SaveCSV(path); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Orient rotates the products of northbound passes by 180°, so they come out
+// north-up, unless the automatic orientation is disabled in the pipeline.
+func Orient(wf *img.Pipeline, direction geo.Direction) {
+	if !wf.Has("AutoRotate") || direction != geo.Ascending {
+		return
+	}
+
+	fmt.Println("[PRC] Rotating the products of the northbound pass.")
+	wf.AddPipe("Rotate", true)
+}
+
+// ParseReprojection returns the reprojection of the georeferenced products with the name of
+// the projection, the bounding box, the resolution in the units of the projection (zero
+// matches the nadir resolution) and the resampling, nearest or bilinear. An empty
+// projection returns nil.
+func ParseReprojection(projection, bbox string, resolution float64, resampling string) (*img.Reprojection, error) {
+	if strings.TrimSpace(projection) == "" {
+		return nil, nil
+	}
+
+	p, err := geo.ParseProjection(projection)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds, err := ParseBoundingBox(bbox)
+	if err != nil {
+		return nil, err
+	}
+
+	if resolution < 0 {
+		return nil, fmt.Errorf("invalid resolution %g", resolution)
+	}
+
+	r := &img.Reprojection{Grid: geo.Grid{Projection: p, Resolution: resolution}}
+	if bounds != nil {
+		r.Grid.West, r.Grid.South, r.Grid.East, r.Grid.North = bounds[0], bounds[1], bounds[2], bounds[3]
+	}
+
+	switch strings.ToLower(strings.TrimSpace(resampling)) {
+	case "", "nearest":
+	case "bilinear":
+		r.Bilinear = true
+	default:
+		return nil, fmt.Errorf("unknown resampling %q", resampling)
+	}
+
+	return r, nil
+}
@@ -0,0 +1,106 @@
+package helpers
+
+import (
+	"encoding/hex"
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+	"weatherdump/src/geo"
+	"weatherdump/src/img"
+)
+
+// Default styles of the overlay layers.
+const (
+	CoastlinesStyle = "#FFFF00:1"
+	BordersStyle    = "#FF8000:1"
+	GraticuleStyle  = "#FFFFFF80:1"
+)
+
+// OverlayOptions are the vector files of the overlay, the step in degrees of the
+// graticule and the styles of the layers. Empty styles use the default ones.
+type OverlayOptions struct {
+	Coastlines      string
+	CoastlinesStyle string
+	Borders         string
+	BordersStyle    string
+	Graticule       float64
+	GraticuleStyle  string
+}
+
+// LoadOverlay reads the vector files of the overlay. Empty files and a zero graticule
+// step skip their layer, without layers it returns nil.
+func LoadOverlay(opts OverlayOptions) (*img.Overlay, error) {
+	var o img.Overlay
+
+	add := func(lines []geo.Polyline, style, fallback string) error {
+		if style == "" {
+			style = fallback
+		}
+
+		c, width, err := ParseStyle(style)
+		if err != nil {
+			return err
+		}
+
+		o.Layers = append(o.Layers, img.OverlayLayer{Lines: lines, Color: c, Width: width})
+		return nil
+	}
+
+	if opts.Graticule < 0 {
+		return nil, fmt.Errorf("invalid graticule step %g", opts.Graticule)
+	}
+
+	if opts.Graticule > 0 {
+		if err := add(geo.Graticule(opts.Graticule), opts.GraticuleStyle, GraticuleStyle); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, layer := range []struct{ path, style, fallback string }{
+		{opts.Borders, opts.BordersStyle, BordersStyle},
+		{opts.Coastlines, opts.CoastlinesStyle, CoastlinesStyle},
+	} {
+		if strings.TrimSpace(layer.path) == "" {
+			continue
+		}
+
+		lines, err := geo.ReadVector(layer.path)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := add(lines, layer.style, layer.fallback); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(o.Layers) == 0 {
+		return nil, nil
+	}
+	return &o, nil
+}
+
+// ParseStyle parses a line style like "#FFFF00:1.5", the color as #RRGGBB or #RRGGBBAA
+// and the width in pixels.
+func ParseStyle(value string) (color.NRGBA, float64, error) {
+	parts := strings.SplitN(strings.TrimSpace(value), ":", 2)
+	code := strings.TrimPrefix(parts[0], "#")
+
+	rgba, err := hex.DecodeString(code)
+	if err != nil || (len(rgba) != 3 && len(rgba) != 4) {
+		return color.NRGBA{}, 0, fmt.Errorf("invalid style color %q", value)
+	}
+	if len(rgba) == 3 {
+		rgba = append(rgba, 0xFF)
+	}
+
+	width := 1.0
+	if len(parts) == 2 {
+		if width, err = strconv.ParseFloat(parts[1], 64); err != nil || width <= 0 {
+			return color.NRGBA{}, 0, fmt.Errorf("invalid style width %q", value)
+		}
+	}
+
+	return color.NRGBA{R: rgba[0], G: rgba[1], B: rgba[2], A: rgba[3]}, width, nil
+}
@@ -0,0 +1,80 @@
+package helpers
+
+import (
+	"io"
+	"os"
+	"weatherdump/src/ccsds/coding"
+)
+
+const (
+	// ProbeSize is the number of bytes read from the head of the input by the probes.
+	ProbeSize = 8 << 20
+	// probeAcquireFrames the sync word should be found in the first frames.
+	probeAcquireFrames = 16
+	// probeTolerance is the drift in symbols allowed between consecutive sync words.
+	probeTolerance = 8
+	// probeMinFrames inputs with less frames have their confidence reduced.
+	probeMinFrames = 16
+)
+
+type headReader struct {
+	io.Reader
+	io.Closer
+}
+
+// OpenHead opens the file limiting the reads to the first ProbeSize bytes.
+func OpenHead(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return headReader{io.LimitReader(file, ProbeSize), file}, nil
+}
+
+// TrackSyncWords looks for the sync words of the correlator at each frame of the
+// soft-symbols. After the first lock, only the expected position of the next
+// sync word is checked. The positions found and the number of frames after the
+// lock are returned.
+func TrackSyncWords(symbols []byte, correlator coding.Correlator, frameBits, wordBits int, minCorrelation uint) ([]int, int) {
+	pos := -1
+	for start := 0; start < probeAcquireFrames*frameBits && start+frameBits+wordBits <= len(symbols); start += frameBits {
+		correlator.Correlate(symbols[start : start+frameBits+wordBits])
+		if correlator.GetHighestCorrelation() >= minCorrelation {
+			pos = start + int(correlator.GetHighestCorrelationPosition())
+			break
+		}
+	}
+
+	if pos < 0 {
+		return nil, 0
+	}
+
+	var found []int
+	frames := 0
+	for ; pos+wordBits+probeTolerance < len(symbols); pos += frameBits {
+		start := pos - probeTolerance
+		if start < 0 {
+			start = 0
+		}
+
+		frames++
+		correlator.Correlate(symbols[start : pos+wordBits+probeTolerance+1])
+		if correlator.GetHighestCorrelation() >= minCorrelation {
+			pos = start + int(correlator.GetHighestCorrelationPosition())
+			found = append(found, pos)
+		}
+	}
+
+	return found, frames
+}
+
+// ProbeSyncWords returns the fraction of frames of the soft-symbols with a sync word.
+// Short inputs are considered to have at least probeMinFrames frames, since a
+// single false lock would be enough to recognize them.
+func ProbeSyncWords(symbols []byte, correlator coding.Correlator, frameBits, wordBits int, minCorrelation uint) float64 {
+	found, frames := TrackSyncWords(symbols, correlator, frameBits, wordBits, minCorrelation)
+	if frames < probeMinFrames {
+		frames = probeMinFrames
+	}
+	return float64(len(found)) / float64(frames)
+}
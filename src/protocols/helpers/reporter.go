@@ -0,0 +1,36 @@
+package helpers
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Reporter writes the progress and messages of a worker. It writes into the
+// standard output unless another writer is set.
+type Reporter struct {
+	output io.Writer
+}
+
+// SetOutput of the progress and messages.
+func (e *Reporter) SetOutput(w io.Writer) {
+	e.output = w
+}
+
+// Output returns the writer of the progress and messages.
+func (e Reporter) Output() io.Writer {
+	if e.output == nil {
+		return os.Stdout
+	}
+	return e.output
+}
+
+// Colorf prints the colored message into the output, a new line is added if missing.
+func (e Reporter) Colorf(attribute color.Attribute, format string, a ...interface{}) {
+	if !strings.HasSuffix(format, "\n") {
+		format += "\n"
+	}
+	color.New(attribute).Fprintf(e.Output(), format, a...)
+}
@@ -0,0 +1,193 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"weatherdump/src/ccsds"
+	"weatherdump/src/ccsds/frames"
+)
+
+// Summary of the transfer frames and packets inside a decoded file.
+type Summary struct {
+	Datalink        string
+	Frames          int
+	LostFrames      int
+	FrameLoss       float64
+	FirstTime       string
+	LastTime        string
+	Spacecrafts     []SpacecraftSummary
+	VirtualChannels []VirtualChannelSummary
+	APIDs           []APIDSummary
+
+	spacecrafts map[uint8]*SpacecraftSummary
+	apids       map[uint16]*APIDSummary
+	counters    map[uint16]*ccsds.PacketCounter
+}
+
+// SpacecraftSummary of the frames received from each SCID.
+type SpacecraftSummary struct {
+	SCID   uint8
+	Name   string
+	Frames int
+}
+
+// VirtualChannelSummary of the frames and packets of each virtual channel.
+type VirtualChannelSummary struct {
+	SCID           uint8
+	VCID           uint8
+	Frames         int
+	LostFrames     int
+	FrameLoss      float64
+	Packets        int
+	DroppedPackets int
+}
+
+// APIDSummary of the packets of each APID. The scan lines are only
+// estimated for the APIDs of the imaging channels.
+type APIDSummary struct {
+	APID        uint16
+	Name        string
+	Packets     int
+	LostPackets int
+	PacketLoss  float64
+	ScanLines   int
+	FirstTime   string
+	LastTime    string
+}
+
+// NewSummary creates an empty summary for the datalink.
+func NewSummary(datalink string) *Summary {
+	return &Summary{
+		Datalink:    datalink,
+		spacecrafts: make(map[uint8]*SpacecraftSummary),
+		apids:       make(map[uint16]*APIDSummary),
+		counters:    make(map[uint16]*ccsds.PacketCounter),
+	}
+}
+
+// AddFrame counts a transfer frame of the spacecraft.
+func (e *Summary) AddFrame(scid uint8, name string) {
+	if e.spacecrafts[scid] == nil {
+		e.spacecrafts[scid] = &SpacecraftSummary{SCID: scid, Name: name}
+	}
+	e.spacecrafts[scid].Frames++
+}
+
+// AddPacket counts the packet and the packets lost before it in the same APID.
+func (e *Summary) AddPacket(packet frames.SpacePacketFrame) {
+	apid := packet.GetAPID()
+	if e.counters[apid] == nil {
+		e.counters[apid] = &ccsds.PacketCounter{}
+	}
+
+	s := e.APID(apid)
+	s.Packets++
+	s.LostPackets += int(e.counters[apid].Next(packet.GetSequenceCount()))
+}
+
+// AddTime registers the timestamp of a packet of the APID.
+func (e *Summary) AddTime(apid uint16, timestamp string) {
+	s := e.APID(apid)
+	if s.FirstTime == "" {
+		s.FirstTime = timestamp
+	}
+	s.LastTime = timestamp
+
+	if e.FirstTime == "" {
+		e.FirstTime = timestamp
+	}
+	e.LastTime = timestamp
+}
+
+// APID returns the summary of the APID, creating it if needed.
+func (e *Summary) APID(apid uint16) *APIDSummary {
+	if e.apids[apid] == nil {
+		e.apids[apid] = &APIDSummary{APID: apid}
+	}
+	return e.apids[apid]
+}
+
+// Finish fills the lists and loss percentages with the statistics
+// of the virtual channels gathered by the demultiplexer.
+func (e *Summary) Finish(stats []ccsds.VirtualChannelStatistics) {
+	e.VirtualChannels = make([]VirtualChannelSummary, 0, len(stats))
+	for _, s := range stats {
+		e.Frames += s.Frames
+		e.LostFrames += s.LostFrames
+		e.VirtualChannels = append(e.VirtualChannels, VirtualChannelSummary{
+			SCID:           s.SCID,
+			VCID:           s.VCID,
+			Frames:         s.Frames,
+			LostFrames:     s.LostFrames,
+			FrameLoss:      lossPercentage(s.Frames, s.LostFrames),
+			Packets:        s.Packets,
+			DroppedPackets: s.DroppedPackets,
+		})
+	}
+	e.FrameLoss = lossPercentage(e.Frames, e.LostFrames)
+
+	e.Spacecrafts = make([]SpacecraftSummary, 0, len(e.spacecrafts))
+	for _, s := range e.spacecrafts {
+		e.Spacecrafts = append(e.Spacecrafts, *s)
+	}
+	sort.Slice(e.Spacecrafts, func(i, j int) bool {
+		return e.Spacecrafts[i].SCID < e.Spacecrafts[j].SCID
+	})
+
+	e.APIDs = make([]APIDSummary, 0, len(e.apids))
+	for _, s := range e.apids {
+		s.PacketLoss = lossPercentage(s.Packets, s.LostPackets)
+		e.APIDs = append(e.APIDs, *s)
+	}
+	sort.Slice(e.APIDs, func(i, j int) bool {
+		return e.APIDs[i].APID < e.APIDs[j].APID
+	})
+}
+
+// Print the summary into the writer.
+func (e Summary) Print(w io.Writer) {
+	fmt.Fprintf(w, "[INF] Datalink %s with %d frames (%d lost, %.2f%%).\n", strings.ToUpper(e.Datalink), e.Frames, e.LostFrames, e.FrameLoss)
+	if e.FirstTime != "" {
+		fmt.Fprintf(w, "[INF] First timestamp %s, last timestamp %s.\n", e.FirstTime, e.LastTime)
+	}
+
+	for _, s := range e.Spacecrafts {
+		fmt.Fprintf(w, "[INF] SCID %3d: %d frames (%s).\n", s.SCID, s.Frames, s.Name)
+	}
+
+	for _, s := range e.VirtualChannels {
+		fmt.Fprintf(w, "[INF] SCID %3d VCID %2d: %d frames (%d lost, %.2f%%), %d packets (%d partial packets dropped).\n",
+			s.SCID, s.VCID, s.Frames, s.LostFrames, s.FrameLoss, s.Packets, s.DroppedPackets)
+	}
+
+	for _, s := range e.APIDs {
+		fmt.Fprintf(w, "[INF] APID %4d: %d packets (%d lost, %.2f%%)", s.APID, s.Packets, s.LostPackets, s.PacketLoss)
+		if s.Name != "" {
+			fmt.Fprintf(w, ", channel %s with %d scan lines", s.Name, s.ScanLines)
+		}
+		if s.FirstTime != "" {
+			fmt.Fprintf(w, ", from %s to %s", s.FirstTime, s.LastTime)
+		}
+		fmt.Fprintln(w, ".")
+	}
+}
+
+// PrintJSON prints the summary into the writer as JSON.
+func (e Summary) PrintJSON(w io.Writer) error {
+	buf, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(buf))
+	return err
+}
+
+func lossPercentage(received, lost int) float64 {
+	if received+lost == 0 {
+		return 0
+	}
+	return float64(lost) / float64(received+lost) * 100
+}
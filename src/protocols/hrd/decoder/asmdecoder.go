@@ -6,12 +6,14 @@ import (
 	"io"
 	"log"
 	"os"
+	"weatherdump/src/ccsds/coding"
+	"weatherdump/src/ccsds/frames"
 	"weatherdump/src/handlers/interfaces"
 	"weatherdump/src/protocols/helpers"
+	"weatherdump/src/protocols/hrd"
 
 	"github.com/fatih/color"
 	"github.com/gosuri/uiprogress"
-	SatHelper "github.com/luigifreitas/libsathelper"
 )
 
 // Decoder ASM
@@ -20,8 +22,9 @@ import (
 type AsmDecoder struct {
 	hardData     []byte
 	rsWorkBuffer []byte
-	reedSolomon  SatHelper.ReedSolomon
+	reedSolomon  coding.ReedSolomon
 	Statistics   helpers.Statistics
+	helpers.Reporter
 }
 
 func NewAsmDecoder(uuid string) interfaces.Decoder {
@@ -31,16 +34,44 @@ func NewAsmDecoder(uuid string) interfaces.Decoder {
 
 	e.hardData = make([]byte, datalink[id].FrameSize)
 	e.rsWorkBuffer = make([]byte, 255)
-	e.reedSolomon = SatHelper.NewReedSolomon()
-	e.reedSolomon.SetCopyParityToOutput(true)
+	e.reedSolomon = coding.NewReedSolomon()
 	e.Statistics.DroppedPackets = 0
 	e.Statistics.TotalPackets = 1
 
 	return &e
 }
 
+// Probe returns the fraction of CADUs in the head of the file starting
+// with the sync word and followed by a valid transfer frame header.
+func (e *AsmDecoder) Probe(inputPath string) float64 {
+	file, err := helpers.OpenHead(inputPath)
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+
+	valid, total := 0, 0
+	cadu := make([]byte, datalink[id].FrameSize)
+	for {
+		if _, err := io.ReadFull(file, cadu); err != nil {
+			break
+		}
+
+		total++
+		frame := frames.NewTransferFrame(cadu[datalink[id].SyncWordSize:])
+		if binary.BigEndian.Uint32(cadu) == 0x1ACFFC1D && hrd.IsValidFrame(frame) {
+			valid++
+		}
+	}
+
+	if total == 0 {
+		return 0
+	}
+	return float64(valid) / float64(total)
+}
+
 func (e *AsmDecoder) Work(inputPath string, outputPath string, signal chan bool) {
-	color.Yellow("[DEC] WARNING! This decoder is currently in BETA development state.")
+	e.Colorf(color.FgYellow, "[DEC] WARNING! This decoder is currently in BETA development state.")
 
 	fi, err := os.Stat(inputPath)
 	input, err := os.Open(inputPath)
@@ -56,6 +87,7 @@ func (e *AsmDecoder) Work(inputPath string, outputPath string, signal chan bool)
 	e.Statistics.TaskName = "Decoding CADU file	"
 
 	progress := uiprogress.New()
+	progress.SetOut(e.Output())
 
 	if !e.Statistics.IsRegistred() {
 		progress.Start()
@@ -101,7 +133,7 @@ func (e *AsmDecoder) Work(inputPath string, outputPath string, signal chan bool)
 		e.Statistics.FrameBits = uint16(datalink[id].FrameBits)
 		e.Statistics.PacketNumber = binary.BigEndian.Uint32(e.hardData[2:]) & 0xFFFFFF00 >> 8
 
-		e.Statistics.ReceivedPacketsPerChannel[e.Statistics.VCID]++
+		e.Statistics.CountFrame(e.Statistics.VCID, e.Statistics.PacketNumber)
 		dat := e.hardData[:datalink[id].FrameSize-datalink[id].RsParityBlockSize-datalink[id].SyncWordSize]
 		output.Write(dat)
 
@@ -114,7 +146,8 @@ func (e *AsmDecoder) Work(inputPath string, outputPath string, signal chan bool)
 
 	os.Remove(outputPath + ".buf")
 
-	color.Green("[DEC] Decoding finished! File saved in the same folder.\n")
+	e.Colorf(color.FgGreen, "[DEC] Decoding finished! File saved in the same folder.\n")
+	e.Statistics.PrintSummary(e.Output())
 
 	e.Statistics.Finish()
 
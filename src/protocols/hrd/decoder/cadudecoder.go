@@ -4,14 +4,17 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"os"
+	"weatherdump/src/ccsds/coding"
+	"weatherdump/src/ccsds/frames"
 	"weatherdump/src/handlers/interfaces"
 	"weatherdump/src/protocols/helpers"
+	"weatherdump/src/protocols/hrd"
 
 	"github.com/fatih/color"
 	"github.com/gosuri/uiprogress"
-	SatHelper "github.com/luigifreitas/libsathelper"
 )
 
 // Decoder CADU
@@ -21,9 +24,10 @@ type CaduDecoder struct {
 	hardData     []byte
 	softData     []byte
 	rsWorkBuffer []byte
-	correlator   SatHelper.Correlator
-	reedSolomon  SatHelper.ReedSolomon
+	correlator   coding.Correlator
+	reedSolomon  coding.ReedSolomon
 	Statistics   helpers.Statistics
+	helpers.Reporter
 }
 
 func NewCaduDecoder(uuid string) interfaces.Decoder {
@@ -34,20 +38,55 @@ func NewCaduDecoder(uuid string) interfaces.Decoder {
 	e.softData = make([]byte, datalink[id].FrameBits)
 	e.hardData = make([]byte, datalink[id].FrameSize)
 	e.rsWorkBuffer = make([]byte, 255)
-	e.correlator = SatHelper.NewCorrelator()
-	e.reedSolomon = SatHelper.NewReedSolomon()
-	e.reedSolomon.SetCopyParityToOutput(true)
+	e.correlator = coding.NewCorrelator()
+	e.reedSolomon = coding.NewReedSolomon()
 	e.Statistics.DroppedPackets = 0
 	e.Statistics.TotalPackets = 1
 
-	e.correlator.AddWord(uint(0x1ACFFC1D))
-	e.correlator.AddWord(uint(0xE53003E2))
+	e.correlator.AddWord32(0x1ACFFC1D)
+	e.correlator.AddWord32(0xE53003E2)
 
 	return &e
 }
 
+// Probe returns the fraction of CADUs in the head of the file with a
+// sync word followed by a valid transfer frame header.
+func (e *CaduDecoder) Probe(inputPath string) float64 {
+	file, err := helpers.OpenHead(inputPath)
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+
+	hard, _ := ioutil.ReadAll(io.LimitReader(file, helpers.ProbeSize/8))
+	soft := make([]byte, len(hard)*8)
+	convertToArray(hard, &soft, len(hard))
+
+	found, total := helpers.TrackSyncWords(soft, e.correlator, datalink[id].FrameBits, asmBits, datalink[id].MinCorrelationBits/2+1)
+	if total == 0 {
+		return 0
+	}
+
+	valid := 0
+	frame := make([]byte, datalink[id].FrameSize-datalink[id].SyncWordSize)
+	for _, pos := range found {
+		start := pos + asmBits
+		if start+len(frame)*8 > len(soft) {
+			continue
+		}
+
+		convertToBytes(soft[start:], frame)
+		coding.DeRandomize(frame)
+		if hrd.IsValidFrame(frames.NewTransferFrame(frame)) {
+			valid++
+		}
+	}
+
+	return float64(valid) / float64(total)
+}
+
 func (e *CaduDecoder) Work(inputPath string, outputPath string, signal chan bool) {
-	color.Red("[DEC] WARNING! This decoder is currently in ALPHA development state.")
+	e.Colorf(color.FgRed, "[DEC] WARNING! This decoder is currently in ALPHA development state.")
 	flywheelCount := 0
 
 	fi, err := os.Stat(inputPath)
@@ -61,6 +100,7 @@ func (e *CaduDecoder) Work(inputPath string, outputPath string, signal chan bool
 	e.Statistics.TaskName = "Converting CADU file"
 
 	progress := uiprogress.New()
+	progress.SetOut(e.Output())
 
 	if !e.Statistics.IsRegistred() {
 		progress.Start()
@@ -79,10 +119,10 @@ func (e *CaduDecoder) Work(inputPath string, outputPath string, signal chan bool
 
 	bar2.AppendFunc(func(b *uiprogress.Bar) string {
 		s := e.Statistics
-		return fmt.Sprintf("\n[DEC] Decoder Statistics	 [VCID: %2d] [#%8d]  [CORR: %2d] [RS: %2d %2d %2d %2d]  [DROPPED: %4.1f%%]",
+		return fmt.Sprintf("\n[DEC] Decoder Statistics	 [VCID: %2d] [#%8d]  [CORR: %2d] [RS: %2d %2d %2d %2d]  [DROPPED: %4.1f%%] [LOST: %d]",
 			s.VCID, s.PacketNumber, s.SyncCorrelation,
 			s.AverageRSCorrections[0], s.AverageRSCorrections[1], s.AverageRSCorrections[2], s.AverageRSCorrections[3],
-			float32(s.DroppedPackets)/float32(s.TotalPackets)*100)
+			float32(s.DroppedPackets)/float32(s.TotalPackets)*100, s.LostPackets)
 	})
 
 	e.Statistics.WaitForClient(signal)
@@ -145,11 +185,11 @@ func (e *CaduDecoder) Work(inputPath string, outputPath string, signal chan bool
 		}
 
 		if !e.Statistics.FrameLock {
-			e.correlator.Correlate(&e.softData[0], uint(datalink[id].FrameBits))
+			e.correlator.Correlate(e.softData)
 		} else {
-			e.correlator.Correlate(&e.softData[0], uint(datalink[id].FrameBits)/128)
+			e.correlator.Correlate(e.softData[:datalink[id].FrameBits/128])
 			if e.correlator.GetHighestCorrelationPosition() != 0 {
-				e.correlator.Correlate(&e.softData[0], uint(datalink[id].FrameBits))
+				e.correlator.Correlate(e.softData)
 				flywheelCount = 0
 			}
 		}
@@ -169,7 +209,7 @@ func (e *CaduDecoder) Work(inputPath string, outputPath string, signal chan bool
 				e.Statistics.TotalBytesRead += uint64(n)
 				bar2.Set(int(e.Statistics.TotalBytesRead))
 				if err != nil {
-					fmt.Println(err)
+					fmt.Fprintln(e.Output(), err)
 					return true
 				}
 
@@ -178,27 +218,17 @@ func (e *CaduDecoder) Work(inputPath string, outputPath string, signal chan bool
 				}
 			}
 
-			for i := 0; i < datalink[id].FrameBits; i += 8 {
-				b := byte(0x00)
-				for j := i; j < i+8 && j < datalink[id].FrameBits; j++ {
-					v := byte(0x00)
-					if e.softData[j] > 128 {
-						v = byte(0x01)
-					}
-					b = (b << 1) | v
-				}
-				e.hardData[i/8] = b
-			}
+			convertToBytes(e.softData, e.hardData)
 
 			helpers.ShiftWithConstantSize(&e.hardData, datalink[id].SyncWordSize, datalink[id].FrameSize-datalink[id].SyncWordSize)
-			SatHelper.DeRandomizerDeRandomize(&e.hardData[0], datalink[id].FrameSize-datalink[id].SyncWordSize)
+			coding.DeRandomize(e.hardData[:datalink[id].FrameSize-datalink[id].SyncWordSize])
 			e.Statistics.TotalPackets++
 
 			var derrors [4]int
 			for i := 0; i < datalink[id].RsBlocks; i++ {
-				e.reedSolomon.Deinterleave(&e.hardData[0], &e.rsWorkBuffer[0], byte(i), byte(datalink[id].RsBlocks))
-				derrors[i] = int(int8(e.reedSolomon.Decode_ccsds(&e.rsWorkBuffer[0])))
-				e.reedSolomon.Interleave(&e.rsWorkBuffer[0], &e.hardData[0], byte(i), byte(datalink[id].RsBlocks))
+				e.reedSolomon.Deinterleave(e.hardData, e.rsWorkBuffer, i, datalink[id].RsBlocks)
+				derrors[i] = e.reedSolomon.DecodeDualBasis(e.rsWorkBuffer)
+				e.reedSolomon.Interleave(e.rsWorkBuffer, e.hardData, i, datalink[id].RsBlocks)
 				if derrors[i] != -1 {
 					e.Statistics.AverageRSCorrections[i] = (e.Statistics.AverageRSCorrections[i] + derrors[i]) / 2
 				}
@@ -218,7 +248,7 @@ func (e *CaduDecoder) Work(inputPath string, outputPath string, signal chan bool
 			e.Statistics.PacketNumber = binary.BigEndian.Uint32(e.hardData[2:]) & 0xFFFFFF00 >> 8
 
 			if e.Statistics.FrameLock {
-				e.Statistics.ReceivedPacketsPerChannel[e.Statistics.VCID]++
+				e.Statistics.CountFrame(e.Statistics.VCID, e.Statistics.PacketNumber)
 				dat := e.hardData[:datalink[id].FrameSize-datalink[id].RsParityBlockSize-datalink[id].SyncWordSize]
 				output.Write(dat)
 			}
@@ -236,7 +266,8 @@ func (e *CaduDecoder) Work(inputPath string, outputPath string, signal chan bool
 	inputBuf.Close()
 	os.Remove(outputPath + ".buf")
 
-	color.Green("[DEC] Decoding finished! File saved in the same folder.\n")
+	e.Colorf(color.FgGreen, "[DEC] Decoding finished! File saved in the same folder.\n")
+	e.Statistics.PrintSummary(e.Output())
 
 	e.Statistics.Finish()
 
@@ -265,3 +296,17 @@ func convertToArray(hard []byte, soft *[]byte, len int) {
 		}
 	}
 }
+
+func convertToBytes(soft []byte, hard []byte) {
+	for i := range hard {
+		b := byte(0x00)
+		for j := i * 8; j < i*8+8; j++ {
+			v := byte(0x00)
+			if soft[j] > 128 {
+				v = byte(0x01)
+			}
+			b = (b << 1) | v
+		}
+		hard[i] = b
+	}
+}
@@ -0,0 +1,126 @@
+//go:build !purego
+// +build !purego
+
+package decoder
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"weatherdump/src/ccsds/coding"
+)
+
+// sample recorded from the X-Band downlink of Suomi-NPP. The repository keeps it in
+// Git LFS, without it the checkout only has the pointer to the file.
+const sample = "../../../../samples/npp_q.raw"
+
+// recordingViterbi and recordingReedSolomon log the result of every frame and block.
+type recordingViterbi struct {
+	coding.Viterbi
+	log *bytes.Buffer
+}
+
+func (e recordingViterbi) Decode(input, output []byte) {
+	e.Viterbi.Decode(input, output)
+	fmt.Fprintf(e.log, "VIT %d %f\n", e.GetBER(), e.GetPercentBER())
+}
+
+type recordingReedSolomon struct {
+	coding.ReedSolomon
+	log *bytes.Buffer
+}
+
+func (e recordingReedSolomon) DecodeDualBasis(block []byte) int {
+	n := e.ReedSolomon.DecodeDualBasis(block)
+	fmt.Fprintf(e.log, "RS %d\n", n)
+	return n
+}
+
+// decodeSample returns the decoded file and the log of the Viterbi and Reed-Solomon
+// statistics, with the primitives of libsathelper or the ones written in Go. The
+// derandomizer and the NRZ-M decoder are package functions, compared on their own in
+// the tests of the coding package.
+func decodeSample(t *testing.T, dir string, pureGo bool) ([]byte, []byte) {
+	e := newSoftSymbolDecoder("")
+	e.SetOutput(ioutil.Discard)
+
+	if pureGo {
+		frameBits := datalink[id].FrameBits
+		if uselastFrameData {
+			frameBits += lastFrameDataBits
+		}
+		e.viterbi = coding.NewGoViterbi27(frameBits)
+		e.reedSolomon = coding.NewGoReedSolomon()
+		e.correlator = coding.NewGoCorrelator()
+		e.packetFixer = coding.NewGoPacketFixer()
+		for _, word := range datalink[id].SyncWords {
+			e.correlator.AddWord(word)
+		}
+	}
+
+	var log bytes.Buffer
+	e.viterbi = recordingViterbi{e.viterbi, &log}
+	e.reedSolomon = recordingReedSolomon{e.reedSolomon, &log}
+
+	output := filepath.Join(dir, fmt.Sprintf("decoded-%t.bin", pureGo))
+	e.Work(sample, output, nil)
+
+	s := e.Statistics
+	fmt.Fprintf(&log, "TOTAL %d DROPPED %d LOST %d VIT %d RS %v\n",
+		s.TotalPackets, s.DroppedPackets, s.LostPackets, s.AverageVitCorrections, s.AverageRSCorrections)
+	fmt.Fprintf(&log, "RECEIVED %v\nLOST %v\n", s.ReceivedPacketsPerChannel, s.LostPacketsPerChannel)
+
+	decoded, err := ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return decoded, log.Bytes()
+}
+
+// TestSampleEquivalence decodes the recorded sample with libsathelper and with the
+// primitives written in Go, expecting the same output file and statistics.
+func TestSampleEquivalence(t *testing.T) {
+	head := make([]byte, 64)
+	file, err := os.Open(sample)
+	if err != nil {
+		t.Skip(err)
+	}
+	n, _ := file.Read(head)
+	file.Close()
+	if bytes.HasPrefix(head[:n], []byte("version https://git-lfs")) {
+		t.Skip("the sample is a Git LFS pointer, fetch it with git lfs pull")
+	}
+
+	dir, err := ioutil.TempDir("", "hrd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cDecoded, cLog := decodeSample(t, dir, false)
+	goDecoded, goLog := decodeSample(t, dir, true)
+
+	if len(cDecoded) == 0 {
+		t.Fatal("no frames decoded from the sample")
+	}
+	if !bytes.Equal(cDecoded, goDecoded) {
+		t.Fatalf("decoded files differ first at byte %d, %d and %d bytes long",
+			firstDifference(cDecoded, goDecoded), len(cDecoded), len(goDecoded))
+	}
+	if !bytes.Equal(cLog, goLog) {
+		line := bytes.Count(cLog[:firstDifference(cLog, goLog)], []byte("\n")) + 1
+		t.Fatalf("statistics differ first at line %d of the log", line)
+	}
+}
+
+func firstDifference(a, b []byte) int {
+	for i := range a {
+		if i >= len(b) || a[i] != b[i] {
+			return i
+		}
+	}
+	return len(a)
+}
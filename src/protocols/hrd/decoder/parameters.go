@@ -11,6 +11,8 @@ const (
 	lastFrameData          = lastFrameDataBits / 8
 	uselastFrameData       = true
 	id                     = "HRD"
+	syncWordBits           = 64
+	asmBits                = 32
 )
 
 type parameters struct {
@@ -21,6 +23,8 @@ type parameters struct {
 	SyncWordSize       int
 	RsParityBlockSize  int
 	RsBlocks           int
+	SymbolRate         int
+	RRCAlpha           float64
 	SyncWords          [8]uint64
 }
 
@@ -33,6 +37,8 @@ var datalink = map[string]parameters{
 		SyncWordSize:       4,
 		RsParityBlockSize:  (32 * 4),
 		RsBlocks:           4,
+		SymbolRate:         15000000,
+		RRCAlpha:           0.5,
 		SyncWords: [8]uint64{
 			0xfc4ef4fd0cc2df89,
 			0x56275254a66b45ec,
@@ -3,8 +3,11 @@ package decoder
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"os"
+	"weatherdump/src/ccsds/coding"
+	"weatherdump/src/dsp"
 	"weatherdump/src/handlers/interfaces"
 	"weatherdump/src/protocols/helpers"
 
@@ -12,7 +15,6 @@ import (
 
 	"github.com/fatih/color"
 	"github.com/gosuri/uiprogress"
-	SatHelper "github.com/luigifreitas/libsathelper"
 )
 
 type SoftSymbolDecoder struct {
@@ -21,14 +23,29 @@ type SoftSymbolDecoder struct {
 	lastFrameEnd []byte
 	codedData    []byte
 	rsWorkBuffer []byte
-	viterbi      SatHelper.Viterbi27
-	reedSolomon  SatHelper.ReedSolomon
-	correlator   SatHelper.Correlator
-	packetFixer  SatHelper.PacketFixer
+	viterbi      coding.Viterbi
+	reedSolomon  coding.ReedSolomon
+	correlator   coding.Correlator
+	packetFixer  coding.PacketFixer
+	demodulate   bool
+	sampleRate   float64
 	Statistics   helpers.Statistics
+	helpers.Reporter
 }
 
 func NewSoftSymbolDecoder(uuid string) interfaces.Decoder {
+	return newSoftSymbolDecoder(uuid)
+}
+
+// NewIQDecoder creator for baseband IQ recordings of the X-Band downlink.
+// The recording is demodulated into soft-symbols before decoding.
+func NewIQDecoder(uuid string) interfaces.Decoder {
+	e := newSoftSymbolDecoder(uuid)
+	e.demodulate = true
+	return e
+}
+
+func newSoftSymbolDecoder(uuid string) *SoftSymbolDecoder {
 	e := SoftSymbolDecoder{}
 
 	e.Statistics.Register("hrd", uuid)
@@ -37,7 +54,7 @@ func NewSoftSymbolDecoder(uuid string) interfaces.Decoder {
 		e.viterbiData = make([]byte, datalink[id].CodedFrameSize+lastFrameDataBits)
 		e.decodedData = make([]byte, datalink[id].FrameSize+lastFrameData)
 		e.lastFrameEnd = make([]byte, lastFrameDataBits)
-		e.viterbi = SatHelper.NewViterbi27(datalink[id].FrameBits + lastFrameDataBits)
+		e.viterbi = coding.NewViterbi27(datalink[id].FrameBits + lastFrameDataBits)
 
 		for i := 0; i < lastFrameDataBits; i++ {
 			e.lastFrameEnd[i] = 128
@@ -45,17 +62,15 @@ func NewSoftSymbolDecoder(uuid string) interfaces.Decoder {
 	} else {
 		e.viterbiData = make([]byte, datalink[id].CodedFrameSize)
 		e.decodedData = make([]byte, datalink[id].FrameSize)
-		e.viterbi = SatHelper.NewViterbi27(datalink[id].FrameBits)
+		e.viterbi = coding.NewViterbi27(datalink[id].FrameBits)
 	}
 
 	e.codedData = make([]byte, datalink[id].CodedFrameSize)
 	e.rsWorkBuffer = make([]byte, 255)
 
-	e.reedSolomon = SatHelper.NewReedSolomon()
-	e.correlator = SatHelper.NewCorrelator()
-	e.packetFixer = SatHelper.NewPacketFixer()
-
-	e.reedSolomon.SetCopyParityToOutput(true)
+	e.reedSolomon = coding.NewReedSolomon()
+	e.correlator = coding.NewCorrelator()
+	e.packetFixer = coding.NewPacketFixer()
 
 	e.correlator.AddWord(datalink[id].SyncWords[0])
 	e.correlator.AddWord(datalink[id].SyncWords[1])
@@ -70,24 +85,70 @@ func NewSoftSymbolDecoder(uuid string) interfaces.Decoder {
 	return &e
 }
 
+// SetSampleRate of raw IQ recordings without a header.
+func (e *SoftSymbolDecoder) SetSampleRate(sampleRate float64) {
+	e.sampleRate = sampleRate
+}
+
+// newInput returns the soft-symbols of the file, demodulating IQ recordings.
+func (e *SoftSymbolDecoder) newInput(file io.Reader, inputPath string) (io.Reader, error) {
+	if !e.demodulate {
+		return file, nil
+	}
+
+	iq, err := dsp.NewIQReader(file, inputPath, e.sampleRate)
+	if err != nil {
+		return nil, err
+	}
+	return dsp.NewQPSKDemodulator(iq, float64(datalink[id].SymbolRate), datalink[id].RRCAlpha), nil
+}
+
+// Probe returns the fraction of frames in the head of the file with a sync word.
+func (e *SoftSymbolDecoder) Probe(inputPath string) float64 {
+	file, err := helpers.OpenHead(inputPath)
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+
+	input, err := e.newInput(file, inputPath)
+	if err != nil {
+		return 0
+	}
+
+	symbols, _ := ioutil.ReadAll(input)
+	return helpers.ProbeSyncWords(symbols, e.correlator, datalink[id].CodedFrameSize, syncWordBits, datalink[id].MinCorrelationBits)
+}
+
 func (e *SoftSymbolDecoder) Work(inputPath string, outputPath string, signal chan bool) {
-	var phaseShift SatHelper.SatHelperPhaseShift
+	var phaseShift coding.PhaseShift
 	flywheelCount := 0
 
 	fi, err := os.Stat(inputPath)
 	output, err := os.Create(outputPath)
-	input, err := os.Open(inputPath)
+	file, err := os.Open(inputPath)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	defer input.Close()
+	defer file.Close()
 	defer output.Close()
 
+	counter := &helpers.CountingReader{Reader: file}
+	input, err := e.newInput(counter, inputPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	e.Statistics.TotalBytes = uint64(fi.Size())
 	e.Statistics.TaskName = "Decoding soft-symbol file"
 
+	if e.demodulate {
+		e.Statistics.TaskName = "Demodulating IQ file"
+	}
+
 	progress := uiprogress.New()
+	progress.SetOut(e.Output())
 
 	if !e.Statistics.IsRegistred() {
 		progress.Start()
@@ -95,28 +156,28 @@ func (e *SoftSymbolDecoder) Work(inputPath string, outputPath string, signal cha
 
 	bar := progress.AddBar(int(fi.Size())).AppendCompleted()
 	bar.PrependFunc(func(b *uiprogress.Bar) string {
-		return "[DEC] Decoding soft-symbol file	"
+		return fmt.Sprintf("[DEC] %s	", e.Statistics.TaskName)
 	})
 	bar.AppendFunc(func(b *uiprogress.Bar) string {
 		s := e.Statistics
-		return fmt.Sprintf("\n[DEC] Decoder Statistics	 [VCID: %2d] [VIT: %5d] [QUAL: %2d%%] [RS: %2d %2d %2d %2d] [DROPPED: %4.1f%%]",
+		return fmt.Sprintf("\n[DEC] Decoder Statistics	 [VCID: %2d] [VIT: %5d] [QUAL: %2d%%] [RS: %2d %2d %2d %2d] [DROPPED: %4.1f%%] [LOST: %d]",
 			s.VCID, s.AverageVitCorrections, s.SignalQuality,
 			s.AverageRSCorrections[0], s.AverageRSCorrections[1], s.AverageRSCorrections[2], s.AverageRSCorrections[3],
-			float32(s.DroppedPackets)/float32(s.TotalPackets)*100)
+			float32(s.DroppedPackets)/float32(s.TotalPackets)*100, s.LostPackets)
 	})
 
 	e.Statistics.WaitForClient(signal)
 
 	helpers.WatchFor(signal, func() bool {
-		n, err := input.Read(e.codedData)
-		if datalink[id].CodedFrameSize != n || err != nil {
-			if err != io.EOF && err != nil {
+		_, err := io.ReadFull(input, e.codedData)
+		if err != nil {
+			if err != io.EOF && err != io.ErrUnexpectedEOF {
 				log.Fatal(err)
 			}
 			return true
 		}
 
-		e.Statistics.TotalBytesRead += uint64(n)
+		e.Statistics.TotalBytesRead = counter.Count
 		bar.Set(int(e.Statistics.TotalBytesRead))
 
 		if e.Statistics.TotalPackets%32 == 0 {
@@ -135,11 +196,11 @@ func (e *SoftSymbolDecoder) Work(inputPath string, outputPath string, signal cha
 		}
 
 		if !e.Statistics.FrameLock {
-			e.correlator.Correlate(&e.codedData[0], uint(datalink[id].CodedFrameSize))
+			e.correlator.Correlate(e.codedData)
 		} else {
-			e.correlator.Correlate(&e.codedData[0], uint(datalink[id].CodedFrameSize)/64)
+			e.correlator.Correlate(e.codedData[:datalink[id].CodedFrameSize/64])
 			if e.correlator.GetHighestCorrelationPosition() != 0 {
-				e.correlator.Correlate(&e.codedData[0], uint(datalink[id].CodedFrameSize))
+				e.correlator.Correlate(e.codedData)
 				flywheelCount = 0
 			}
 		}
@@ -153,13 +214,13 @@ func (e *SoftSymbolDecoder) Work(inputPath string, outputPath string, signal cha
 			iqInv := (word / 4) > 0
 			switch word % 4 {
 			case 0:
-				phaseShift = SatHelper.DEG_0
+				phaseShift = coding.Deg0
 			case 1:
-				phaseShift = SatHelper.DEG_90
+				phaseShift = coding.Deg90
 			case 2:
-				phaseShift = SatHelper.DEG_180
+				phaseShift = coding.Deg180
 			case 3:
-				phaseShift = SatHelper.DEG_270
+				phaseShift = coding.Deg270
 			}
 
 			if pos != 0 {
@@ -167,12 +228,12 @@ func (e *SoftSymbolDecoder) Work(inputPath string, outputPath string, signal cha
 				offset := datalink[id].CodedFrameSize - int(pos)
 
 				buffer := make([]byte, int(pos))
-				n, err = input.Read(buffer)
+				_, err = io.ReadFull(input, buffer)
 
-				e.Statistics.TotalBytesRead += uint64(n)
+				e.Statistics.TotalBytesRead = counter.Count
 				bar.Set(int(e.Statistics.TotalBytesRead))
 				if err != nil {
-					fmt.Println(err)
+					fmt.Fprintln(e.Output(), err)
 					return true
 				}
 
@@ -181,7 +242,7 @@ func (e *SoftSymbolDecoder) Work(inputPath string, outputPath string, signal cha
 				}
 			}
 
-			e.packetFixer.FixPacket(&e.codedData[0], uint(datalink[id].CodedFrameSize), phaseShift, iqInv)
+			e.packetFixer.FixPacket(e.codedData, phaseShift, iqInv)
 
 			if uselastFrameData {
 				for i := 0; i < lastFrameDataBits; i++ {
@@ -196,7 +257,7 @@ func (e *SoftSymbolDecoder) Work(inputPath string, outputPath string, signal cha
 				}
 			}
 
-			e.viterbi.Decode(&e.viterbiData[0], &e.decodedData[0])
+			e.viterbi.Decode(e.viterbiData, e.decodedData)
 
 			nrzmDecodeSize := datalink[id].FrameSize
 
@@ -204,7 +265,7 @@ func (e *SoftSymbolDecoder) Work(inputPath string, outputPath string, signal cha
 				nrzmDecodeSize += lastFrameData
 			}
 
-			SatHelper.DifferentialEncodingNrzmDecode(&e.decodedData[0], nrzmDecodeSize)
+			coding.NRZMDecode(e.decodedData[:nrzmDecodeSize])
 
 			signalErrors := float32(e.viterbi.GetPercentBER())
 			signalErrors = 100 - (signalErrors * 10)
@@ -224,13 +285,13 @@ func (e *SoftSymbolDecoder) Work(inputPath string, outputPath string, signal cha
 
 			e.Statistics.TotalPackets++
 
-			SatHelper.DeRandomizerDeRandomize(&e.decodedData[0], datalink[id].FrameSize-datalink[id].SyncWordSize)
+			coding.DeRandomize(e.decodedData[:datalink[id].FrameSize-datalink[id].SyncWordSize])
 
 			var derrors [4]int
 			for i := 0; i < datalink[id].RsBlocks; i++ {
-				e.reedSolomon.Deinterleave(&e.decodedData[0], &e.rsWorkBuffer[0], byte(i), byte(datalink[id].RsBlocks))
-				derrors[i] = int(int8(e.reedSolomon.Decode_ccsds(&e.rsWorkBuffer[0])))
-				e.reedSolomon.Interleave(&e.rsWorkBuffer[0], &e.decodedData[0], byte(i), byte(datalink[id].RsBlocks))
+				e.reedSolomon.Deinterleave(e.decodedData, e.rsWorkBuffer, i, datalink[id].RsBlocks)
+				derrors[i] = e.reedSolomon.DecodeDualBasis(e.rsWorkBuffer)
+				e.reedSolomon.Interleave(e.rsWorkBuffer, e.decodedData, i, datalink[id].RsBlocks)
 				if derrors[i] != -1 {
 					e.Statistics.AverageRSCorrections[i] = (e.Statistics.AverageRSCorrections[i] + derrors[i]) / 2
 				}
@@ -258,7 +319,7 @@ func (e *SoftSymbolDecoder) Work(inputPath string, outputPath string, signal cha
 			e.Statistics.AverageVitCorrections /= 2
 
 			if e.Statistics.FrameLock {
-				e.Statistics.ReceivedPacketsPerChannel[e.Statistics.VCID]++
+				e.Statistics.CountFrame(e.Statistics.VCID, e.Statistics.PacketNumber)
 				dat := e.decodedData[:datalink[id].FrameSize-datalink[id].RsParityBlockSize-datalink[id].SyncWordSize]
 				output.Write(dat)
 			}
@@ -267,7 +328,8 @@ func (e *SoftSymbolDecoder) Work(inputPath string, outputPath string, signal cha
 		return false
 	})
 
-	color.Green("[DEC] Decoding finished! File saved in the same folder.\n")
+	e.Colorf(color.FgGreen, "[DEC] Decoding finished! File saved in the same folder.\n")
+	e.Statistics.PrintSummary(e.Output())
 
 	e.Statistics.Finish()
 
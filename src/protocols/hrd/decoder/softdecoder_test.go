@@ -0,0 +1,36 @@
+package decoder
+
+import (
+	"fmt"
+	"testing"
+	"weatherdump/src/simulator/simtest"
+)
+
+// simulatedNoise keeps the clipping of the soft-symbols rare. With more noise the symbols
+// clipped at +127 are taken as ones by the hard decision of the correlator, like in
+// libsathelper, and sync words are occasionally missed.
+const (
+	simulatedFrames = 256
+	simulatedNoise  = 0.1
+)
+
+// TestSimulatedRoundTrip decodes simulated soft-symbol files with low noise in all the
+// phase ambiguities of the carrier and expects every frame back.
+func TestSimulatedRoundTrip(t *testing.T) {
+	for _, rotation := range []int{0, 90, 180, 270} {
+		t.Run(fmt.Sprintf("%d", rotation), func(t *testing.T) {
+			simtest.RoundTrip(t, NewSoftSymbolDecoder(""), simtest.Case{
+				Datalink: "hrd", Format: "soft", Frames: simulatedFrames,
+				Noise: simulatedNoise, Rotation: rotation, Seed: int64(rotation),
+			})
+		})
+	}
+}
+
+// TestSimulatedIQ decodes a baseband IQ file. The demodulator may lose the
+// first frames while it locks.
+func TestSimulatedIQ(t *testing.T) {
+	simtest.RoundTrip(t, NewIQDecoder(""), simtest.Case{
+		Datalink: "hrd", Format: "iq", Frames: 64, Noise: simulatedNoise, Seed: 1, MaxLost: 2,
+	})
+}
@@ -0,0 +1,62 @@
+package hrd
+
+import (
+	"encoding/binary"
+	"math"
+	"weatherdump/src/ccsds/frames"
+	"weatherdump/src/geo"
+)
+
+// DiaryAPID of the spacecraft diary packets with the ephemeris and attitude.
+const DiaryAPID = 11
+
+// Offsets inside the data field of the spacecraft diary packets, as laid out by the
+// Spacecraft Diary RDR of the JPSS Common Data Format Control Book - External,
+// Volume II (474-00001-02). Every field is big-endian.
+const (
+	diaryEphemerisTime = 8  // CCSDS day segmented time of the ephemeris
+	diaryPosition      = 16 // Earth-fixed X, Y and Z position in meters, float32
+	diaryVelocity      = 28 // Earth-fixed X, Y and Z velocity in m/s, float32
+	diaryAttitudeTime  = 40 // CCSDS day segmented time of the attitude
+	diaryQuaternion    = 48 // attitude quaternion Q1 to Q4, float32
+	diarySize          = 64
+)
+
+// Earth-fixed orbit radius limits of valid positions, in meters.
+const (
+	minOrbitRadius = 6.5e6
+	maxOrbitRadius = 8.0e6
+)
+
+// ParseDiary returns the state vector of a spacecraft diary packet, timed by the
+// ephemeris timestamp. Packets with positions outside the orbit radius limits are invalid.
+func ParseDiary(packet frames.SpacePacketFrame) (geo.StateVector, bool) {
+	var sv geo.StateVector
+
+	dat := packet.GetData()
+	if !packet.IsValid() || len(dat) < diarySize {
+		return sv, false
+	}
+
+	var t Time
+	t.FromBinary(dat[diaryEphemerisTime:diaryPosition])
+	sv.Time = t.GetDate().UTC()
+
+	var radius float64
+	for i := 0; i < 3; i++ {
+		sv.Position[i] = readFloat(dat[diaryPosition+i*4:])
+		sv.Velocity[i] = readFloat(dat[diaryVelocity+i*4:])
+		radius += sv.Position[i] * sv.Position[i]
+	}
+
+	for i := 0; i < 4; i++ {
+		sv.Attitude[i] = readFloat(dat[diaryQuaternion+i*4:])
+	}
+
+	radius = math.Sqrt(radius)
+	return sv, radius > minOrbitRadius && radius < maxOrbitRadius
+}
+
+func readFloat(dat []byte) float64 {
+	return float64(math.Float32frombits(binary.BigEndian.Uint32(dat)))
+}
@@ -0,0 +1,75 @@
+package hrd
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+	"weatherdump/src/ccsds/frames"
+)
+
+// diaryPacket encodes a spacecraft diary packet with the time and the state vector.
+func diaryPacket(day uint16, ms uint32, pos, vel [3]float32, q [4]float32) frames.SpacePacketFrame {
+	dat := make([]byte, 6+diarySize)
+	dat[0] = 0x08 | uint8(DiaryAPID>>8)
+	dat[1] = uint8(DiaryAPID)
+	binary.BigEndian.PutUint16(dat[2:], 0xC000)
+	binary.BigEndian.PutUint16(dat[4:], diarySize-1)
+
+	body := dat[6:]
+	for _, offset := range []int{0, diaryEphemerisTime, diaryAttitudeTime} {
+		binary.BigEndian.PutUint16(body[offset:], day)
+		binary.BigEndian.PutUint32(body[offset+2:], ms)
+	}
+	for i := 0; i < 3; i++ {
+		binary.BigEndian.PutUint32(body[diaryPosition+i*4:], math.Float32bits(pos[i]))
+		binary.BigEndian.PutUint32(body[diaryVelocity+i*4:], math.Float32bits(vel[i]))
+	}
+	for i := 0; i < 4; i++ {
+		binary.BigEndian.PutUint32(body[diaryQuaternion+i*4:], math.Float32bits(q[i]))
+	}
+
+	var packet frames.SpacePacketFrame
+	packet.FromBinary(dat)
+	return packet
+}
+
+func TestParseDiary(t *testing.T) {
+	pos := [3]float32{-2114532.5, 4318870.0, 5412987.5}
+	vel := [3]float32{1204.25, -5873.5, 5161.75}
+	q := [4]float32{0.25, -0.5, 0.75, 0.3535534}
+
+	// 2020-01-01 12:00:00 UTC is day 22645 since 1958.
+	sv, ok := ParseDiary(diaryPacket(22645, 43200000, pos, vel, q))
+	if !ok {
+		t.Fatal("valid diary packet rejected")
+	}
+
+	if want := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC); !sv.Time.Equal(want) {
+		t.Errorf("time %s, want %s", sv.Time, want)
+	}
+	for i := 0; i < 3; i++ {
+		if sv.Position[i] != float64(pos[i]) || sv.Velocity[i] != float64(vel[i]) {
+			t.Errorf("axis %d: position %g velocity %g, want %g %g",
+				i, sv.Position[i], sv.Velocity[i], pos[i], vel[i])
+		}
+	}
+	for i := 0; i < 4; i++ {
+		if sv.Attitude[i] != float64(q[i]) {
+			t.Errorf("quaternion %d: %g, want %g", i, sv.Attitude[i], q[i])
+		}
+	}
+}
+
+func TestParseDiaryInvalid(t *testing.T) {
+	ground := diaryPacket(22645, 0, [3]float32{6378137, 0, 0}, [3]float32{}, [4]float32{})
+	if _, ok := ParseDiary(ground); ok {
+		t.Error("position on the ground accepted")
+	}
+
+	truncated := diaryPacket(22645, 0, [3]float32{7200000, 0, 0}, [3]float32{}, [4]float32{})
+	truncated.FromBinary(truncated.ToBinary()[:6+diarySize-4])
+	if _, ok := ParseDiary(truncated); ok {
+		t.Error("truncated packet accepted")
+	}
+}
@@ -0,0 +1,105 @@
+// Package atms assembles the science packets of the Advanced Technology Microwave
+// Sounder into one image per channel in the scan geometry. Each packet carries
+// one beam position: the 8 bytes timestamp, the beam position, the status word
+// and the counts of the 22 channels as 16-bit words.
+package atms
+
+import (
+	"encoding/binary"
+	"fmt"
+	"weatherdump/src/ccsds/frames"
+	"weatherdump/src/protocols/hrd"
+)
+
+// ScienceAPID of the ATMS science packets.
+const ScienceAPID = 528
+
+// BeamPositions of the Earth view of each scan.
+const BeamPositions = 96
+
+// ChannelCount of the sounder.
+const ChannelCount = 22
+
+// ManifestBase is the first manifest key of the ATMS channels. The keys are
+// after the APID range, so the channels share the parser manifest with VIIRS.
+const ManifestBase = 2048
+
+const packetSize = 8 + 2 + 2 + ChannelCount*2
+
+type scan [BeamPositions][ChannelCount]uint16
+
+// Sounder keeps the scans of all channels received so far.
+type Sounder struct {
+	scans     []scan
+	lastBeam  int
+	StartTime hrd.Time
+	EndTime   hrd.Time
+}
+
+// New returns an empty sounder.
+func New() *Sounder {
+	return &Sounder{lastBeam: BeamPositions}
+}
+
+// Parse a science packet. A beam position before the last one starts a new scan,
+// the cold and warm calibration views after the Earth view are ignored.
+func (e *Sounder) Parse(packet frames.SpacePacketFrame) {
+	dat := packet.GetData()
+	if !packet.IsValid() || len(dat) < packetSize {
+		return
+	}
+
+	beam := int(binary.BigEndian.Uint16(dat[8:])&0x7F) - 1
+	if beam < 0 || beam >= BeamPositions {
+		return
+	}
+
+	var t hrd.Time
+	t.FromBinary(dat[0:8])
+
+	if beam <= e.lastBeam {
+		if len(e.scans) == 0 {
+			e.StartTime = t
+		}
+		e.scans = append(e.scans, scan{})
+	}
+
+	e.lastBeam = beam
+	e.EndTime = t
+
+	s := &e.scans[len(e.scans)-1]
+	for c := 0; c < ChannelCount; c++ {
+		s[beam][c] = binary.BigEndian.Uint16(dat[12+c*2:])
+	}
+}
+
+// HasData reports if any scan was received.
+func (e Sounder) HasData() bool {
+	return len(e.scans) > 0
+}
+
+// GetDimensions of the channel images.
+func (e Sounder) GetDimensions() (int, int) {
+	return BeamPositions, len(e.scans)
+}
+
+// Export the 16-bit counts of the channel with a line per scan.
+func (e Sounder) Export(buf *[]byte, ch Channel) bool {
+	if !e.HasData() {
+		return false
+	}
+
+	*buf = make([]byte, BeamPositions*len(e.scans)*2)
+	for y, s := range e.scans {
+		for x := range s {
+			binary.BigEndian.PutUint16((*buf)[(y*BeamPositions+x)*2:], s[x][ch.Number-1])
+		}
+	}
+
+	return true
+}
+
+// GetFileName of the channel image.
+func (e Sounder) GetFileName(ch Channel, scft hrd.SpacecraftParameters) string {
+	return fmt.Sprintf("%s_%s_ATMS_%s_%s", scft.Filename, scft.SignalName, ch.ChannelName, e.StartTime.GetZuluSafe())
+}
@@ -0,0 +1,118 @@
+package atms
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+	"weatherdump/src/ccsds/frames"
+)
+
+// calibrationViews after the Earth view of each scan: the cold and warm views.
+const calibrationViews = 8
+
+// calibrationCount is never used by the synthetic Earth view counts.
+const calibrationCount = 0xFFFF
+
+// sciencePacket encodes a science packet of the beam position, one based like on
+// the instrument, with the counts of all channels.
+func sciencePacket(ms uint32, position int, counts [ChannelCount]uint16) frames.SpacePacketFrame {
+	dat := make([]byte, 6+packetSize)
+	dat[0] = 0x08 | uint8(ScienceAPID>>8)
+	dat[1] = uint8(ScienceAPID & 0xFF)
+	binary.BigEndian.PutUint16(dat[2:], 0xC000)
+	binary.BigEndian.PutUint16(dat[4:], packetSize-1)
+
+	body := dat[6:]
+	binary.BigEndian.PutUint16(body[0:], 21000)
+	binary.BigEndian.PutUint32(body[2:], ms)
+	binary.BigEndian.PutUint16(body[8:], uint16(position))
+	for c, count := range counts {
+		binary.BigEndian.PutUint16(body[12+c*2:], count)
+	}
+
+	var packet frames.SpacePacketFrame
+	packet.FromBinary(dat)
+	return packet
+}
+
+// earthCount of the channel at the beam position of the scan.
+func earthCount(scan, beam, c int) uint16 {
+	return uint16(scan*4096 + beam*ChannelCount + c)
+}
+
+func TestParse(t *testing.T) {
+	const scans = 2
+
+	sounder := New()
+	ms := uint32(0)
+	for s := 0; s < scans; s++ {
+		for beam := 0; beam < BeamPositions; beam++ {
+			var counts [ChannelCount]uint16
+			for c := range counts {
+				counts[c] = earthCount(s, beam, c)
+			}
+			sounder.Parse(sciencePacket(ms, beam+1, counts))
+			ms += 18
+		}
+
+		var counts [ChannelCount]uint16
+		for c := range counts {
+			counts[c] = calibrationCount
+		}
+		for v := 0; v < calibrationViews; v++ {
+			sounder.Parse(sciencePacket(ms, BeamPositions+1+v, counts))
+			ms += 18
+		}
+	}
+
+	if width, height := sounder.GetDimensions(); width != BeamPositions || height != scans {
+		t.Fatalf("dimensions %dx%d, want %dx%d", width, height, BeamPositions, scans)
+	}
+	lastEarth := time.Duration((BeamPositions+calibrationViews)*(scans-1)+BeamPositions-1) * 18 * time.Millisecond
+	if d := sounder.EndTime.GetDate().Sub(sounder.StartTime.GetDate()); d != lastEarth {
+		t.Errorf("scans last %s, want %s to the last Earth view", d, lastEarth)
+	}
+
+	for _, ch := range Channels {
+		var buf []byte
+		if !sounder.Export(&buf, ch) {
+			t.Fatalf("%s not exported", ch.ChannelName)
+		}
+		if len(buf) != BeamPositions*scans*2 {
+			t.Fatalf("%s: %d bytes, want %d", ch.ChannelName, len(buf), BeamPositions*scans*2)
+		}
+
+		for s := 0; s < scans; s++ {
+			for beam := 0; beam < BeamPositions; beam++ {
+				got := binary.BigEndian.Uint16(buf[(s*BeamPositions+beam)*2:])
+				if got == calibrationCount {
+					t.Fatalf("%s scan %d beam %d: calibration view exported", ch.ChannelName, s, beam)
+				}
+				if want := earthCount(s, beam, ch.Number-1); got != want {
+					t.Errorf("%s scan %d beam %d: count %d, want %d", ch.ChannelName, s, beam, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	var counts [ChannelCount]uint16
+
+	sounder := New()
+	sounder.Parse(sciencePacket(0, 0, counts))
+	sounder.Parse(sciencePacket(0, BeamPositions+1, counts))
+
+	truncated := sciencePacket(0, 1, counts)
+	truncated.FromBinary(truncated.ToBinary()[:6+packetSize-4])
+	sounder.Parse(truncated)
+
+	if sounder.HasData() {
+		t.Errorf("scan started by an invalid packet")
+	}
+
+	var buf []byte
+	if sounder.Export(&buf, Channels[ManifestBase+1]) {
+		t.Errorf("channel exported without data")
+	}
+}
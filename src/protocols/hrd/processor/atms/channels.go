@@ -0,0 +1,57 @@
+package atms
+
+import (
+	"weatherdump/src/protocols/helpers"
+)
+
+// Channel of the sounder. Warm scenes have higher counts,
+// so the images are inverted like the infrared channels.
+type Channel struct {
+	Number      int
+	ChannelName string
+	Frequency   string
+	Invert      bool
+}
+
+// List of channels by manifest key.
+type List map[uint16]Channel
+
+var Channels = List{
+	ManifestBase + 1:  {Number: 1, ChannelName: "CH01", Frequency: "23.8 GHz", Invert: true},
+	ManifestBase + 2:  {Number: 2, ChannelName: "CH02", Frequency: "31.4 GHz", Invert: true},
+	ManifestBase + 3:  {Number: 3, ChannelName: "CH03", Frequency: "50.3 GHz", Invert: true},
+	ManifestBase + 4:  {Number: 4, ChannelName: "CH04", Frequency: "51.76 GHz", Invert: true},
+	ManifestBase + 5:  {Number: 5, ChannelName: "CH05", Frequency: "52.8 GHz", Invert: true},
+	ManifestBase + 6:  {Number: 6, ChannelName: "CH06", Frequency: "53.596 GHz", Invert: true},
+	ManifestBase + 7:  {Number: 7, ChannelName: "CH07", Frequency: "54.4 GHz", Invert: true},
+	ManifestBase + 8:  {Number: 8, ChannelName: "CH08", Frequency: "54.94 GHz", Invert: true},
+	ManifestBase + 9:  {Number: 9, ChannelName: "CH09", Frequency: "55.5 GHz", Invert: true},
+	ManifestBase + 10: {Number: 10, ChannelName: "CH10", Frequency: "57.29 GHz", Invert: true},
+	ManifestBase + 11: {Number: 11, ChannelName: "CH11", Frequency: "57.29±0.217 GHz", Invert: true},
+	ManifestBase + 12: {Number: 12, ChannelName: "CH12", Frequency: "57.29±0.322±0.048 GHz", Invert: true},
+	ManifestBase + 13: {Number: 13, ChannelName: "CH13", Frequency: "57.29±0.322±0.022 GHz", Invert: true},
+	ManifestBase + 14: {Number: 14, ChannelName: "CH14", Frequency: "57.29±0.322±0.010 GHz", Invert: true},
+	ManifestBase + 15: {Number: 15, ChannelName: "CH15", Frequency: "57.29±0.322±0.0045 GHz", Invert: true},
+	ManifestBase + 16: {Number: 16, ChannelName: "CH16", Frequency: "88.2 GHz", Invert: true},
+	ManifestBase + 17: {Number: 17, ChannelName: "CH17", Frequency: "165.5 GHz", Invert: true},
+	ManifestBase + 18: {Number: 18, ChannelName: "CH18", Frequency: "183.31±7.0 GHz", Invert: true},
+	ManifestBase + 19: {Number: 19, ChannelName: "CH19", Frequency: "183.31±4.5 GHz", Invert: true},
+	ManifestBase + 20: {Number: 20, ChannelName: "CH20", Frequency: "183.31±3.0 GHz", Invert: true},
+	ManifestBase + 21: {Number: 21, ChannelName: "CH21", Frequency: "183.31±1.8 GHz", Invert: true},
+	ManifestBase + 22: {Number: 22, ChannelName: "CH22", Frequency: "183.31±1.0 GHz", Invert: true},
+}
+
+// Manifest of the sounder channels, merged into the VIIRS parser manifest.
+var Manifest = newManifest()
+
+func newManifest() helpers.ManifestList {
+	manifest := make(helpers.ManifestList)
+	for key, ch := range Channels {
+		manifest[key] = &helpers.Manifest{
+			Name:        "ATMS " + ch.ChannelName,
+			Description: "Microwave Sounder (" + ch.Frequency + ")",
+			Activated:   true,
+		}
+	}
+	return manifest
+}
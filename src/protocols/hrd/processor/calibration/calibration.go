@@ -0,0 +1,309 @@
+// Package calibration converts the VIIRS counts into physical units. Reflective
+// bands are calibrated to reflectance and emissive bands to brightness temperature.
+package calibration
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"time"
+	"weatherdump/src/ccsds/frames"
+	"weatherdump/src/geo"
+	"weatherdump/src/protocols/hrd/processor/parser/segment"
+)
+
+// Physical constants of the Planck function in SI units, exact in the CODATA 2018 values.
+const (
+	planck    = 6.62607015e-34
+	light     = 2.99792458e8
+	boltzmann = 1.380649e-23
+)
+
+// Fixed ranges of the calibrated images, values outside are clipped.
+var (
+	ReflectanceRange = [2]float64{0, 1.2}
+	TemperatureRange = [2]float64{180, 340}
+)
+
+type view struct {
+	sum   float64
+	count int
+}
+
+// views of each detector and gain of a band.
+type views [32][2][segment.CalibrationViews]view
+
+// Calibrator keeps the space and blackbody view counts of each band, detector
+// and gain received in the calibration packets.
+type Calibrator struct {
+	views map[uint8]*views
+}
+
+// New returns a calibrator without calibration views.
+func New() *Calibrator {
+	return &Calibrator{views: make(map[uint8]*views)}
+}
+
+// Parse a calibration packet (APID 825) and average the space and blackbody views
+// of its band and detector.
+func (e *Calibrator) Parse(packet frames.SpacePacketFrame) {
+	if packet.GetSequenceFlags() == 1 || !packet.IsValid() {
+		return
+	}
+
+	body := segment.NewBody(packet.GetData())
+	detector := body.GetDetectorNumber()
+	if detector >= 32 {
+		return
+	}
+
+	for _, band := range Bands {
+		if band.ID != body.GetBand() {
+			continue
+		}
+
+		if body.ProcessCalibration(band.Samples) > 0 {
+			return
+		}
+
+		v, ok := e.views[band.ID]
+		if !ok {
+			v = &views{}
+			e.views[band.ID] = v
+		}
+
+		for _, sector := range []int{segment.SpaceView, segment.BlackbodyView} {
+			data := *body.Detector[sector].GetData()
+			for i := 0; i+1 < len(data); i += 2 {
+				sample := binary.BigEndian.Uint16(data[i:])
+				if sample == 0 {
+					continue
+				}
+
+				gain := band.gain(sample)
+				v[detector][gain][sector].sum += float64(sample & segment.CountMask)
+				v[detector][gain][sector].count++
+			}
+		}
+		return
+	}
+}
+
+// mean returns the average counts of the view of each detector and gain, NaN without samples.
+func (e views) mean(sector int) [32][2]float64 {
+	var m [32][2]float64
+	for d := range e {
+		for g := range e[d] {
+			m[d][g] = math.NaN()
+			if v := e[d][g][sector]; v.count > 0 {
+				m[d][g] = v.sum / float64(v.count)
+			}
+		}
+	}
+	return m
+}
+
+// Calibrated values of a channel image and the radiance they were computed from,
+// in W/(m² sr µm). Pixels without data or calibration views are NaN in both.
+type Calibrated struct {
+	Values   []float32
+	Radiance []float32
+	Quantity Quantity
+}
+
+// Calibrate converts the 16-bit counts of a channel image of the spacecraft into reflectance
+// or brightness temperature. The rows of each scan are the detectors of the channel. The
+// emissive bands are calibrated between the space view and the blackbody view, the
+// reflective bands between the space view and the maximum radiance of the gain of each
+// pixel. The reflectance is divided by the cosine of the solar zenith angle of each pixel
+// of the swath, without it the radiance factor is returned. Pixels without the Sun above
+// the horizon are NaN.
+func (e Calibrator) Calibrate(scid uint8, name string, date time.Time, buf []byte, width, detectors int, swath *geo.Swath) (Calibrated, error) {
+	band, ok := Bands[name]
+	if !ok {
+		return Calibrated{}, fmt.Errorf("band %s has no calibration support", name)
+	}
+
+	scft, ok := Spacecrafts[scid]
+	if !ok {
+		return Calibrated{}, fmt.Errorf("spacecraft %d has no calibration coefficients", scid)
+	}
+	coefficients := scft.Bands[name]
+
+	v, ok := e.views[band.ID]
+	if !ok {
+		return Calibrated{}, fmt.Errorf("no calibration views of band %s were received", name)
+	}
+
+	space := v.mean(segment.SpaceView)
+	blackbody := v.mean(segment.BlackbodyView)
+
+	// The reflective bands use the space view of the other gain if one wasn't received.
+	// The emissive bands can't be calibrated without a blackbody view above the space view.
+	for d := range space {
+		for g := range space[d] {
+			if !band.Emissive && math.IsNaN(space[d][g]) {
+				space[d][g] = space[d][1-g]
+			}
+			if band.Emissive && !(blackbody[d][g] > space[d][g]) {
+				blackbody[d][g] = math.NaN()
+			}
+		}
+	}
+
+	temperature := coefficients.effectiveTemperature(scft.BlackbodyTemperature)
+	blackbodyRadiance := planckRadiance(band.Wavelength, temperature)
+	distance := geo.SunDistance(date)
+
+	out := Calibrated{
+		Values:   make([]float32, len(buf)/2),
+		Radiance: make([]float32, len(buf)/2),
+		Quantity: Temperature,
+	}
+	if !band.Emissive {
+		out.Quantity = RadianceFactor
+		if swath != nil {
+			out.Quantity = Reflectance
+		}
+	}
+
+	var zenith solarZenith
+
+	for i := range out.Values {
+		sample := binary.BigEndian.Uint16(buf[i*2:])
+		if sample == 0 {
+			out.Values[i] = float32(math.NaN())
+			out.Radiance[i] = float32(math.NaN())
+			continue
+		}
+
+		d, g := (i/width)%detectors, band.gain(sample)
+		dn := float64(sample&segment.CountMask) - space[d][g]
+
+		if band.Emissive {
+			radiance := blackbodyRadiance * dn / (blackbody[d][g] - space[d][g])
+			out.Radiance[i] = float32(radiance)
+			out.Values[i] = float32(coefficients.brightnessTemperature(radiance, band.Wavelength))
+		} else {
+			radiance := band.MaxRadiance[g] * dn / (maxCount - space[d][g])
+			out.Radiance[i] = float32(radiance)
+			out.Values[i] = float32(math.Pi * radiance * distance * distance / coefficients.SolarIrradiance)
+			if swath != nil {
+				out.Values[i] /= float32(zenith.cos(swath, i%width, i/width))
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// zenithBlock is the number of columns sharing the solar zenith angle of their center.
+const zenithBlock = 16
+
+// solarZenith caches the cosine of the solar zenith angle of the last block of columns.
+type solarZenith struct {
+	x, y  int
+	value float64
+	valid bool
+}
+
+// cos returns the cosine of the solar zenith angle of the pixel of the swath,
+// NaN if it can't be located or the Sun is below the horizon.
+func (e *solarZenith) cos(swath *geo.Swath, x, y int) float64 {
+	x = x / zenithBlock * zenithBlock
+	if e.valid && e.x == x && e.y == y {
+		return e.value
+	}
+	e.x, e.y, e.valid = x, y, true
+
+	center := x + zenithBlock/2
+	if center >= len(swath.Model) {
+		center = len(swath.Model) - 1
+	}
+
+	e.value = math.NaN()
+	if p, ok := swath.Locate(center, y); ok {
+		if c := math.Cos(geo.SolarZenith(p) * math.Pi / 180); c > 0 {
+			e.value = c
+		}
+	}
+	return e.value
+}
+
+// gain returns the index of the gain of the sample, one in the low gain of the dual gain bands.
+func (e Band) gain(sample uint16) int {
+	if e.DualGain && sample&segment.LowGainFlag != 0 {
+		return 1
+	}
+	return 0
+}
+
+// Quantity is the physical unit of the calibrated values.
+type Quantity string
+
+// Quantities of the calibrated values. Without the geolocation of the pixels the
+// reflective bands are calibrated to the radiance factor, the reflectance of a
+// surface lit by the Sun at the zenith.
+const (
+	Reflectance    Quantity = "REFLECTANCE"
+	RadianceFactor Quantity = "RADIANCE_FACTOR"
+	Temperature    Quantity = "TEMPERATURE"
+)
+
+// Range returns the fixed range of the calibrated images of the quantity.
+func (e Quantity) Range() [2]float64 {
+	if e == Temperature {
+		return TemperatureRange
+	}
+	return ReflectanceRange
+}
+
+// Scale the calibrated values into a 16-bit image with the fixed range of the quantity.
+func Scale(values []float32, quantity Quantity) []byte {
+	r := quantity.Range()
+	buf := make([]byte, len(values)*2)
+
+	for i, v := range values {
+		if math.IsNaN(float64(v)) {
+			continue
+		}
+
+		p := (float64(v) - r[0]) / (r[1] - r[0])
+		p = math.Max(0, math.Min(1, p))
+		binary.BigEndian.PutUint16(buf[i*2:], uint16(p*65535))
+	}
+
+	return buf
+}
+
+// Save the calibrated values as little-endian float32 samples.
+func Save(values []float32, path string) error {
+	buf := make([]byte, len(values)*4)
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(v))
+	}
+	return ioutil.WriteFile(path, buf, 0644)
+}
+
+// planckRadiance returns the radiance in W/(m² sr µm) of a blackbody at the temperature
+// in kelvin and the wavelength in micrometers.
+func planckRadiance(wavelength, temperature float64) float64 {
+	l := wavelength * 1e-6
+	c1 := 2 * planck * light * light / math.Pow(l, 5)
+	c2 := planck * light / (l * boltzmann)
+	return c1 / (math.Exp(c2/temperature) - 1) * 1e-6
+}
+
+// monochromaticTemperature inverts the Planck function at the wavelength.
+// The radiance is in W/(m² sr µm) and the wavelength in micrometers.
+func monochromaticTemperature(radiance, wavelength float64) float64 {
+	if radiance <= 0 {
+		return math.NaN()
+	}
+
+	l := wavelength * 1e-6
+	c1 := 2 * planck * light * light / math.Pow(l, 5)
+	c2 := planck * light / (l * boltzmann)
+	return c2 / math.Log(1+c1/(radiance*1e6))
+}
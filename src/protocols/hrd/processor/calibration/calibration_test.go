@@ -0,0 +1,113 @@
+package calibration
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+	"weatherdump/src/protocols/hrd/processor/parser/segment"
+)
+
+// npp is the SCID of Suomi NPP.
+const npp = 157
+
+func TestCalibrateEmissive(t *testing.T) {
+	e := New()
+	v := &views{}
+	for d := range v {
+		v[d][0][segment.SpaceView] = view{sum: 500, count: 1}
+		v[d][0][segment.BlackbodyView] = view{sum: 3500, count: 1}
+	}
+	e.views[Bands["M15"].ID] = v
+
+	buf := make([]byte, 4)
+	binary.BigEndian.PutUint16(buf[0:], 3500)
+	binary.BigEndian.PutUint16(buf[2:], 500+(3500-500)/2)
+
+	out, err := e.Calibrate(npp, "M15", time.Now(), buf, 2, 16, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scft := Spacecrafts[npp]
+	if math.Abs(float64(out.Values[0])-scft.BlackbodyTemperature) > 0.01 {
+		t.Errorf("blackbody counts calibrated to %f K, want %f K", out.Values[0], scft.BlackbodyTemperature)
+	}
+
+	c := scft.Bands["M15"]
+	radiance := planckRadiance(10.763, c.effectiveTemperature(scft.BlackbodyTemperature))
+	if math.Abs(float64(out.Radiance[0])/radiance-1) > 1e-6 {
+		t.Errorf("blackbody counts calibrated to %f W/(m² sr µm), want %f", out.Radiance[0], radiance)
+	}
+
+	half := c.brightnessTemperature(radiance/2, 10.763)
+	if math.Abs(float64(out.Values[1])-half) > 0.01 {
+		t.Errorf("half the blackbody radiance calibrated to %f K, want %f K", out.Values[1], half)
+	}
+
+	if _, err := e.Calibrate(0, "M15", time.Now(), buf, 2, 16, nil); err == nil {
+		t.Error("unknown spacecraft calibrated")
+	}
+}
+
+// TestBandCorrection compares the band corrected temperatures with the Planck function
+// averaged over the nominal bandpasses of the emissive bands in micrometers.
+func TestBandCorrection(t *testing.T) {
+	for name, bandpass := range map[string][2]float64{
+		"M12": {3.610, 3.790},
+		"M13": {3.973, 4.128},
+		"M14": {8.400, 8.700},
+		"M15": {10.263, 11.263},
+		"M16": {11.538, 12.488},
+		"I04": {3.550, 3.930},
+		"I05": {10.500, 12.400},
+	} {
+		band := Bands[name]
+		c := Spacecrafts[npp].Bands[name]
+
+		for temperature := 200.0; temperature <= 320; temperature += 40 {
+			const steps = 1000
+			var radiance float64
+			for i := 0; i < steps; i++ {
+				l := bandpass[0] + (bandpass[1]-bandpass[0])*(float64(i)+0.5)/steps
+				radiance += planckRadiance(l, temperature) / steps
+			}
+
+			if got := c.brightnessTemperature(radiance, band.Wavelength); math.Abs(got-temperature) > 0.15 {
+				t.Errorf("%s at %.0f K: band averaged radiance calibrated to %.3f K", name, temperature, got)
+			}
+		}
+	}
+}
+
+func TestCalibrateDualGain(t *testing.T) {
+	e := New()
+	v := &views{}
+	for d := range v {
+		v[d][0][segment.SpaceView] = view{sum: 300, count: 1}
+	}
+	e.views[Bands["M04"].ID] = v
+
+	buf := make([]byte, 6)
+	binary.BigEndian.PutUint16(buf[0:], maxCount)
+	binary.BigEndian.PutUint16(buf[2:], maxCount|segment.LowGainFlag)
+
+	out, err := e.Calibrate(npp, "M04", time.Date(2020, 1, 4, 0, 0, 0, 0, time.UTC), buf, 3, 16, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Saturated pixels of each gain reach the maximum radiance of the gain.
+	band := Bands["M04"]
+	for g, radiance := range band.MaxRadiance {
+		if math.Abs(float64(out.Radiance[g])/radiance-1) > 1e-6 {
+			t.Errorf("gain %d saturated at %f W/(m² sr µm), want %f", g, out.Radiance[g], radiance)
+		}
+	}
+	if ratio := out.Values[1] / out.Values[0]; math.Abs(float64(ratio)-band.MaxRadiance[1]/band.MaxRadiance[0]) > 1e-3 {
+		t.Errorf("low to high gain ratio %f, want %f", ratio, band.MaxRadiance[1]/band.MaxRadiance[0])
+	}
+	if !math.IsNaN(float64(out.Values[2])) || !math.IsNaN(float64(out.Radiance[2])) {
+		t.Errorf("pixel without data calibrated to %f and %f", out.Values[2], out.Radiance[2])
+	}
+}
@@ -0,0 +1,118 @@
+package calibration
+
+// Band describes the spectral response of a VIIRS band. The central wavelengths,
+// gain modes and maximum radiances are the ones of the VIIRS band specification
+// published by Cao et al. (2013), "Suomi NPP VIIRS sensor data record verification,
+// validation, and long-term performance monitoring", J. Geophys. Res. Atmos., 118.
+type Band struct {
+	ID          uint8      // band number inside the calibration packets
+	Emissive    bool       // thermal band calibrated to brightness temperature
+	DualGain    bool       // band digitized with the high or the low gain of each sample
+	Wavelength  float64    // central wavelength in micrometers
+	MaxRadiance [2]float64 // high and low gain Lmax of the reflective bands in W/(m² sr µm)
+	Samples     int        // samples of each calibration view
+}
+
+// Bands of the VIIRS imager with calibration support. The DNB needs the gain
+// stage of each pixel and is handled by its own composer.
+var Bands = map[string]Band{
+	"M01": {ID: 1, DualGain: true, Wavelength: 0.412, MaxRadiance: [2]float64{135, 615}, Samples: 48},
+	"M02": {ID: 2, DualGain: true, Wavelength: 0.445, MaxRadiance: [2]float64{127, 687}, Samples: 48},
+	"M03": {ID: 3, DualGain: true, Wavelength: 0.488, MaxRadiance: [2]float64{107, 702}, Samples: 48},
+	"M04": {ID: 4, DualGain: true, Wavelength: 0.555, MaxRadiance: [2]float64{78, 667}, Samples: 48},
+	"M05": {ID: 5, DualGain: true, Wavelength: 0.672, MaxRadiance: [2]float64{59, 651}, Samples: 48},
+	"M06": {ID: 6, Wavelength: 0.746, MaxRadiance: [2]float64{41.0}, Samples: 48},
+	"M07": {ID: 7, DualGain: true, Wavelength: 0.865, MaxRadiance: [2]float64{29, 349}, Samples: 48},
+	"M08": {ID: 8, Wavelength: 1.240, MaxRadiance: [2]float64{164.9}, Samples: 48},
+	"M09": {ID: 9, Wavelength: 1.378, MaxRadiance: [2]float64{77.1}, Samples: 48},
+	"M10": {ID: 10, Wavelength: 1.610, MaxRadiance: [2]float64{71.2}, Samples: 48},
+	"M11": {ID: 11, Wavelength: 2.250, MaxRadiance: [2]float64{31.8}, Samples: 48},
+	"M12": {ID: 12, Emissive: true, Wavelength: 3.700, Samples: 48},
+	"M13": {ID: 13, Emissive: true, DualGain: true, Wavelength: 4.050, Samples: 48},
+	"M14": {ID: 14, Emissive: true, Wavelength: 8.550, Samples: 48},
+	"M15": {ID: 15, Emissive: true, Wavelength: 10.763, Samples: 48},
+	"M16": {ID: 16, Emissive: true, Wavelength: 12.013, Samples: 48},
+	"I01": {ID: 17, Wavelength: 0.640, MaxRadiance: [2]float64{718}, Samples: 96},
+	"I02": {ID: 18, Wavelength: 0.865, MaxRadiance: [2]float64{349}, Samples: 96},
+	"I03": {ID: 19, Wavelength: 1.610, MaxRadiance: [2]float64{72.5}, Samples: 96},
+	"I04": {ID: 20, Emissive: true, Wavelength: 3.740, Samples: 96},
+	"I05": {ID: 21, Emissive: true, Wavelength: 11.450, Samples: 96},
+}
+
+// maxCount of the 14-bit digitizer. The reflective bands are calibrated nominally, with
+// the maximum radiance of the gain at the top of the counts above the space view. The
+// operational gains are the F-factors of the solar diffuser views in the SDR look-up
+// tables, which aren't bundled.
+const maxCount = 1<<14 - 1
+
+// Coefficients of a band of a spacecraft.
+type Coefficients struct {
+	// BandCorrection is the A and B of the effective temperature A + B·T of the
+	// monochromatic Planck function at the central wavelength of the emissive bands.
+	BandCorrection [2]float64
+
+	// SolarIrradiance is the irradiance of the Sun at one astronomical unit averaged
+	// over the reflective bands in W/(m² µm).
+	SolarIrradiance float64
+}
+
+// Spacecraft holds the coefficients of the VIIRS bands of a spacecraft.
+type Spacecraft struct {
+	// BlackbodyTemperature in kelvin the on-board blackbody is controlled at.
+	BlackbodyTemperature float64
+	Bands                map[string]Coefficients
+}
+
+// Spacecrafts with calibration support by their SCID.
+//
+// The band corrections are least squares fits between 180 and 340 K of the Planck
+// function averaged over the nominal bandpasses of the specification published by
+// Cao et al. (2013), which are the same for both instruments. The solar irradiances
+// are approximate averages of the Thuillier et al. (2003) spectrum over the same
+// bandpasses. The measured spectral responses of each instrument shift them slightly
+// and aren't bundled.
+//
+// The blackbody temperature of Suomi NPP is the one published by Xiong et al. (2014),
+// "VIIRS on-orbit calibration methodology and performance", J. Geophys. Res. Atmos.,
+// 119, and the same set point is used for NOAA-20. The thermistors of the blackbody
+// aren't decoded, their telemetry layout and conversion coefficients aren't bundled.
+var Spacecrafts = map[uint8]Spacecraft{
+	157: {BlackbodyTemperature: 292.5, Bands: nominalBands},
+	159: {BlackbodyTemperature: 292.5, Bands: nominalBands},
+}
+
+var nominalBands = map[string]Coefficients{
+	"M01": {SolarIrradiance: 1720},
+	"M02": {SolarIrradiance: 1880},
+	"M03": {SolarIrradiance: 1980},
+	"M04": {SolarIrradiance: 1860},
+	"M05": {SolarIrradiance: 1515},
+	"M06": {SolarIrradiance: 1275},
+	"M07": {SolarIrradiance: 965},
+	"M08": {SolarIrradiance: 460},
+	"M09": {SolarIrradiance: 365},
+	"M10": {SolarIrradiance: 245},
+	"M11": {SolarIrradiance: 78},
+	"M12": {BandCorrection: [2]float64{0.3312, 0.999220}},
+	"M13": {BandCorrection: [2]float64{0.1937, 0.999571}},
+	"M14": {BandCorrection: [2]float64{0.0259, 0.999863}},
+	"M15": {BandCorrection: [2]float64{-0.0474, 0.999889}},
+	"M16": {BandCorrection: [2]float64{-0.1098, 1.000251}},
+	"I01": {SolarIrradiance: 1610},
+	"I02": {SolarIrradiance: 965},
+	"I03": {SolarIrradiance: 245},
+	"I04": {BandCorrection: [2]float64{1.3911, 0.996716}},
+	"I05": {BandCorrection: [2]float64{-0.3337, 1.000428}},
+}
+
+// effectiveTemperature returns the temperature of the monochromatic Planck function
+// with the radiance of the band at the temperature in kelvin.
+func (e Coefficients) effectiveTemperature(temperature float64) float64 {
+	return e.BandCorrection[0] + e.BandCorrection[1]*temperature
+}
+
+// brightnessTemperature returns the temperature of the band with the radiance
+// in W/(m² sr µm), NaN without radiance.
+func (e Coefficients) brightnessTemperature(radiance, wavelength float64) float64 {
+	return (monochromaticTemperature(radiance, wavelength) - e.BandCorrection[0]) / e.BandCorrection[1]
+}
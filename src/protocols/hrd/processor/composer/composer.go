@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"sort"
+	"weatherdump/src/geo"
 	"weatherdump/src/img"
 	"weatherdump/src/protocols/hrd"
 	"weatherdump/src/protocols/hrd/processor/parser"
@@ -15,6 +16,8 @@ type Composer struct {
 	ShortName        string
 	FileName         string
 	RequiredChannels []uint16
+	render           func(Composer, parser.List, string) string
+	georeference     func(*parser.Channel) *geo.Swath
 }
 
 func (e *Composer) Register(pipeline img.Pipeline, scft hrd.SpacecraftParameters) *Composer {
@@ -23,7 +26,25 @@ func (e *Composer) Register(pipeline img.Pipeline, scft hrd.SpacecraftParameters
 	return e
 }
 
+// Georeference sets the function returning the swath of a processed channel.
+func (e *Composer) Georeference(georeference func(*parser.Channel) *geo.Swath) *Composer {
+	e.georeference = georeference
+	return e
+}
+
+// getSwath returns the swath of the channel, or nil without the function.
+func (e Composer) getSwath(ch *parser.Channel) *geo.Swath {
+	if e.georeference == nil {
+		return nil
+	}
+	return e.georeference(ch)
+}
+
 func (e Composer) Render(ch parser.List, outputFolder string) string {
+	if e.render != nil {
+		return e.render(e, ch, outputFolder)
+	}
+
 	ch01 := ch[e.RequiredChannels[0]]
 	ch02 := ch[e.RequiredChannels[1]]
 	ch03 := ch[e.RequiredChannels[2]]
@@ -62,26 +83,29 @@ func (e Composer) Render(ch parser.List, outputFolder string) string {
 		finalBuf[p+1] = 0xFF
 	}
 
-	// Compose images and fill buffer.
+	// Compose images and fill buffer. The composite is oriented
+	// after it's composed, with its georeference.
 	var buf []byte
 	e.pipeline.Target(img.NewGray16(&buf, w, h))
 	e.pipeline.AddException("Invert", false)
+	e.pipeline.AddException("Flop", false)
+	e.pipeline.AddException("Rotate", false)
 
-	ch01.Export(&buf, ch, e.scft)
+	ch01.Export(&buf, ch, e.scft, e.getSwath(ch01))
 	e.pipeline.Process()
 	for p := 2; p < len(finalBuf); p += 8 {
 		finalBuf[p+0] = buf[(p/4)+0]
 		finalBuf[p+1] = buf[(p/4)+1]
 	}
 
-	ch02.Export(&buf, ch, e.scft)
+	ch02.Export(&buf, ch, e.scft, e.getSwath(ch02))
 	e.pipeline.Process()
 	for p := 0; p < len(finalBuf); p += 8 {
 		finalBuf[p+0] = buf[(p/4)+0]
 		finalBuf[p+1] = buf[(p/4)+1]
 	}
 
-	ch03.Export(&buf, ch, e.scft)
+	ch03.Export(&buf, ch, e.scft, e.getSwath(ch03))
 	e.pipeline.Process()
 	for p := 4; p < len(finalBuf); p += 8 {
 		finalBuf[p+0] = buf[(p/4)-1]
@@ -89,8 +113,8 @@ func (e Composer) Render(ch parser.List, outputFolder string) string {
 	}
 
 	// Render and save the true-color image.
-	e.pipeline.Target(img.NewRGBA64(&finalBuf, w, h)).Export(outputName, 100)
 	e.pipeline.ResetExceptions()
+	e.pipeline.Target(img.NewRGBA64(&finalBuf, w, h).Georeference(e.getSwath(ch01))).Process().Export(outputName, 100)
 	return outputName
 }
 
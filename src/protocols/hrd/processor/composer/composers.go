@@ -19,6 +19,11 @@ var Composers = List{
 		FileName:         "NAT_COLOR_M_CH",
 		RequiredChannels: []uint16{808, 806, 801},
 	},
+	003: {
+		FileName:         "DNB_MERGED",
+		RequiredChannels: []uint16{821, 822, 823},
+		render:           renderDNB,
+	},
 }
 
 var Manifest = helpers.ManifestList{
@@ -37,4 +42,9 @@ var Manifest = helpers.ManifestList{
 		Description: "Moderate Natural Composite",
 		Activated:   true,
 	},
+	003: {
+		Name:        "Day/Night Band",
+		Description: "Merged Gain Stages Radiance",
+		Activated:   true,
+	},
 }
@@ -0,0 +1,144 @@
+package composer
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"weatherdump/src/img"
+	"weatherdump/src/protocols/hrd/processor/parser"
+)
+
+// dnbStage is a gain stage of the Day/Night Band with its nominal offset and
+// radiance in W/(cm² sr) per count. They are the same for every detector and
+// aggregation mode, so the merged image is a relative signal: the stages line up,
+// but the values aren't a calibrated radiance. The per-detector offsets and gains
+// of the operational calibration need the DNB calibration views and look-up
+// tables, which aren't decoded.
+type dnbStage struct {
+	apid   uint16
+	offset float64
+	gain   float64
+}
+
+// dnbStages from the most to the least sensitive. The medium and high gain
+// stages are about 119 and 56000 times more sensitive than the low gain.
+var dnbStages = []dnbStage{
+	{apid: 821, offset: 350, gain: 2.64e-11},
+	{apid: 822, offset: 250, gain: 1.26e-8},
+	{apid: 823, offset: 200, gain: 1.50e-6},
+}
+
+// dnbSaturation is the count where a gain stage is replaced by the next one.
+const dnbSaturation = 15000
+
+// Percentiles of the log signal mapped to black and white.
+const (
+	dnbLowPercentile  = 0.005
+	dnbHighPercentile = 0.999
+)
+
+// renderDNB merges the gain stages of the Day/Night Band into a single image of
+// relative signal. Each pixel uses the most sensitive stage that isn't saturated.
+// The logarithm of the signal is stretched between percentiles of the scene,
+// so city lights, moonlit clouds and daylight scenes are equally visible.
+func renderDNB(e Composer, ch parser.List, outputFolder string) string {
+	var stages []dnbStage
+	var channels []*parser.Channel
+	for _, s := range dnbStages {
+		if ch[s.apid].HasData {
+			stages = append(stages, s)
+			channels = append(channels, ch[s.apid])
+		}
+	}
+
+	if len(channels) == 0 {
+		fmt.Println("[COM] Can't export component channel. No DNB gain stage is available.")
+		return ""
+	}
+
+	outputName, _ := filepath.Abs(fmt.Sprintf("%s/%s_%s_COMP_%s_VIIRS_%s",
+		outputFolder, e.scft.Filename, e.scft.SignalName, e.FileName, channels[0].StartTime.GetZuluSafe()))
+
+	// Synchronize all gain stages scans.
+	firstScan := make([]int, len(channels))
+	lastScan := make([]int, len(channels))
+	for i, c := range channels {
+		firstScan[i], lastScan[i] = c.GetBounds()
+	}
+
+	first, last := MaxIntSlice(firstScan), MinIntSlice(lastScan)
+	for _, c := range channels {
+		c.SetBounds(first, last)
+		c.Process(e.scft)
+	}
+
+	// Merge the gain stages into the relative signal.
+	w, h := channels[0].GetDimensions()
+	signal := make([]float32, w*h)
+	for i := range signal {
+		signal[i] = float32(math.NaN())
+	}
+
+	for i, c := range channels {
+		// The DNB has no bow-tie deletion, see RemoveBowTie.
+		var buf []byte
+		c.ExportCounts(&buf, ch, e.scft)
+
+		for p := range signal {
+			if !math.IsNaN(float64(signal[p])) || p*2+1 >= len(buf) {
+				continue
+			}
+
+			count := float64(binary.BigEndian.Uint16(buf[p*2:]))
+			if count == 0 || (count >= dnbSaturation && i < len(channels)-1) {
+				continue
+			}
+
+			signal[p] = float32(math.Max(count-stages[i].offset, 1) * stages[i].gain)
+		}
+	}
+
+	// Render and save the tone mapped image.
+	finalBuf := toneMapDNB(signal)
+	e.pipeline.AddException("Equalize", false)
+	e.pipeline.AddException("Invert", false)
+	e.pipeline.Target(img.NewGray16(&finalBuf, w, h).Georeference(e.getSwath(channels[0]))).Process().Export(outputName, 100)
+	e.pipeline.ResetExceptions()
+	return outputName
+}
+
+// toneMapDNB stretches the logarithm of the signal into a 16-bit image.
+func toneMapDNB(signal []float32) []byte {
+	var logs []float64
+	for _, r := range signal {
+		if !math.IsNaN(float64(r)) {
+			logs = append(logs, math.Log10(float64(r)))
+		}
+	}
+
+	buf := make([]byte, len(signal)*2)
+	if len(logs) == 0 {
+		return buf
+	}
+
+	sort.Float64s(logs)
+	low := logs[int(float64(len(logs)-1)*dnbLowPercentile)]
+	high := logs[int(float64(len(logs)-1)*dnbHighPercentile)]
+	if high <= low {
+		high = low + 1
+	}
+
+	for i, r := range signal {
+		if math.IsNaN(float64(r)) {
+			continue
+		}
+
+		p := (math.Log10(float64(r)) - low) / (high - low)
+		p = math.Max(0, math.Min(1, p))
+		binary.BigEndian.PutUint16(buf[i*2:], uint16(p*65535))
+	}
+
+	return buf
+}
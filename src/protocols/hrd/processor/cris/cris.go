@@ -0,0 +1,204 @@
+// Package cris reassembles the interferograms of the Cross-track Infrared Sounder
+// for each field of regard (FOR) and field of view (FOV).
+//
+// The 81 science APIDs from 1315 are allocated by scene, then by band (LW, MW and
+// SW), then by FOV: 1315 to 1341 are the Earth scene of the three bands, 1342 to
+// 1368 the deep space views and 1369 to 1395 the internal calibration target views,
+// each band having 9 consecutive APIDs for the FOVs 1 to 9. An interferogram is a
+// segmented packet whose first segment carries the 8 bytes timestamp, the FOR, the
+// sweep direction flags, the number of complex samples and the first samples. The
+// other segments carry the remaining samples, as big-endian 16-bit real and
+// imaginary parts.
+//
+// The interferograms of each band are saved in a binary file starting with a 16
+// bytes header: the "CRIS" magic, the layout version (uint16), the band index
+// (uint16) and the first and last wavenumbers of the band in cm⁻¹ (float32). Each
+// record has a 20 bytes header: the timestamp of the packet, the scan number
+// (uint32), the FOR, the FOV (1 to 9), the scene (0 Earth, 1 deep space, 2 internal
+// calibration target), the sweep direction and the number of samples of the record
+// (uint32), followed by the complex samples as pairs of int16. The number of samples
+// is the one of the packet, so it follows the resolution mode of the instrument.
+// All fields are big-endian.
+//
+// The optional spectra files have the same layout, the samples are the float32
+// magnitudes of the uncalibrated spectrum of each interferogram. Their record
+// headers are 28 bytes long, with the wavenumber of the first bin and the spacing
+// of the bins in cm⁻¹ (float32) after the number of samples, so the bin k has the
+// wavenumber first+spacing*k. The axis comes from the sampling of the interferograms,
+// see Band.FreeSpectralRange.
+package cris
+
+import (
+	"encoding/binary"
+	"math"
+	"weatherdump/src/ccsds"
+	"weatherdump/src/ccsds/frames"
+	"weatherdump/src/protocols/hrd"
+)
+
+// FirstAPID of the CrIS science packets.
+const FirstAPID = 1315
+
+// LastAPID of the CrIS science packets.
+const LastAPID = FirstAPID + bandCount*scenes*FOVs - 1
+
+// FOVs of each field of regard, in a 3x3 grid.
+const FOVs = 9
+
+// EarthFORs of each scan.
+const EarthFORs = 30
+
+// Scenes of the CrIS science packets.
+const (
+	EarthScene = iota
+	DeepSpace
+	InternalTarget
+	scenes
+)
+
+const (
+	bandCount     = 3
+	layoutVersion = 2
+	fileHeader    = 16
+	recordHeader  = 20
+	firstHeader   = 12
+)
+
+// laserWavelength of the metrology laser sampling the interferograms in cm.
+const laserWavelength = 1550e-7
+
+// Band of the interferometer with its wavenumber range in cm⁻¹ and the decimation
+// factor of its interferograms.
+type Band struct {
+	Name        string
+	Wavenumbers [2]float64
+	Decimation  int
+}
+
+// Bands of the interferometer by index. The decimation factors and the laser wavelength
+// are the ones published by Han et al. (2013), "Suomi NPP CrIS measurements, sensor data
+// record algorithm, calibration and validation activities, and record data quality",
+// J. Geophys. Res. Atmos., 118.
+var Bands = [bandCount]Band{
+	{Name: "LW", Wavenumbers: [2]float64{650, 1095}, Decimation: 24},
+	{Name: "MW", Wavenumbers: [2]float64{1210, 1750}, Decimation: 20},
+	{Name: "SW", Wavenumbers: [2]float64{2155, 2550}, Decimation: 26},
+}
+
+// FreeSpectralRange returns the width in cm⁻¹ of the spectrum of the decimated
+// interferograms. They're sampled at each half wavelength of the metrology laser
+// before the decimation, and the band is inside the alias of the range centered
+// on it.
+func (e Band) FreeSpectralRange() float64 {
+	return 2 / (laserWavelength * float64(e.Decimation))
+}
+
+// axis returns the FFT bin of the band with the lowest wavenumber, the wavenumber
+// and the spacing in cm⁻¹ of the bins of a spectrum with n samples.
+func (e Band) axis(n int) (int, float64, float64) {
+	spacing := e.FreeSpectralRange() / float64(n)
+	center := (e.Wavenumbers[0] + e.Wavenumbers[1]) / 2
+	first := int(math.Ceil((center - e.FreeSpectralRange()/2) / spacing))
+	return first, float64(first) * spacing, spacing
+}
+
+type record struct {
+	band    int
+	scene   uint8
+	fov     uint8
+	fieldOR uint8
+	sweep   uint8
+	scan    uint32
+	count   int
+	stamp   [8]byte
+	time    hrd.Time
+	samples []byte
+}
+
+func newRecord(apid uint16) *record {
+	index := int(apid - FirstAPID)
+	return &record{
+		band:  index / FOVs % bandCount,
+		scene: uint8(index / (bandCount * FOVs)),
+		fov:   uint8(index%FOVs + 1),
+	}
+}
+
+// complexSamples returns the samples of the interferogram, padded to the number of samples of the packet.
+func (e record) complexSamples() []complex64 {
+	res := make([]complex64, e.count)
+	for i := range res {
+		if i*4+3 >= len(e.samples) {
+			break
+		}
+		re := int16(binary.BigEndian.Uint16(e.samples[i*4:]))
+		im := int16(binary.BigEndian.Uint16(e.samples[i*4+2:]))
+		res[i] = complex(float32(re), float32(im))
+	}
+	return res
+}
+
+func (e record) header() []byte {
+	buf := make([]byte, recordHeader)
+	copy(buf, e.stamp[:])
+	binary.BigEndian.PutUint32(buf[8:], e.scan)
+	buf[12] = e.fieldOR
+	buf[13] = e.fov
+	buf[14] = e.scene
+	buf[15] = e.sweep
+	binary.BigEndian.PutUint32(buf[16:], uint32(e.count))
+	return buf
+}
+
+type assembler struct {
+	counter ccsds.PacketCounter
+	pending *record
+}
+
+func bandHeader(band int) []byte {
+	buf := make([]byte, fileHeader)
+	copy(buf, "CRIS")
+	binary.BigEndian.PutUint16(buf[4:], layoutVersion)
+	binary.BigEndian.PutUint16(buf[6:], uint16(band))
+	binary.BigEndian.PutUint32(buf[8:], math.Float32bits(float32(Bands[band].Wavenumbers[0])))
+	binary.BigEndian.PutUint32(buf[12:], math.Float32bits(float32(Bands[band].Wavenumbers[1])))
+	return buf
+}
+
+// reassemble the segments of an interferogram, returning it when the last one is received.
+// Sequence count gaps drop the interferogram being assembled.
+func (e *assembler) reassemble(packet frames.SpacePacketFrame) *record {
+	if e.counter.Next(packet.GetSequenceCount()) > 0 {
+		e.pending = nil
+	}
+
+	dat := packet.GetData()
+	flags := packet.GetSequenceFlags()
+
+	if flags == 1 || flags == 3 {
+		if len(dat) < firstHeader {
+			e.pending = nil
+			return nil
+		}
+
+		r := newRecord(packet.GetAPID())
+		copy(r.stamp[:], dat[0:8])
+		r.time.FromBinary(dat[0:8])
+		r.fieldOR = dat[8]
+		r.sweep = dat[9] & 0x01
+		r.count = int(binary.BigEndian.Uint16(dat[10:]))
+		r.samples = make([]byte, 0, r.count*4)
+		r.samples = append(r.samples, dat[firstHeader:]...)
+		e.pending = r
+	} else if e.pending != nil {
+		e.pending.samples = append(e.pending.samples, dat...)
+	}
+
+	if (flags == 2 || flags == 3) && e.pending != nil {
+		r := e.pending
+		e.pending = nil
+		return r
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package cris
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+// TestSpectrumAxis checks the wavenumber of the peak of the spectrum of the interferogram
+// of a single line, sampled every decimated half wavelength of the laser.
+func TestSpectrumAxis(t *testing.T) {
+	for _, tc := range []struct {
+		band       int
+		wavenumber float64
+		samples    int
+	}{
+		{0, 900, 866},
+		{1, 1500, 1052},
+		{1, 1500, 530},
+		{2, 2300, 799},
+	} {
+		band := Bands[tc.band]
+		step := laserWavelength / 2 * float64(band.Decimation)
+
+		samples := make([]complex64, tc.samples)
+		for m := range samples {
+			samples[m] = complex64(cmplx.Exp(complex(0, 2*math.Pi*tc.wavenumber*step*float64(m))))
+		}
+
+		magnitudes, first, spacing := spectrum(samples, band)
+		peak := 0
+		for k, m := range magnitudes {
+			if m > magnitudes[peak] {
+				peak = k
+			}
+		}
+
+		if wn := first + spacing*float64(peak); math.Abs(wn-tc.wavenumber) > spacing {
+			t.Errorf("%s with %d samples: peak at %f cm⁻¹, want %f cm⁻¹", band.Name, tc.samples, wn, tc.wavenumber)
+		}
+		if first > band.Wavenumbers[0] || first+spacing*float64(len(magnitudes)) < band.Wavenumbers[1] {
+			t.Errorf("%s with %d samples: axis from %f cm⁻¹ doesn't cover the band", band.Name, tc.samples, first)
+		}
+	}
+}
+
+// TestNewRecord pins the APIDs at the edges of each scene and band.
+func TestNewRecord(t *testing.T) {
+	for _, tc := range []struct {
+		apid  uint16
+		band  int
+		scene uint8
+		fov   uint8
+	}{
+		{1315, 0, EarthScene, 1},
+		{1323, 0, EarthScene, 9},
+		{1324, 1, EarthScene, 1},
+		{1333, 2, EarthScene, 1},
+		{1341, 2, EarthScene, 9},
+		{1342, 0, DeepSpace, 1},
+		{1355, 1, DeepSpace, 5},
+		{1368, 2, DeepSpace, 9},
+		{1369, 0, InternalTarget, 1},
+		{1395, 2, InternalTarget, 9},
+	} {
+		r := newRecord(tc.apid)
+		if r.band != tc.band || r.scene != tc.scene || r.fov != tc.fov {
+			t.Errorf("APID %d: band %d, scene %d, FOV %d, want band %d, scene %d, FOV %d",
+				tc.apid, r.band, r.scene, r.fov, tc.band, tc.scene, tc.fov)
+		}
+	}
+
+	if LastAPID != 1395 {
+		t.Errorf("last APID %d, want 1395", LastAPID)
+	}
+}
@@ -0,0 +1,283 @@
+package cris
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"weatherdump/src/ccsds/frames"
+	"weatherdump/src/dsp"
+	"weatherdump/src/protocols/hrd"
+)
+
+// ImageWidth of the brightness image, the 3 FOV columns of each Earth FOR.
+const ImageWidth = EarthFORs * 3
+
+type bandFiles struct {
+	interferograms *os.File
+	spectra        *os.File
+}
+
+// Interferometer saves the interferograms of all bands inside the output path
+// while the packets are received. With a wavenumber range, it also saves the
+// spectra and keeps the mean magnitude of the range of each Earth scene FOV.
+type Interferometer struct {
+	path       string
+	spectrum   []float64
+	assemblers map[uint16]*assembler
+	files      [bandCount]*bandFiles
+	scan       uint32
+	lastFOR    int
+	brightness [][ImageWidth]float32
+	records    int
+	StartTime  hrd.Time
+	HasData    bool
+}
+
+// NewInterferometer saves the interferograms inside the output path. The spectrum is
+// the wavenumber range in cm⁻¹ of the brightness image, nil disables the spectra.
+func NewInterferometer(path string, spectrum []float64) *Interferometer {
+	return &Interferometer{
+		path:       path,
+		spectrum:   spectrum,
+		assemblers: make(map[uint16]*assembler),
+	}
+}
+
+// Parse a CrIS science packet, saving the interferogram once it's reassembled.
+func (e *Interferometer) Parse(packet frames.SpacePacketFrame) error {
+	apid := packet.GetAPID()
+	if apid < FirstAPID || apid > LastAPID {
+		return nil
+	}
+
+	a, ok := e.assemblers[apid]
+	if !ok {
+		a = &assembler{}
+		e.assemblers[apid] = a
+	}
+
+	r := a.reassemble(packet)
+	if r == nil {
+		return nil
+	}
+
+	if r.scene == EarthScene {
+		if int(r.fieldOR) < e.lastFOR {
+			e.scan++
+		}
+		e.lastFOR = int(r.fieldOR)
+	}
+	r.scan = e.scan
+
+	if !e.HasData {
+		e.StartTime = r.time
+		e.HasData = true
+	}
+
+	return e.write(r)
+}
+
+func (e *Interferometer) write(r *record) error {
+	files, err := e.open(r.band)
+	if err != nil {
+		return err
+	}
+
+	samples := r.complexSamples()
+	buf := r.header()
+	for _, s := range samples {
+		buf = append(buf, 0, 0, 0, 0)
+		binary.BigEndian.PutUint16(buf[len(buf)-4:], uint16(int16(real(s))))
+		binary.BigEndian.PutUint16(buf[len(buf)-2:], uint16(int16(imag(s))))
+	}
+
+	if _, err := files.interferograms.Write(buf); err != nil {
+		return err
+	}
+	e.records++
+
+	if files.spectra == nil {
+		return nil
+	}
+
+	magnitudes, first, spacing := spectrum(samples, Bands[r.band])
+	buf = append(r.header(), 0, 0, 0, 0, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(buf[16:], uint32(len(magnitudes)))
+	binary.BigEndian.PutUint32(buf[20:], math.Float32bits(float32(first)))
+	binary.BigEndian.PutUint32(buf[24:], math.Float32bits(float32(spacing)))
+	for _, m := range magnitudes {
+		buf = append(buf, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(buf[len(buf)-4:], math.Float32bits(m))
+	}
+
+	if _, err := files.spectra.Write(buf); err != nil {
+		return err
+	}
+
+	if r.scene == EarthScene {
+		e.addBrightness(r, magnitudes, first, spacing)
+	}
+	return nil
+}
+
+func (e *Interferometer) open(band int) (*bandFiles, error) {
+	if e.files[band] != nil {
+		return e.files[band], nil
+	}
+
+	files := &bandFiles{}
+	var err error
+
+	files.interferograms, err = e.create(band, "INTERFEROGRAMS")
+	if err != nil {
+		return nil, err
+	}
+
+	if e.spectrum != nil {
+		files.spectra, err = e.create(band, "SPECTRA")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	e.files[band] = files
+	return files, nil
+}
+
+func (e Interferometer) create(band int, kind string) (*os.File, error) {
+	file, err := os.Create(e.tempName(band, kind))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := file.Write(bandHeader(band)); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+func (e Interferometer) tempName(band int, kind string) string {
+	return filepath.Join(e.path, fmt.Sprintf(".cris_%s_%s.tmp", Bands[band].Name, kind))
+}
+
+// addBrightness stores the mean magnitude of the spectrum inside the wavenumber
+// range at the position of the FOV. FOVs of bands outside the range are ignored.
+func (e *Interferometer) addBrightness(r *record, magnitudes []float32, first, spacing float64) {
+	var sum float64
+	var count int
+	for k, m := range magnitudes {
+		wn := first + spacing*float64(k)
+		if wn >= e.spectrum[0] && wn <= e.spectrum[1] {
+			sum += float64(m)
+			count++
+		}
+	}
+
+	if count == 0 || r.fieldOR < 1 || int(r.fieldOR) > EarthFORs {
+		return
+	}
+
+	for len(e.brightness) < int(r.scan+1)*3 {
+		var row [ImageWidth]float32
+		for i := range row {
+			row[i] = float32(math.NaN())
+		}
+		e.brightness = append(e.brightness, row)
+	}
+
+	fov := int(r.fov - 1)
+	x := (int(r.fieldOR)-1)*3 + fov%3
+	y := int(r.scan)*3 + fov/3
+	e.brightness[y][x] = float32(sum / float64(count))
+}
+
+// Close the files, naming them after the spacecraft and the first interferogram.
+// It returns the names of the files saved.
+func (e *Interferometer) Close(scft hrd.SpacecraftParameters) ([]string, error) {
+	var names []string
+	for band, files := range e.files {
+		if files == nil {
+			continue
+		}
+
+		kinds := []string{"INTERFEROGRAMS", "SPECTRA"}
+		for i, file := range []*os.File{files.interferograms, files.spectra} {
+			if file == nil {
+				continue
+			}
+
+			kind := kinds[i]
+			if err := file.Close(); err != nil {
+				return names, err
+			}
+
+			name := filepath.Join(e.path, fmt.Sprintf("%s_%s_CRIS_%s_%s_%s.bin",
+				scft.Filename, scft.SignalName, Bands[band].Name, kind, e.StartTime.GetZuluSafe()))
+			if err := os.Rename(e.tempName(band, kind), name); err != nil {
+				return names, err
+			}
+			names = append(names, name)
+		}
+	}
+
+	e.files = [bandCount]*bandFiles{}
+	return names, nil
+}
+
+// GetRecordCount returns the number of interferograms saved.
+func (e Interferometer) GetRecordCount() int {
+	return e.records
+}
+
+// ExportBrightness returns the brightness image of the wavenumber range as 16-bit
+// pixels, stretched between the minimum and maximum magnitudes of the pass.
+func (e Interferometer) ExportBrightness(buf *[]byte) (int, int, bool) {
+	if len(e.brightness) == 0 {
+		return 0, 0, false
+	}
+
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, row := range e.brightness {
+		for _, v := range row {
+			if !math.IsNaN(float64(v)) {
+				low = math.Min(low, float64(v))
+				high = math.Max(high, float64(v))
+			}
+		}
+	}
+
+	if high <= low {
+		high = low + 1
+	}
+
+	*buf = make([]byte, len(e.brightness)*ImageWidth*2)
+	for y, row := range e.brightness {
+		for x, v := range row {
+			if math.IsNaN(float64(v)) {
+				continue
+			}
+			p := (float64(v) - low) / (high - low)
+			binary.BigEndian.PutUint16((*buf)[(y*ImageWidth+x)*2:], uint16(p*65535))
+		}
+	}
+
+	return ImageWidth, len(e.brightness), true
+}
+
+// spectrum returns the magnitudes of the interferogram spectrum of the band with increasing
+// wavenumbers, the wavenumber of the first bin and the spacing of the bins in cm⁻¹.
+func spectrum(samples []complex64, band Band) ([]float32, float64, float64) {
+	bins := dsp.FFT(samples)
+	n := len(bins)
+	offset, first, spacing := band.axis(n)
+
+	res := make([]float32, n)
+	for k := range res {
+		v := bins[((offset+k)%n+n)%n]
+		res[k] = float32(math.Hypot(float64(real(v)), float64(imag(v))))
+	}
+	return res, first, spacing
+}
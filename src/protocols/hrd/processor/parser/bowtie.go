@@ -0,0 +1,246 @@
+package parser
+
+import (
+	"encoding/binary"
+	"fmt"
+	"weatherdump/src/geo"
+	"weatherdump/src/img"
+)
+
+// Bow-tie modes of the exported VIIRS images.
+const (
+	BowTieNone = iota
+	BowTieFill
+	BowTieCorrect
+)
+
+// SetBowTie selects the bow-tie mode of all channels from the pipeline.
+// The FillBowTie task fills the rows deleted on-board, the CorrectBowTie task
+// resamples the detectors of each scan on the ground and takes precedence over it.
+func (e List) SetBowTie(wf img.Pipeline) {
+	mode := BowTieNone
+	if wf.Has("FillBowTie") {
+		mode = BowTieFill
+	}
+	if wf.Has("CorrectBowTie") {
+		mode = BowTieCorrect
+	}
+
+	for _, ch := range e {
+		ch.BowTieMode = mode
+	}
+}
+
+// RemoveBowTie applies the bow-tie mode of the channel to the exported image.
+// VIIRS deletes the BowTieHeight rows at the top and the bottom of each scan
+// in the edge zones, because they overlap the neighbouring scans. The correction
+// needs the swath of the channel, without it the deleted rows are filled instead.
+//
+// The DNB aggregates on-board a varying number of CCD rows and columns into its
+// pixels, keeping them close to 742 m in both directions along the whole scan.
+// Its scans don't grow towards the edges and don't overlap, so they keep all their
+// rows and are left as received by both modes.
+func (e Channel) RemoveBowTie(buf []byte, swath *geo.Swath) {
+	mode := e.BowTieMode
+	if mode == BowTieCorrect {
+		if sources := e.bowTieSources(swath); sources != nil {
+			e.correctBowTie(buf, sources)
+			return
+		}
+		if e.hasBowTie() {
+			fmt.Printf("[SEN] Channel %s can't be corrected without its georeference, the deleted rows were filled instead.\n", e.ChannelName)
+		}
+		mode = BowTieFill
+	}
+
+	if mode == BowTieFill {
+		e.forEachZone(func(first, last, rows int) {
+			e.fillBowTie(buf, first, last, rows)
+		})
+	}
+}
+
+func (e Channel) hasBowTie() bool {
+	for _, rows := range e.BowTieHeight {
+		if rows > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// forEachZone calls the handler with the columns and the deleted rows of each
+// aggregation zone with bow-tie deletion.
+func (e Channel) forEachZone(handler func(first, last, rows int)) {
+	first := 0
+	for z, width := range e.AggregationZoneWidth {
+		rows := e.BowTieHeight[z]
+		if rows > 0 && rows*2 < e.AggregationZoneHeight {
+			handler(first, first+width, rows)
+		}
+		first += width
+	}
+}
+
+// fillBowTie interpolates the deleted rows of the zone between the last valid row
+// of a scan and the first valid row of the next one. The scans at the borders
+// of the image repeat their valid row.
+func (e Channel) fillBowTie(buf []byte, first, last, rows int) {
+	height := e.AggregationZoneHeight
+	scans := e.countScans(buf)
+
+	for s := 0; s <= scans; s++ {
+		above := (s-1)*height + height - 1 - rows
+		below := s*height + rows
+		gap := s*height - rows
+
+		for x := first; x < last; x++ {
+			a, b := e.pixel(buf, x, above), e.pixel(buf, x, below)
+			if s == 0 {
+				a = b
+			}
+			if s == scans {
+				b = a
+			}
+
+			for i := 0; i < rows*2; i++ {
+				y := gap + i
+				if y < 0 || y >= scans*height {
+					continue
+				}
+				t := float64(i+1) / float64(rows*2+1)
+				e.setPixel(buf, x, y, uint16(float64(a)*(1-t)+float64(b)*t+0.5))
+			}
+		}
+	}
+}
+
+// bowTieSources returns the detector, as a fractional row of the scan, resampled into
+// each row of each column. All detectors of a scan look at the same time, each one
+// ahead of the previous by the same along-track angle, so their ground positions
+// spread apart towards the edges of the scan, where the slant range is longer. The
+// rows of the corrected scan are instead spaced on the ground by the advance of the
+// scans divided by the number of detectors, like the detectors at nadir. The geometry
+// of the middle scan of the swath is used for the whole image, the altitude changes
+// little during a pass. It's nil for channels without bow-tie deletion.
+func (e Channel) bowTieSources(swath *geo.Swath) []float64 {
+	height := e.AggregationZoneHeight
+	if swath == nil || !e.hasBowTie() || len(swath.Lines) < height*2 || len(swath.Model) != int(e.Width) {
+		return nil
+	}
+
+	scan := len(swath.Lines)/height/2*height + height/2
+	if scan+height >= len(swath.Lines) {
+		scan -= height
+	}
+	sv, next := swath.Lines[scan], swath.Lines[scan+height]
+	model := swath.Model
+
+	// The along-track angle between the detectors, from their spacing at nadir.
+	const step = 1e-4
+	nadir, ok1 := model.Locate(sv, 0)
+	nadirNext, ok2 := model.Locate(next, 0)
+	ahead, ok3 := model.LocateDetector(sv, 0, step)
+	if !ok1 || !ok2 || !ok3 {
+		return nil
+	}
+	ifov := geo.Distance(nadir, nadirNext) / float64(height) / (geo.Distance(nadir, ahead) / step)
+
+	center := float64(height-1) / 2
+	sources := make([]float64, len(model)*height)
+	position := make([]float64, height)
+
+	first := 0
+	for z, width := range e.AggregationZoneWidth {
+		rows := e.BowTieHeight[z]
+		for x := first; x < first+width; x++ {
+			// The ground position of each detector along the track.
+			var origin geo.GeoPoint
+			for d := range position {
+				p, ok := model.LocateDetector(sv, model[x], (float64(d)-center)*ifov)
+				if !ok {
+					return nil
+				}
+				if d == 0 {
+					origin = p
+				}
+				position[d] = geo.Distance(origin, p)
+			}
+
+			a, ok1 := model.Locate(sv, model[x])
+			b, ok2 := model.Locate(next, model[x])
+			if !ok1 || !ok2 {
+				return nil
+			}
+			spacing := geo.Distance(a, b) / float64(height)
+			middle := (position[0] + position[height-1]) / 2
+
+			for i := 0; i < height; i++ {
+				target := middle + (float64(i)-center)*spacing
+
+				d := 0
+				for d < height-2 && position[d+1] < target {
+					d++
+				}
+				source := float64(d) + (target-position[d])/(position[d+1]-position[d])
+
+				// The deleted rows are blank, the nearest valid rows are used instead.
+				source = clamp(source, float64(rows), float64(height-1-rows))
+				sources[x*height+i] = source
+			}
+		}
+		first += width
+	}
+	return sources
+}
+
+// correctBowTie resamples the rows of each scan from the detectors of the sources.
+func (e Channel) correctBowTie(buf []byte, sources []float64) {
+	height := e.AggregationZoneHeight
+	column := make([]float64, height)
+
+	for s := 0; s < e.countScans(buf); s++ {
+		for x := 0; x < int(e.Width); x++ {
+			for i := range column {
+				column[i] = float64(e.pixel(buf, x, s*height+i))
+			}
+
+			for i := 0; i < height; i++ {
+				pos := sources[x*height+i]
+				p := int(pos)
+				if p >= height-1 {
+					e.setPixel(buf, x, s*height+i, uint16(column[height-1]+0.5))
+					continue
+				}
+
+				t := pos - float64(p)
+				e.setPixel(buf, x, s*height+i, uint16(column[p]*(1-t)+column[p+1]*t+0.5))
+			}
+		}
+	}
+}
+
+func clamp(v, lo, hi float64) float64 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+func (e Channel) countScans(buf []byte) int {
+	return len(buf) / (int(e.Width) * 2 * e.AggregationZoneHeight)
+}
+
+func (e Channel) pixel(buf []byte, x, y int) uint16 {
+	if y < 0 || (y*int(e.Width)+x)*2 >= len(buf) {
+		return 0
+	}
+	return binary.BigEndian.Uint16(buf[(y*int(e.Width)+x)*2:])
+}
+
+func (e Channel) setPixel(buf []byte, x, y int, val uint16) {
+	binary.BigEndian.PutUint16(buf[(y*int(e.Width)+x)*2:], val)
+}
@@ -0,0 +1,82 @@
+package parser
+
+import (
+	"math"
+	"testing"
+	"time"
+	"weatherdump/src/geo"
+)
+
+// polarSwath returns the swath of the channel from a circular polar orbit at the
+// altitude of VIIRS, over the equator at the middle line.
+func polarSwath(ch *Channel, scans int) *geo.Swath {
+	const radius, period = 7202e3, 6081.0
+	w := 2 * math.Pi / period
+	interval := scanPeriod / time.Duration(ch.AggregationZoneHeight)
+	lines := scans * ch.AggregationZoneHeight
+
+	swath := geo.Swath{Model: ch.GetScanModel()}
+	for i := 0; i < lines; i++ {
+		t := interval * time.Duration(i-lines/2)
+		s, c := math.Sincos(w * t.Seconds())
+		swath.Lines = append(swath.Lines, geo.StateVector{
+			Position: [3]float64{radius * c, 0, radius * s},
+			Velocity: [3]float64{-radius * w * s, 0, radius * w * c},
+		})
+	}
+	return &swath
+}
+
+func TestBowTieSources(t *testing.T) {
+	ch := New()[800]
+	height := ch.AggregationZoneHeight
+	sources := ch.bowTieSources(polarSwath(ch, 8))
+	if sources == nil {
+		t.Fatal("no sources from the swath")
+	}
+
+	column := func(x int) []float64 { return sources[x*height : (x+1)*height] }
+
+	// At nadir the detectors are already spaced by the advance of the scans.
+	for i, source := range column(int(ch.Width) / 2) {
+		if math.Abs(source-float64(i)) > 0.1 {
+			t.Errorf("nadir row %d from detector %.2f", i, source)
+		}
+	}
+
+	// At the edges the detectors spread apart, the rows come from the central ones
+	// without reaching the rows deleted on-board.
+	rows := ch.BowTieHeight[0]
+	for _, x := range []int{0, int(ch.Width) - 1} {
+		c := column(x)
+		for i := 1; i < height; i++ {
+			if c[i] <= c[i-1] {
+				t.Fatalf("column %d: rows %d and %d from detectors %.2f and %.2f", x, i-1, i, c[i-1], c[i])
+			}
+		}
+		if c[0] <= float64(rows) || c[height-1] >= float64(height-1-rows) {
+			t.Errorf("column %d: rows from detectors %.2f to %.2f, the valid ones are %d to %d",
+				x, c[0], c[height-1], rows, height-1-rows)
+		}
+	}
+}
+
+func TestRemoveBowTieWithoutSwath(t *testing.T) {
+	ch := New()[800]
+	ch.BowTieMode = BowTieCorrect
+	buf := make([]byte, int(ch.Width)*ch.AggregationZoneHeight*2*2)
+	for x := 0; x < int(ch.Width); x++ {
+		for y := 0; y < ch.AggregationZoneHeight*2; y++ {
+			ch.setPixel(buf, x, y, 1000)
+		}
+		for y := 0; y < ch.BowTieHeight[0]; y++ {
+			ch.setPixel(buf, x, ch.AggregationZoneHeight-1-y, 0)
+		}
+	}
+
+	// The deleted rows are filled instead.
+	ch.RemoveBowTie(buf, nil)
+	if v := ch.pixel(buf, 0, ch.AggregationZoneHeight-1); v != 1000 {
+		t.Errorf("deleted row filled with %d, want 1000", v)
+	}
+}
@@ -3,19 +3,34 @@ package parser
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 	"weatherdump/src/ccsds/frames"
+	"weatherdump/src/geo"
 	"weatherdump/src/protocols/hrd"
 	"weatherdump/src/protocols/hrd/processor/parser/segment"
 )
 
 const maxFrameCount = 8192
 
+// scanPeriod of VIIRS, used when the channel has a single scan.
+const scanPeriod = 1786400 * time.Microsecond
+
+// scanHalfAngle of the VIIRS swath in degrees.
+const scanHalfAngle = 56.28
+
+// scanAggregation is the number of samples aggregated on-board into each pixel of the
+// aggregation zones, from the start to the end of the scan. The Day/Night Band pixels
+// also shrink towards the edges of the scan, so it's used for all channels.
+var scanAggregation = []int{1, 2, 3, 3, 2, 1}
+
 type Channel struct {
 	APID                  uint16
 	ChannelName           string
 	AggregationZoneWidth  [6]int
 	AggregationZoneHeight int
 	BowTieHeight          [6]int
+	BowTieMode            int
 	OversampleZone        [6]int
 	Width                 uint32
 	Height                uint32
@@ -57,6 +72,22 @@ func (e *Channel) SetBounds(first, last int) {
 	e.LastSegment = uint32(last)
 }
 
+// GetLineTimes returns the time of the first line and the interval between the lines.
+// Should be called after the Process().
+func (e Channel) GetLineTimes() (time.Time, time.Duration) {
+	start := e.StartTime.GetDate()
+	period := scanPeriod
+	if scans := e.LastSegment - e.FirstSegment; scans > 0 {
+		period = e.EndTime.GetDate().Sub(start) / time.Duration(scans)
+	}
+	return start, period / time.Duration(e.AggregationZoneHeight)
+}
+
+// GetScanModel returns the scan angle of each column of the channel.
+func (e Channel) GetScanModel() geo.ScanModel {
+	return geo.NewAggregatedScanModel(scanHalfAngle, e.AggregationZoneWidth[:], scanAggregation)
+}
+
 func (e *Channel) Process(scft hrd.SpacecraftParameters) {
 	if e.LastSegment-e.FirstSegment > maxFrameCount {
 		fmt.Println("[SEN] Potentially invalid channel %s was found.\n", e.ChannelName)
@@ -79,6 +110,7 @@ func (e *Channel) Process(scft hrd.SpacecraftParameters) {
 	}
 
 	if !e.Processed && e.HasData {
+		var damaged int32
 		for i := e.FirstSegment; i <= e.LastSegment; i++ {
 			if e.segments[i] == nil {
 				e.segments[i] = segment.NewFillSegment(i)
@@ -90,13 +122,18 @@ func (e *Channel) Process(scft hrd.SpacecraftParameters) {
 			for j := range e.segments[i].Body {
 				go func(wg *sync.WaitGroup, j int) {
 					defer wg.Done()
-					e.segments[i].Body[j].Process(e.AggregationZoneWidth, e.OversampleZone)
+					n := e.segments[i].Body[j].Process(e.AggregationZoneWidth, e.OversampleZone)
+					atomic.AddInt32(&damaged, int32(n))
 				}(&wg, j)
 			}
 
 			wg.Wait()
 		}
 
+		if damaged > 0 {
+			fmt.Printf("[SEN] Channel %s has %d damaged detector lines, they were left blank.\n", e.ChannelName, damaged)
+		}
+
 		e.Processed = true
 	}
 
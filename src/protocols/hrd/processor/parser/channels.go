@@ -2,6 +2,7 @@ package parser
 
 import (
 	"encoding/json"
+	"time"
 	"weatherdump/src/protocols/helpers"
 )
 
@@ -14,6 +15,22 @@ func New() List {
 	return n
 }
 
+// GetPassTime returns the time of the first scan received by any channel.
+func (e List) GetPassTime() (time.Time, bool) {
+	var first time.Time
+	for _, ch := range e {
+		for _, s := range ch.segments {
+			if s == nil || !s.Header.IsValid() {
+				continue
+			}
+			if t := s.Header.GetDate().GetDate(); first.IsZero() || t.Before(first) {
+				first = t
+			}
+		}
+	}
+	return first, !first.IsZero()
+}
+
 var Channels = List{
 	800: {
 		APID:                  800,
@@ -251,7 +268,7 @@ var Channels = List{
 		ChannelName:           "DNB",
 		AggregationZoneWidth:  [6]int{784, 488, 760, 760, 488, 784},
 		AggregationZoneHeight: 15,
-		BowTieHeight:          [6]int{3, 1, 0, 0, 1, 3},
+		BowTieHeight:          [6]int{0, 0, 0, 0, 0, 0},
 		OversampleZone:        [6]int{1, 1, 1, 1, 1, 1},
 		Width:                 4064,
 		ReconstructionBand:    000,
@@ -262,7 +279,7 @@ var Channels = List{
 		ChannelName:           "DNBMGS",
 		AggregationZoneWidth:  [6]int{784, 488, 760, 760, 488, 784},
 		AggregationZoneHeight: 15,
-		BowTieHeight:          [6]int{3, 1, 0, 0, 1, 3},
+		BowTieHeight:          [6]int{0, 0, 0, 0, 0, 0},
 		OversampleZone:        [6]int{1, 1, 1, 1, 1, 1},
 		Width:                 4064,
 		ReconstructionBand:    000,
@@ -273,7 +290,7 @@ var Channels = List{
 		ChannelName:           "DNBLGS",
 		AggregationZoneWidth:  [6]int{784, 488, 760, 760, 488, 784},
 		AggregationZoneHeight: 15,
-		BowTieHeight:          [6]int{3, 1, 0, 0, 1, 3},
+		BowTieHeight:          [6]int{0, 0, 0, 0, 0, 0},
 		OversampleZone:        [6]int{1, 1, 1, 1, 1, 1},
 		Width:                 4064,
 		ReconstructionBand:    000,
@@ -3,10 +3,23 @@ package parser
 import (
 	"runtime"
 	"sync"
+	"weatherdump/src/geo"
 	"weatherdump/src/protocols/hrd"
 )
 
-func (e *Channel) Export(buf *[]byte, ch List, scft hrd.SpacecraftParameters) bool {
+// Export the channel image with the bow-tie mode of the channel applied, the swath
+// of the channel is needed by the bow-tie correction.
+func (e *Channel) Export(buf *[]byte, ch List, scft hrd.SpacecraftParameters, swath *geo.Swath) bool {
+	if !e.ExportCounts(buf, ch, scft) {
+		return false
+	}
+	e.RemoveBowTie(*buf, swath)
+	return true
+}
+
+// ExportCounts exports the channel image without the bow-tie mode applied, so each
+// row of a scan is still the detector with the same index.
+func (e *Channel) ExportCounts(buf *[]byte, ch List, scft hrd.SpacecraftParameters) bool {
 	if !e.HasData {
 		return false
 	}
@@ -30,7 +43,7 @@ func (e *Channel) Export(buf *[]byte, ch List, scft hrd.SpacecraftParameters) bo
 
 		codedChannel.Process(scft)
 		if codedChannel.ReconstructionBand != 000 {
-			if !codedChannel.Export(&[]byte{}, ch, scft) {
+			if !codedChannel.ExportCounts(&[]byte{}, ch, scft) {
 				return false
 			}
 		}
@@ -84,6 +97,7 @@ func (e *Channel) Export(buf *[]byte, ch List, scft hrd.SpacecraftParameters) bo
 	}
 
 	wg.Wait()
+
 	e.ReconstructionBand = 000
 	return true
 }
@@ -8,6 +8,15 @@ import (
 
 const bodyMinimum = 88
 
+// Views of the calibration packets (APID 825). Their bodies have the header of the
+// science bodies followed by one sector of each view instead of the aggregation zones.
+const (
+	SpaceView = iota
+	BlackbodyView
+	SolarDiffuserView
+	CalibrationViews
+)
+
 type Body struct {
 	sequenceCount    uint32
 	packetTime       hrd.Time
@@ -81,15 +90,31 @@ func (e Body) Print() {
 	fmt.Println()
 }
 
-func (e *Body) Process(width, oversample [6]int) {
+// Process decompresses the detectors and returns the number of damaged ones.
+func (e *Body) Process(width, oversample [6]int) int {
+	damaged := 0
 	for i := range e.Detector {
 		if e.Detector[i].IsValid(e.syncWordPattern) {
-			e.Detector[i].Decompress(width[i], oversample[i])
+			if err := e.Detector[i].Decompress(width[i], oversample[i]); err != nil {
+				damaged++
+				continue
+			}
 			e.Detector[i].Decimate(width[i], oversample[i])
 		} else {
 			e.Detector[i].Pad(width[i])
 		}
 	}
+	return damaged
+}
+
+// ProcessCalibration decompresses the calibration views with the samples of each
+// one and returns the number of damaged ones.
+func (e *Body) ProcessCalibration(samples int) int {
+	var width [6]int
+	for i := 0; i < CalibrationViews; i++ {
+		width[i] = samples
+	}
+	return e.Process(width, [6]int{1, 1, 1, 1, 1, 1})
 }
 
 func (e Body) GetDetectorNumber() uint8 {
@@ -99,3 +124,8 @@ func (e Body) GetDetectorNumber() uint8 {
 func (e Body) GetSequenceCount() uint32 {
 	return e.sequenceCount
 }
+
+// GetBand returns the VIIRS band number of the body.
+func (e Body) GetBand() uint8 {
+	return e.band
+}
@@ -8,6 +8,13 @@ import (
 
 const detectorMinimum = 88
 
+// The 15-bit samples are the 14-bit counts of the digitizer and, in the dual gain
+// bands, the flag of the samples digitized with the low gain.
+const (
+	CountMask   = 0x3FFF
+	LowGainFlag = 0x4000
+)
+
 // Detector is the final data structure from the VIIRS pictures products.
 type Detector struct {
 	fillData       uint8
@@ -15,6 +22,7 @@ type Detector struct {
 	checksum       uint32
 	syncWord       uint32
 	data           []byte
+	damaged        bool
 }
 
 // NewDetector returns a pointer of a new Detector.
@@ -81,29 +89,47 @@ func (e *Detector) Pad(width int) {
 	e.data = make([]byte, width*2)
 }
 
-func (e *Detector) Decompress(width, oversample int) {
-	e.data, _ = Decompress(e.data, len(e.data), width*2*oversample)
+// IsDamaged reports if the detector data couldn't be decompressed.
+func (e Detector) IsDamaged() bool {
+	return e.damaged
 }
 
+// Decompress the detector data. Damaged data is replaced by an empty line.
+func (e *Detector) Decompress(width, oversample int) error {
+	data, err := Decompress(e.data, width*2*oversample)
+	if err != nil {
+		e.damaged = true
+		e.Pad(width)
+		return err
+	}
+
+	e.data = data
+	return nil
+}
+
+// Decimate aggregates the oversampled pixels of the dual gain bands. Samples of
+// different gains can't be averaged, so the pixels with low gain samples only
+// average those and keep the low gain flag.
 func (e *Detector) Decimate(width, oversample int) {
 	if oversample == 1 {
 		return
 	}
 
-	for x := 0; x < len(e.data); x += oversample * 2 {
-		var val uint16
-
-		switch oversample {
-		case 2:
-			val += binary.BigEndian.Uint16(e.data[x:])
-			val += binary.BigEndian.Uint16(e.data[x+2:])
-		case 3:
-			val += binary.BigEndian.Uint16(e.data[x:])
-			val += binary.BigEndian.Uint16(e.data[x+2:])
-			val += binary.BigEndian.Uint16(e.data[x+4:])
+	for x := 0; x+oversample*2 <= len(e.data); x += oversample * 2 {
+		var sum, count [2]int
+		for i := 0; i < oversample; i++ {
+			sample := binary.BigEndian.Uint16(e.data[x+i*2:])
+			gain := int(sample & LowGainFlag >> 14)
+			sum[gain] += int(sample & CountMask)
+			count[gain]++
 		}
 
-		val /= uint16(oversample)
+		val := uint16(LowGainFlag)
+		if count[1] > 0 {
+			val |= uint16(sum[1] / count[1])
+		} else {
+			val = uint16(sum[0] / count[0])
+		}
 		binary.BigEndian.PutUint16(e.data[x/oversample:], val)
 	}
 
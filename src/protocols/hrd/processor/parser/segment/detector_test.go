@@ -0,0 +1,41 @@
+package segment
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestDetectorDecompress(t *testing.T) {
+	// A zero block with the reference sample 0x1234: identifier 0000, the
+	// extension bit 0, the 15-bit sample and the run of one block.
+	e := Detector{data: []byte{0x01, 0x23, 0x48}}
+	if err := e.Decompress(8, 1); err != nil {
+		t.Fatal(err)
+	}
+	if e.IsDamaged() {
+		t.Fatal("valid detector marked damaged")
+	}
+	for x := 0; x < 8; x++ {
+		if v := binary.BigEndian.Uint16(e.data[x*2:]); v != 0x1234 {
+			t.Fatalf("pixel %d is %#x, want 0x1234", x, v)
+		}
+	}
+}
+
+func TestDetectorDecompressDamaged(t *testing.T) {
+	e := Detector{data: []byte{0x01, 0x23}}
+	if err := e.Decompress(8, 2); err == nil {
+		t.Fatal("truncated detector decompressed")
+	}
+	if !e.IsDamaged() {
+		t.Fatal("truncated detector not marked damaged")
+	}
+	if len(e.data) != 16 {
+		t.Fatalf("damaged detector has %d bytes, want an empty line of 16", len(e.data))
+	}
+	for _, b := range e.data {
+		if b != 0 {
+			t.Fatal("damaged detector line isn't empty")
+		}
+	}
+}
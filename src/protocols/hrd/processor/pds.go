@@ -0,0 +1,63 @@
+package processor
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"weatherdump/src/ccsds/frames"
+	"weatherdump/src/ccsds/pds"
+	"weatherdump/src/protocols/hrd"
+)
+
+// pdsGroup of APIDs saved in the same production data set. The key
+// APID and the name are used in the file name, like RT-STPS does.
+type pdsGroup struct {
+	key   uint16
+	name  string
+	apids []uint16
+}
+
+var pdsGroups = []pdsGroup{
+	{key: hrd.DiaryAPID, name: "", apids: []uint16{hrd.DiaryAPID}},
+	{key: 515, name: "", apids: []uint16{515, 528, 530, 531}},
+	{key: 826, name: "VIIRSSCIENCE", apids: apidRange(800, 826)},
+	{key: 1289, name: "CRISSCIENCE", apids: apidRange(1289, 1396)},
+}
+
+func apidRange(first, last uint16) []uint16 {
+	apids := make([]uint16, 0, last-first+1)
+	for apid := first; apid <= last; apid++ {
+		apids = append(apids, apid)
+	}
+	return apids
+}
+
+// ExportPDS saves the packets of the spacecraft diary, ATMS, VIIRS and CrIS
+// as production data sets inside the output path while the file is processed.
+func (e *Worker) ExportPDS(outputPath string) {
+	for _, group := range pdsGroups {
+		writer := pds.NewWriter(outputPath, group.key, group.name)
+		e.pds = append(e.pds, writer)
+
+		for _, apid := range group.apids {
+			e.demux.Subscribe(apid, func(packet frames.SpacePacketFrame) {
+				if err := writer.Write(e.demux.GetChannel(), packet); err != nil {
+					log.Fatal(err)
+				}
+			})
+		}
+	}
+}
+
+func (e *Worker) closePDS() {
+	for _, writer := range e.pds {
+		name, err := writer.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if name != "" {
+			fmt.Printf("[PRC] Saved %d packets in the production data set %s.\n", writer.GetPacketCount(), filepath.Base(name))
+		}
+	}
+}
@@ -2,15 +2,21 @@ package processor
 
 import (
 	"fmt"
-	"io/ioutil"
+	"log"
+	"os"
 	"path/filepath"
 	"weatherdump/src/ccsds"
 	"weatherdump/src/ccsds/frames"
+	"weatherdump/src/ccsds/pds"
+	"weatherdump/src/geo"
 	"weatherdump/src/handlers/interfaces"
 	"weatherdump/src/img"
 	"weatherdump/src/protocols/helpers"
 	"weatherdump/src/protocols/hrd"
+	"weatherdump/src/protocols/hrd/processor/atms"
+	"weatherdump/src/protocols/hrd/processor/calibration"
 	"weatherdump/src/protocols/hrd/processor/composer"
+	"weatherdump/src/protocols/hrd/processor/cris"
 	"weatherdump/src/protocols/hrd/processor/parser"
 
 	"github.com/fatih/color"
@@ -19,20 +25,33 @@ import (
 
 var upgrader = websocket.Upgrader{}
 
-const frameSize = 892
-
 type Worker struct {
-	ccsds    *ccsds.Worker
-	scid     uint8
-	manifest helpers.ProcessingManifest
-	channels parser.List
+	demux      *ccsds.Demultiplexer
+	dumper     *ccsds.PacketDumper
+	pds        []*pds.Writer
+	calibrator *calibration.Calibrator
+	sounder    *atms.Sounder
+	cris       *cris.Interferometer
+	spectrum   []float64
+	ephemeris  geo.Ephemeris
+	tle        string
+	satellite  *geo.Satellite
+	scid       uint8
+	manifest   helpers.ProcessingManifest
+	channels   parser.List
 }
 
 func NewProcessor(uuid string, manifest *helpers.ProcessingManifest) interfaces.Processor {
 	e := Worker{
-		ccsds:    ccsds.New(),
-		channels: parser.New(),
+		demux:      ccsds.NewDemultiplexer(ccsds.Version["HRD"]),
+		calibrator: calibration.New(),
+		sounder:    atms.New(),
+		channels:   parser.New(),
 	}
+	e.demux.SubscribeRange(800, 823, e.parsePacket)
+	e.demux.Subscribe(825, e.calibrator.Parse)
+	e.demux.Subscribe(atms.ScienceAPID, e.sounder.Parse)
+	e.demux.Subscribe(hrd.DiaryAPID, e.parseDiary)
 
 	if manifest == nil {
 		e.manifest = e.GetProductsManifest()
@@ -49,46 +68,231 @@ func (e *Worker) Work(inputFile string) {
 	color.Yellow("[PRC] WARNING! This processor is currently in BETA development state.")
 	scidStat := [256]int{}
 
-	file, _ := ioutil.ReadFile(inputFile)
-	for i := len(file); i > 0; i -= frameSize {
-		f := frames.NewTransferFrame(file[(len(file) - i):])
-		p := frames.NewMultiplexingFrame(ccsds.Version["HRD"], f.GetMPDU())
+	file, err := os.Open(inputFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
 
-		if f.IsReplay() && p.IsValid() {
+	err = ccsds.ReadFrames(file, func(f *frames.TransferFrame) {
+		if f.IsReplay() {
 			scidStat[f.GetSCID()]++
-			switch f.GetVCID() {
-			case 16:
-				e.ccsds.ParseMPDU(*p) // VCID 16 Parser (VIIRS)
-			}
+			e.demux.ParseFrame(f)
 		}
+	})
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	for _, packet := range e.ccsds.GetSpacePackets() {
-		if packet.GetAPID() >= 800 && packet.GetAPID() <= 823 {
-			e.channels[packet.GetAPID()].Parse(packet)
+	if e.dumper != nil {
+		if err := e.dumper.Close(); err != nil {
+			log.Fatal(err)
 		}
+		e.dumper.PrintStatistics()
 	}
+	e.closePDS()
+	e.closeInterferometer()
 
 	e.scid = uint8(helpers.MaxIntSlice(scidStat[:]))
-	fmt.Printf("[PRC] Decoded %d packets from VCID 16.\n", len(e.ccsds.GetSpacePackets()))
+	e.demux.PrintStatistics()
+}
+
+// DumpPackets saves the raw packets of the APIDs to the output path while the file is processed.
+func (e *Worker) DumpPackets(outputPath string, apids []uint16) {
+	e.dumper = ccsds.NewPacketDumper(outputPath)
+	e.dumper.Subscribe(e.demux, apids)
+}
+
+// ExportInterferograms saves the CrIS interferograms of each band inside the output path
+// while the file is processed. A wavenumber range also saves the uncalibrated spectra
+// and exports a brightness image of the range.
+func (e *Worker) ExportInterferograms(outputPath string, spectrum []float64) {
+	e.cris = cris.NewInterferometer(outputPath, spectrum)
+	e.spectrum = spectrum
+	e.demux.SubscribeRange(cris.FirstAPID, cris.LastAPID, e.parseInterferogram)
+}
+
+func (e *Worker) parseInterferogram(packet frames.SpacePacketFrame) {
+	if err := e.cris.Parse(packet); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func (e *Worker) closeInterferometer() {
+	if e.cris == nil || !e.cris.HasData {
+		return
+	}
+
+	names, err := e.cris.Close(hrd.Spacecrafts[e.scid])
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("[PRC] Saved %d CrIS interferograms in %d files.\n", e.cris.GetRecordCount(), len(names))
+}
+
+func (e *Worker) parseDiary(packet frames.SpacePacketFrame) {
+	if sv, ok := hrd.ParseDiary(packet); ok {
+		e.ephemeris.Add(sv)
+	}
+}
+
+// SetTLE propagates the orbit of the spacecraft with its element set inside the
+// TLE file, saving the sub-satellite track and georeferencing each
+// VIIRS product.
+func (e *Worker) SetTLE(path string) {
+	e.tle = path
+}
+
+// navigate propagates the orbit of the TLE file and rotates the products of northbound
+// passes. The direction comes from the spacecraft diary or, without it, from the orbit.
+func (e *Worker) navigate(wf *img.Pipeline) {
+	passTime, ok := e.channels.GetPassTime()
+	if ok && e.tle != "" {
+		e.satellite = helpers.LoadOrbit(e.tle, hrd.Spacecrafts[e.scid].Catalog, passTime)
+	}
+
+	if direction, ok := e.ephemeris.GetDirection(); ok {
+		helpers.Orient(wf, direction)
+	} else if e.satellite != nil {
+		if sv, err := e.satellite.Propagate(passTime); err == nil {
+			helpers.Orient(wf, sv.GetDirection())
+		}
+	} else if wf.Has("AutoRotate") {
+		fmt.Println("[PRC] The pass direction is unknown without the spacecraft diary or a TLE file, the products won't be rotated.")
+	}
+}
+
+// exportTrack saves the sub-satellite track of the channel lines.
+func (e *Worker) exportTrack(ch *parser.Channel, outputName string) {
+	if e.satellite == nil {
+		return
+	}
+
+	start, interval := ch.GetLineTimes()
+	_, h := ch.GetDimensions()
+	helpers.SaveTrack(e.satellite, start, interval, h, fmt.Sprintf("%s_TRACK.csv", outputName))
+}
+
+// georeference returns the swath locating the pixels of the channel image with the
+// spacecraft diary or, when it doesn't cover the channel, the TLE orbit. It's nil
+// without either.
+func (e *Worker) georeference(ch *parser.Channel) *geo.Swath {
+	start, interval := ch.GetLineTimes()
+	_, h := ch.GetDimensions()
+
+	if len(e.ephemeris) > 1 {
+		swath, err := geo.NewEphemerisSwath(e.ephemeris, start, interval, h, ch.GetScanModel())
+		if err == nil {
+			return &swath
+		}
+		if e.satellite == nil {
+			fmt.Printf("[PRC] Channel %s wasn't georeferenced: %s.\n", ch.ChannelName, err)
+			return nil
+		}
+	}
+
+	if e.satellite == nil {
+		return nil
+	}
+
+	swath, err := geo.NewSwath(e.satellite, start, interval, h, ch.GetScanModel())
+	if err != nil {
+		fmt.Printf("[PRC] Channel %s wasn't georeferenced: %s.\n", ch.ChannelName, err)
+		return nil
+	}
+	return &swath
+}
+
+// Probe returns the fraction of valid transfer frames in the head of the file.
+func (e Worker) Probe(inputFile string) float64 {
+	file, err := helpers.OpenHead(inputFile)
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+
+	confidence, _ := ccsds.ProbeFrames(file, hrd.IsValidFrame)
+	return confidence
+}
+
+// Inspect summarizes the frames and packets of all APIDs of the file.
+func (e Worker) Inspect(inputFile string) (*helpers.Summary, error) {
+	summary := helpers.NewSummary("hrd")
+	demux := ccsds.NewDemultiplexer(ccsds.Version["HRD"])
+
+	demux.SubscribeRange(0, ccsds.IdleAPID-1, func(packet frames.SpacePacketFrame) {
+		apid := packet.GetAPID()
+		summary.AddPacket(packet)
+
+		if packet.HasSecondaryHeader() && len(packet.GetData()) >= 8 {
+			var t hrd.Time
+			t.FromBinary(packet.GetData())
+			summary.AddTime(apid, t.GetZulu())
+		}
+
+		if ch, ok := e.channels[apid]; ok {
+			summary.APID(apid).Name = ch.ChannelName
+			if packet.GetSequenceFlags() == 1 {
+				summary.APID(apid).ScanLines += ch.AggregationZoneHeight
+			}
+		}
+	})
+
+	file, err := os.Open(inputFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	err = ccsds.ReadFrames(file, func(f *frames.TransferFrame) {
+		summary.AddFrame(f.GetSCID(), hrd.Spacecrafts[f.GetSCID()].FullName)
+		demux.ParseFrame(f)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	summary.Finish(demux.GetStatistics())
+	return summary, nil
+}
+
+// parsePacket forwards each packet to its channel as soon as it's assembled.
+func (e *Worker) parsePacket(packet frames.SpacePacketFrame) {
+	e.channels[packet.GetAPID()].Parse(packet)
 }
 
 func (e *Worker) Export(outputPath string, wf img.Pipeline) {
-	fmt.Printf("[PRC] Exporting VIIRS science products.\n")
+	fmt.Printf("[PRC] Exporting VIIRS and ATMS science products.\n")
 	e.manifest.Start()
+	e.channels.SetBowTie(wf)
+	e.navigate(&wf)
 
 	re := helpers.CaptureOutput(func() {
 		for _, apid := range e.manifest.Parser.Parse() {
+			if sc, ok := atms.Channels[apid]; ok {
+				e.exportSounder(apid, sc, outputPath, wf)
+				continue
+			}
+
 			ch := e.channels[apid]
 
 			var buf []byte
-			if ch.Export(&buf, e.channels, hrd.Spacecrafts[e.scid]) {
+			if ch.ExportCounts(&buf, e.channels, hrd.Spacecrafts[e.scid]) {
 				w, h := ch.GetDimensions()
 				outputName, _ := filepath.Abs(fmt.Sprintf("%s/%s", outputPath, ch.FileName))
 
+				swath := e.georeference(ch)
+
+				// The detector of each row is lost once the bow-tie rows are filled or resampled.
+				if wf.Has("Calibrate") && e.calibrate(ch, &buf, outputName, swath) {
+					wf.AddException("Equalize", false)
+				}
+				ch.RemoveBowTie(buf, swath)
+
 				wf.AddException("Invert", ch.Invert)
-				wf.Target(img.NewGray16(&buf, w, h)).Process().Export(outputName, 100)
+				wf.Target(img.NewGray16(&buf, w, h).Georeference(swath)).Process().Export(outputName, 100)
 				wf.ResetExceptions()
+				e.exportTrack(ch, outputName)
 
 				e.manifest.Parser[apid].FileName(outputName)
 			}
@@ -96,24 +300,107 @@ func (e *Worker) Export(outputPath string, wf img.Pipeline) {
 			e.manifest.ParserCompleted(apid)
 		}
 
+		if e.cris != nil && e.spectrum != nil {
+			e.exportBrightness(outputPath, wf)
+		}
+
+		if len(e.ephemeris) > 0 {
+			e.exportEphemeris(outputPath)
+		}
+
 		for _, code := range e.manifest.Composer.Parse() {
 			c := composer.Composers[uint16(code)]
-			outputName := c.Register(wf, hrd.Spacecrafts[e.scid]).Render(e.channels, outputPath)
+			outputName := c.Register(wf, hrd.Spacecrafts[e.scid]).Georeference(e.georeference).Render(e.channels, outputPath)
 			e.manifest.Composer[code].FileName(outputName)
 			e.manifest.ComposerCompleted(code)
 		}
 	})
 
 	e.channels = make(parser.List)
-	e.ccsds = nil
+	e.demux = nil
 
 	e.manifest.Stop(re)
 	color.Green("[PRC] Done! All products and components were saved.")
 }
 
+// calibrate saves the radiance and the physical values of the channel next to its
+// image and replaces the counts by the values in the fixed range of their quantity.
+// The swath locates the pixels for the solar zenith angle of the reflectance.
+func (e *Worker) calibrate(ch *parser.Channel, buf *[]byte, outputName string, swath *geo.Swath) bool {
+	w, _ := ch.GetDimensions()
+	out, err := e.calibrator.Calibrate(e.scid, ch.ChannelName, ch.StartTime.GetDate(), *buf, w, ch.AggregationZoneHeight, swath)
+	if err != nil {
+		fmt.Printf("[PRC] Channel %s wasn't calibrated: %s.\n", ch.ChannelName, err)
+		return false
+	}
+
+	if err := calibration.Save(out.Radiance, outputName+"_RADIANCE.f32"); err != nil {
+		log.Fatal(err)
+	}
+	if err := calibration.Save(out.Values, fmt.Sprintf("%s_%s.f32", outputName, out.Quantity)); err != nil {
+		log.Fatal(err)
+	}
+
+	*buf = calibration.Scale(out.Values, out.Quantity)
+	return true
+}
+
+// exportEphemeris saves the spacecraft diary as CSV and JSON files.
+func (e *Worker) exportEphemeris(outputPath string) {
+	scft := hrd.Spacecrafts[e.scid]
+	outputName, _ := filepath.Abs(fmt.Sprintf("%s/%s_%s_EPHEMERIS_%s", outputPath,
+		scft.Filename, scft.SignalName, e.ephemeris[0].Time.Format("2006-01-02T150405Z")))
+
+	if err := e.ephemeris.Save(outputName); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("[PRC] Saved %d ephemeris and attitude samples.\n", len(e.ephemeris))
+}
+
+// exportBrightness saves the CrIS brightness image of the wavenumber range.
+func (e *Worker) exportBrightness(outputPath string, wf img.Pipeline) {
+	var buf []byte
+	w, h, ok := e.cris.ExportBrightness(&buf)
+	if !ok {
+		fmt.Printf("[PRC] No CrIS spectrum inside %.0f-%.0f cm-1 was found.\n", e.spectrum[0], e.spectrum[1])
+		return
+	}
+
+	scft := hrd.Spacecrafts[e.scid]
+	outputName, _ := filepath.Abs(fmt.Sprintf("%s/%s_%s_CRIS_%.0f-%.0fCM_%s", outputPath,
+		scft.Filename, scft.SignalName, e.spectrum[0], e.spectrum[1], e.cris.StartTime.GetZuluSafe()))
+
+	wf.Target(img.NewGray16(&buf, w, h)).Process().Export(outputName, 100)
+}
+
+// exportSounder saves the image of an ATMS channel in the scan geometry.
+func (e *Worker) exportSounder(key uint16, ch atms.Channel, outputPath string, wf img.Pipeline) {
+	var buf []byte
+	if e.sounder.Export(&buf, ch) {
+		w, h := e.sounder.GetDimensions()
+		outputName, _ := filepath.Abs(fmt.Sprintf("%s/%s", outputPath, e.sounder.GetFileName(ch, hrd.Spacecrafts[e.scid])))
+
+		wf.AddException("Invert", ch.Invert)
+		wf.Target(img.NewGray16(&buf, w, h)).Process().Export(outputName, 100)
+		wf.ResetExceptions()
+
+		e.manifest.Parser[key].FileName(outputName)
+	}
+
+	e.manifest.ParserCompleted(key)
+}
+
 func (e Worker) GetProductsManifest() helpers.ProcessingManifest {
+	channels := make(helpers.ManifestList)
+	for key, m := range parser.Manifest {
+		channels[key] = m
+	}
+	for key, m := range atms.Manifest {
+		channels[key] = m
+	}
+
 	return helpers.ProcessingManifest{
-		Parser:   parser.Manifest,
+		Parser:   channels,
 		Composer: composer.Manifest,
 	}
 }
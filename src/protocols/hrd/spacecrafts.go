@@ -1,9 +1,12 @@
 package hrd
 
+import "weatherdump/src/ccsds/frames"
+
 type SpacecraftParameters struct {
 	Filename   string
 	FullName   string
 	SignalName string
+	Catalog    int
 }
 
 var Spacecrafts = map[uint8]SpacecraftParameters{
@@ -11,10 +14,18 @@ var Spacecrafts = map[uint8]SpacecraftParameters{
 		Filename:   "NOAA20",
 		FullName:   "Joint Polar Satellite System",
 		SignalName: "HRD",
+		Catalog:    43013,
 	},
 	157: {
 		Filename:   "NPP1",
 		FullName:   "Suomi National Polar-orbiting Partnership",
 		SignalName: "HRD",
+		Catalog:    37849,
 	},
 }
+
+// IsValidFrame checks if the transfer frame is a replay frame of a known spacecraft.
+func IsValidFrame(frame *frames.TransferFrame) bool {
+	_, ok := Spacecrafts[frame.GetSCID()]
+	return ok && frame.GetVersion() == 0x01 && frame.IsReplay()
+}
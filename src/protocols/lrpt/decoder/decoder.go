@@ -3,8 +3,11 @@ package decoder
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"os"
+	"weatherdump/src/ccsds/coding"
+	"weatherdump/src/dsp"
 	"weatherdump/src/handlers/interfaces"
 	"weatherdump/src/protocols/helpers"
 
@@ -12,7 +15,6 @@ import (
 
 	"github.com/fatih/color"
 	"github.com/gosuri/uiprogress"
-	SatHelper "github.com/luigifreitas/libsathelper"
 )
 
 const (
@@ -21,7 +23,7 @@ const (
 	lastFrameDataBits      = 64
 	lastFrameData          = lastFrameDataBits / 8
 	uselastFrameData       = true
-	id                     = "LRPT"
+	syncWordBits           = 64
 )
 
 type Worker struct {
@@ -30,105 +32,203 @@ type Worker struct {
 	lastFrameEnd []byte
 	codedData    []byte
 	rsWorkBuffer []byte
-	viterbi      SatHelper.Viterbi27
-	reedSolomon  SatHelper.ReedSolomon
-	correlator   SatHelper.Correlator
-	packetFixer  SatHelper.PacketFixer
+	viterbi      coding.Viterbi
+	reedSolomon  coding.ReedSolomon
+	correlator   coding.Correlator
+	packetFixer  coding.PacketFixer
+	params       parameters
+	demodulate   bool
+	sampleRate   float64
 	Statistics   helpers.Statistics
+	helpers.Reporter
 }
 
-// NewDecoder creator
+// NewDecoder creator for the regular 72k QPSK downlink.
 func NewDecoder(uuid string) interfaces.Decoder {
-	e := Worker{}
+	return newDecoder(uuid, "LRPT")
+}
+
+// NewOQPSKDecoder creator for the 72k OQPSK interleaved downlink.
+func NewOQPSKDecoder(uuid string) interfaces.Decoder {
+	return newDecoder(uuid, "LRPT_OQPSK")
+}
+
+// NewInterleaved80kDecoder creator for the 80k OQPSK interleaved and differential downlink.
+func NewInterleaved80kDecoder(uuid string) interfaces.Decoder {
+	return newDecoder(uuid, "LRPT_80K")
+}
+
+// NewIQDecoder creator for baseband IQ recordings of the 72k QPSK downlink.
+// The recording is demodulated into soft-symbols before decoding.
+func NewIQDecoder(uuid string) interfaces.Decoder {
+	e := newDecoder(uuid, "LRPT")
+	e.demodulate = true
+	return e
+}
+
+func newDecoder(uuid string, mode string) *Worker {
+	e := Worker{params: datalink[mode]}
 
 	e.Statistics.Register("lrpt", uuid)
 
 	if uselastFrameData {
-		e.viterbiData = make([]byte, datalink[id].CodedFrameSize+lastFrameDataBits)
-		e.decodedData = make([]byte, datalink[id].FrameSize+lastFrameData)
+		e.viterbiData = make([]byte, e.params.CodedFrameSize+lastFrameDataBits)
+		e.decodedData = make([]byte, e.params.FrameSize+lastFrameData)
 		e.lastFrameEnd = make([]byte, lastFrameDataBits)
-		e.viterbi = SatHelper.NewViterbi27(datalink[id].FrameBits + lastFrameDataBits)
+		e.viterbi = coding.NewViterbi27(e.params.FrameBits + lastFrameDataBits)
 
 		for i := 0; i < lastFrameDataBits; i++ {
 			e.lastFrameEnd[i] = 128
 		}
 	} else {
-		e.viterbiData = make([]byte, datalink[id].CodedFrameSize)
-		e.decodedData = make([]byte, datalink[id].FrameSize)
-		e.viterbi = SatHelper.NewViterbi27(datalink[id].FrameBits)
+		e.viterbiData = make([]byte, e.params.CodedFrameSize)
+		e.decodedData = make([]byte, e.params.FrameSize)
+		e.viterbi = coding.NewViterbi27(e.params.FrameBits)
 	}
 
-	e.codedData = make([]byte, datalink[id].CodedFrameSize)
+	e.codedData = make([]byte, e.params.CodedFrameSize)
 	e.rsWorkBuffer = make([]byte, 255)
 
-	e.reedSolomon = SatHelper.NewReedSolomon()
-	e.correlator = SatHelper.NewCorrelator()
-	e.packetFixer = SatHelper.NewPacketFixer()
-
-	e.reedSolomon.SetCopyParityToOutput(true)
+	e.reedSolomon = coding.NewReedSolomon()
+	e.correlator = coding.NewCorrelator()
+	e.packetFixer = coding.NewPacketFixer()
 
-	e.correlator.AddWord(datalink[id].SyncWords[0])
-	e.correlator.AddWord(datalink[id].SyncWords[1])
-	e.correlator.AddWord(datalink[id].SyncWords[2])
-	e.correlator.AddWord(datalink[id].SyncWords[3])
+	e.correlator.AddWord(e.params.SyncWords[0])
+	e.correlator.AddWord(e.params.SyncWords[1])
+	e.correlator.AddWord(e.params.SyncWords[2])
+	e.correlator.AddWord(e.params.SyncWords[3])
 
-	e.correlator.AddWord(datalink[id].SyncWords[4])
-	e.correlator.AddWord(datalink[id].SyncWords[5])
-	e.correlator.AddWord(datalink[id].SyncWords[6])
-	e.correlator.AddWord(datalink[id].SyncWords[7])
+	e.correlator.AddWord(e.params.SyncWords[4])
+	e.correlator.AddWord(e.params.SyncWords[5])
+	e.correlator.AddWord(e.params.SyncWords[6])
+	e.correlator.AddWord(e.params.SyncWords[7])
 
 	return &e
 }
 
+// SetSampleRate of raw IQ recordings without a header.
+func (e *Worker) SetSampleRate(sampleRate float64) {
+	e.sampleRate = sampleRate
+}
+
+// newInput returns the soft-symbols of the file after demodulating IQ
+// recordings and reverting the differential coding and the interleaving.
+func (e *Worker) newInput(file io.Reader, inputPath string) (io.Reader, error) {
+	input := file
+
+	if e.demodulate {
+		iq, err := dsp.NewIQReader(file, inputPath, e.sampleRate)
+		if err != nil {
+			return nil, err
+		}
+		input = dsp.NewQPSKDemodulator(iq, float64(e.params.SymbolRate), e.params.RRCAlpha)
+	}
+
+	if e.params.Differential {
+		input = newDifferentialDecoder(input)
+	}
+	if e.params.Interleaved {
+		input = newDeinterleaver(input)
+	}
+
+	return input, nil
+}
+
+// Probe returns the fraction of frames in the head of the file with a sync word.
+func (e *Worker) Probe(inputPath string) float64 {
+	file, err := helpers.OpenHead(inputPath)
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+
+	input, err := e.newInput(file, inputPath)
+	if err != nil {
+		return 0
+	}
+
+	symbols, _ := ioutil.ReadAll(input)
+	return helpers.ProbeSyncWords(symbols, e.correlator, e.params.CodedFrameSize, syncWordBits, e.params.MinCorrelationBits)
+}
+
 func (e *Worker) Work(inputPath string, outputPath string, signal chan bool) {
-	var phaseShift SatHelper.SatHelperPhaseShift
+	var phaseShift coding.PhaseShift
 	flywheelCount := 0
 
-	fi, err := os.Stat(inputPath)
+	file, size, err := helpers.OpenInput(inputPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	counter := &helpers.CountingReader{Reader: file}
+	input, err := e.newInput(counter, inputPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	output, err := os.Create(outputPath)
-	input, err := os.Open(inputPath)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	defer input.Close()
+	defer file.Close()
 	defer output.Close()
 
-	e.Statistics.TotalBytes = uint64(fi.Size())
+	e.Statistics.Live = helpers.IsLiveInput(inputPath)
+	e.Statistics.TotalBytes = uint64(size)
 	e.Statistics.TaskName = "Decoding soft-symbol file"
 
+	if e.demodulate {
+		e.Statistics.TaskName = "Demodulating IQ file"
+	}
+
+	if e.Statistics.Live {
+		e.Statistics.TaskName = "Decoding soft-symbol stream"
+		e.Colorf(color.FgYellow, "[DEC] Listening for soft-symbols from %s.\n", inputPath)
+	}
+
 	progress := uiprogress.New()
+	progress.SetOut(e.Output())
 
 	if !e.Statistics.IsRegistred() {
 		progress.Start()
 	}
 
-	bar := progress.AddBar(int(fi.Size())).AppendCompleted()
+	bar := progress.AddBar(int(size)).AppendCompleted()
 	bar.PrependFunc(func(b *uiprogress.Bar) string {
-		return "[DEC] Decoding soft-symbol file	"
+		return fmt.Sprintf("[DEC] %s	", e.Statistics.TaskName)
 	})
 
 	bar.AppendFunc(func(b *uiprogress.Bar) string {
 		s := e.Statistics
-		return fmt.Sprintf("\n[DEC] Decoder Statistics	 [VCID: %2d] [VIT: %5d] [QUAL: %2d%%] [RS: %2d %2d %2d %2d] [DROPPED: %4.1f%%]",
+		return fmt.Sprintf("\n[DEC] Decoder Statistics	 [VCID: %2d] [VIT: %5d] [QUAL: %2d%%] [RS: %2d %2d %2d %2d] [DROPPED: %4.1f%%] [LOST: %d]",
 			s.VCID, s.AverageVitCorrections, s.SignalQuality,
 			s.AverageRSCorrections[0], s.AverageRSCorrections[1], s.AverageRSCorrections[2], s.AverageRSCorrections[3],
-			float32(s.DroppedPackets)/float32(s.TotalPackets)*100)
+			float32(s.DroppedPackets)/float32(s.TotalPackets)*100, s.LostPackets)
 	})
 
 	e.Statistics.WaitForClient(signal)
 
+	// The reads of live sockets block until data arrives, so the cancel closes them.
+	stop := func() bool { return false }
+	if e.Statistics.Live {
+		stop = helpers.CloseOnCancel(signal, file)
+	}
+
+	var readErr error
 	helpers.WatchFor(signal, func() bool {
-		n, err := input.Read(e.codedData)
-		if datalink[id].CodedFrameSize != n || err != nil {
-			if err != io.EOF && err != nil {
-				log.Fatal(err)
+		_, err := io.ReadFull(input, e.codedData)
+		if err != nil {
+			if err != io.EOF && err != io.ErrUnexpectedEOF {
+				readErr = err
 			}
 			return true
 		}
 
-		e.Statistics.TotalBytesRead += uint64(n)
-		bar.Set(int(e.Statistics.TotalBytesRead))
+		e.Statistics.TotalBytesRead = counter.Count
+		if !e.Statistics.Live {
+			bar.Set(int(e.Statistics.TotalBytesRead))
+		}
 
 		if e.Statistics.TotalPackets%32 == 0 {
 			e.Statistics.Constellation = e.codedData[:200]
@@ -146,11 +246,11 @@ func (e *Worker) Work(inputPath string, outputPath string, signal chan bool) {
 		}
 
 		if !e.Statistics.FrameLock {
-			e.correlator.Correlate(&e.codedData[0], uint(datalink[id].CodedFrameSize))
+			e.correlator.Correlate(e.codedData)
 		} else {
-			e.correlator.Correlate(&e.codedData[0], uint(datalink[id].CodedFrameSize)/64)
+			e.correlator.Correlate(e.codedData[:e.params.CodedFrameSize/64])
 			if e.correlator.GetHighestCorrelationPosition() != 0 {
-				e.correlator.Correlate(&e.codedData[0], uint(datalink[id].CodedFrameSize))
+				e.correlator.Correlate(e.codedData)
 				flywheelCount = 0
 			}
 		}
@@ -160,80 +260,80 @@ func (e *Worker) Work(inputPath string, outputPath string, signal chan bool) {
 		pos := e.correlator.GetHighestCorrelationPosition()
 		cor := e.correlator.GetHighestCorrelation()
 
-		if cor > datalink[id].MinCorrelationBits {
+		if cor > e.params.MinCorrelationBits {
 
 			iqInv := (word / 4) > 0
 			switch word % 4 {
 			case 0:
-				phaseShift = SatHelper.DEG_0
+				phaseShift = coding.Deg0
 			case 1:
-				phaseShift = SatHelper.DEG_90
+				phaseShift = coding.Deg90
 			case 2:
-				phaseShift = SatHelper.DEG_180
+				phaseShift = coding.Deg180
 			case 3:
-				phaseShift = SatHelper.DEG_270
+				phaseShift = coding.Deg270
 			}
 
 			if pos != 0 {
-				helpers.ShiftWithConstantSize(&e.codedData, int(pos), datalink[id].CodedFrameSize)
-				offset := datalink[id].CodedFrameSize - int(pos)
+				helpers.ShiftWithConstantSize(&e.codedData, int(pos), e.params.CodedFrameSize)
+				offset := e.params.CodedFrameSize - int(pos)
 
 				buffer := make([]byte, int(pos))
-				n, err = input.Read(buffer)
+				_, err = io.ReadFull(input, buffer)
 
-				e.Statistics.TotalBytesRead += uint64(n)
+				e.Statistics.TotalBytesRead = counter.Count
 				if err != nil {
-					fmt.Println(err)
+					fmt.Fprintln(e.Output(), err)
 					return true
 				}
 
-				for i := offset; i < datalink[id].CodedFrameSize; i++ {
+				for i := offset; i < e.params.CodedFrameSize; i++ {
 					e.codedData[i] = buffer[i-offset]
 				}
 			}
 
-			e.packetFixer.FixPacket(&e.codedData[0], uint(datalink[id].CodedFrameSize), phaseShift, iqInv)
+			e.packetFixer.FixPacket(e.codedData, phaseShift, iqInv)
 
 			if uselastFrameData {
 				for i := 0; i < lastFrameDataBits; i++ {
 					e.viterbiData[i] = e.lastFrameEnd[i]
 				}
-				for i := lastFrameDataBits; i < datalink[id].CodedFrameSize+lastFrameDataBits; i++ {
+				for i := lastFrameDataBits; i < e.params.CodedFrameSize+lastFrameDataBits; i++ {
 					e.viterbiData[i] = e.codedData[i-lastFrameDataBits]
 				}
 			} else {
-				for i := 0; i < datalink[id].CodedFrameSize; i++ {
+				for i := 0; i < e.params.CodedFrameSize; i++ {
 					e.viterbiData[i] = e.codedData[i]
 				}
 			}
 
-			e.viterbi.Decode(&e.viterbiData[0], &e.decodedData[0])
+			e.viterbi.Decode(e.viterbiData, e.decodedData)
 
 			signalErrors := float32(e.viterbi.GetPercentBER())
 			signalErrors = 100 - (signalErrors * 10)
 
 			if uselastFrameData {
-				helpers.ShiftWithConstantSize(&e.decodedData, lastFrameData/2, datalink[id].FrameSize+lastFrameData/2)
+				helpers.ShiftWithConstantSize(&e.decodedData, lastFrameData/2, e.params.FrameSize+lastFrameData/2)
 				for i := 0; i < lastFrameDataBits; i++ {
-					e.lastFrameEnd[i] = e.viterbiData[datalink[id].CodedFrameSize+i]
+					e.lastFrameEnd[i] = e.viterbiData[e.params.CodedFrameSize+i]
 				}
 			}
 
-			for i := 0; i < datalink[id].SyncWordSize; i++ {
+			for i := 0; i < e.params.SyncWordSize; i++ {
 				e.Statistics.SyncWord[i] = e.decodedData[i]
 			}
 
-			helpers.ShiftWithConstantSize(&e.decodedData, datalink[id].SyncWordSize, datalink[id].FrameSize-datalink[id].SyncWordSize)
+			helpers.ShiftWithConstantSize(&e.decodedData, e.params.SyncWordSize, e.params.FrameSize-e.params.SyncWordSize)
 
 			e.Statistics.TotalPackets++
 
-			SatHelper.DeRandomizerDeRandomize(&e.decodedData[0], datalink[id].FrameSize-datalink[id].SyncWordSize)
+			coding.DeRandomize(e.decodedData[:e.params.FrameSize-e.params.SyncWordSize])
 
 			var derrors [4]int
-			for i := 0; i < datalink[id].RsBlocks; i++ {
-				e.reedSolomon.Deinterleave(&e.decodedData[0], &e.rsWorkBuffer[0], byte(i), byte(datalink[id].RsBlocks))
-				derrors[i] = int(int8(e.reedSolomon.Decode_rs8(&e.rsWorkBuffer[0])))
-				e.reedSolomon.Interleave(&e.rsWorkBuffer[0], &e.decodedData[0], byte(i), byte(datalink[id].RsBlocks))
+			for i := 0; i < e.params.RsBlocks; i++ {
+				e.reedSolomon.Deinterleave(e.decodedData, e.rsWorkBuffer, i, e.params.RsBlocks)
+				derrors[i] = e.reedSolomon.Decode(e.rsWorkBuffer)
+				e.reedSolomon.Interleave(e.rsWorkBuffer, e.decodedData, i, e.params.RsBlocks)
 				if derrors[i] != -1 {
 					e.Statistics.AverageRSCorrections[i] = (e.Statistics.AverageRSCorrections[i] + derrors[i]) / 2
 				}
@@ -248,7 +348,7 @@ func (e *Worker) Work(inputPath string, outputPath string, signal chan bool) {
 			}
 
 			e.Statistics.VCID = e.decodedData[1] & 0x3F
-			e.Statistics.FrameBits = uint16(datalink[id].FrameBits)
+			e.Statistics.FrameBits = uint16(e.params.FrameBits)
 			e.Statistics.PacketNumber = binary.BigEndian.Uint32(e.decodedData[2:]) & 0xFFFFFF00 >> 8
 			e.Statistics.SignalQuality = uint8(signalErrors)
 			e.Statistics.SyncCorrelation = uint8(cor)
@@ -261,8 +361,8 @@ func (e *Worker) Work(inputPath string, outputPath string, signal chan bool) {
 			e.Statistics.AverageVitCorrections /= 2
 
 			if e.Statistics.FrameLock {
-				e.Statistics.ReceivedPacketsPerChannel[e.Statistics.VCID]++
-				dat := e.decodedData[:datalink[id].FrameSize-datalink[id].RsParityBlockSize-datalink[id].SyncWordSize]
+				e.Statistics.CountFrame(e.Statistics.VCID, e.Statistics.PacketNumber)
+				dat := e.decodedData[:e.params.FrameSize-e.params.RsParityBlockSize-e.params.SyncWordSize]
 				output.Write(dat)
 			}
 		}
@@ -270,7 +370,12 @@ func (e *Worker) Work(inputPath string, outputPath string, signal chan bool) {
 		return false
 	})
 
-	color.Green("[DEC] Decoding finished! File saved in the same folder.\n")
+	if canceled := stop(); readErr != nil && !canceled {
+		fmt.Fprintln(e.Output(), readErr)
+	}
+
+	e.Colorf(color.FgGreen, "[DEC] Decoding finished! File saved in the same folder.\n")
+	e.Statistics.PrintSummary(e.Output())
 
 	e.Statistics.Finish()
 
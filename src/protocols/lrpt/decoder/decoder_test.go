@@ -0,0 +1,53 @@
+package decoder
+
+import (
+	"fmt"
+	"testing"
+	"weatherdump/src/handlers/interfaces"
+	"weatherdump/src/simulator/simtest"
+)
+
+// simulatedNoise keeps the clipping of the soft-symbols rare. With more noise the symbols
+// clipped at +127 are taken as ones by the hard decision of the correlator, like in
+// libsathelper, and sync words are occasionally missed.
+const (
+	simulatedFrames = 256
+	simulatedNoise  = 0.1
+)
+
+// TestSimulatedRoundTrip decodes simulated soft-symbol files with low noise in all the
+// phase ambiguities of the carrier and expects every frame back.
+func TestSimulatedRoundTrip(t *testing.T) {
+	for _, rotation := range []int{0, 90, 180, 270} {
+		t.Run(fmt.Sprintf("%d", rotation), func(t *testing.T) {
+			simtest.RoundTrip(t, NewDecoder(""), simtest.Case{
+				Datalink: "lrpt", Format: "soft", Frames: simulatedFrames,
+				Noise: simulatedNoise, Rotation: rotation, Seed: int64(rotation),
+			})
+		})
+	}
+}
+
+// TestSimulatedFormats decodes the interleaved, differential and IQ formats in all the
+// phase ambiguities of the carrier. The demodulator of the IQ format may lose the
+// first frames while it locks.
+func TestSimulatedFormats(t *testing.T) {
+	for _, tc := range []struct {
+		format  string
+		decoder func(string) interfaces.Decoder
+		maxLost int
+	}{
+		{"oqpsk", NewOQPSKDecoder, 0},
+		{"interleaved80k", NewInterleaved80kDecoder, 0},
+		{"iq", NewIQDecoder, 2},
+	} {
+		for _, rotation := range []int{0, 90, 180, 270} {
+			t.Run(fmt.Sprintf("%s/%d", tc.format, rotation), func(t *testing.T) {
+				simtest.RoundTrip(t, tc.decoder(""), simtest.Case{
+					Datalink: "lrpt", Format: tc.format, Frames: 64,
+					Noise: simulatedNoise, Rotation: rotation, Seed: int64(rotation), MaxLost: tc.maxLost,
+				})
+			})
+		}
+	}
+}
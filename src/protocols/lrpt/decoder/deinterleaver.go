@@ -0,0 +1,183 @@
+package decoder
+
+import (
+	"io"
+)
+
+const (
+	interleaverBranches = 36
+	interleaverDelay    = 2048
+	interleaverSize     = interleaverBranches * interleaverBranches * interleaverDelay
+	markerStride        = 80
+	markerSize          = 8
+	markerWindow        = 64
+	marker              = 0x27
+)
+
+// deinterleaver removes the synchronization markers inserted every 72 soft-bits
+// by the Meteor-M2-x transmitter and reverts its convolutional interleaver.
+// The markers are found by looking for the 8 soft-bits that stay constant
+// between consecutive strides, this way the lock doesn't depend on the phase
+// ambiguity of the stream. The value of the marker then gives the quarter turns
+// undoing the phase ambiguity, since a rotation by 90 degrees swaps the I and Q
+// soft-bits between the branches of the interleaver.
+type deinterleaver struct {
+	input    io.Reader
+	window   []byte
+	filled   int
+	start    int
+	rotation int
+	output   []byte
+	delay    []byte
+	offset   int
+	branch   int
+	skip     int
+	err      error
+}
+
+func newDeinterleaver(input io.Reader) *deinterleaver {
+	return &deinterleaver{
+		input:  input,
+		window: make([]byte, markerStride*markerWindow),
+		delay:  make([]byte, interleaverSize),
+		skip:   interleaverSize,
+	}
+}
+
+func (e *deinterleaver) Read(p []byte) (int, error) {
+	for len(e.output) == 0 {
+		if e.err != nil {
+			return 0, e.err
+		}
+		e.fill()
+	}
+
+	n := copy(p, e.output)
+	e.output = e.output[n:]
+	return n, nil
+}
+
+func (e *deinterleaver) fill() {
+	n, err := io.ReadFull(e.input, e.window[e.filled:])
+	e.filled += n
+	if err != nil {
+		e.err = err
+		if err == io.ErrUnexpectedEOF {
+			e.err = io.EOF
+		}
+	}
+
+	offset := findMarker(e.window[:e.filled])
+	strides := (e.filled - offset) / markerStride
+
+	// The quarter turns are only known when the markers start at an I soft-bit.
+	aligned := (e.start+offset)%2 == 0
+	if aligned && strides > 1 {
+		if rotation, ok := findRotation(e.window[offset:e.filled]); ok {
+			e.rotation = rotation
+		}
+	}
+
+	e.output = make([]byte, 0, strides*(markerStride-markerSize))
+	for s := 0; s < strides; s++ {
+		start := offset + s*markerStride + markerSize
+		data := e.window[start : start+markerStride-markerSize]
+		if aligned {
+			rotate(data, e.rotation)
+		}
+
+		for _, b := range data {
+			if out := e.push(b); e.skip == 0 {
+				e.output = append(e.output, out)
+			} else {
+				e.skip--
+			}
+		}
+	}
+
+	used := offset + strides*markerStride
+	e.filled = copy(e.window, e.window[used:e.filled])
+	e.start += used
+}
+
+// push feeds one soft-bit into the delay lines and returns the oldest one.
+// Branch b of the deinterleaver is delayed by (branches - b) * branches * delay
+// soft-bits, complementing the b * branches * delay of the transmitter.
+func (e *deinterleaver) push(b byte) byte {
+	out := e.delay[e.offset]
+	e.delay[(e.offset+(interleaverBranches-e.branch)*interleaverBranches*interleaverDelay)%interleaverSize] = b
+	e.offset = (e.offset + 1) % interleaverSize
+	e.branch = (e.branch + 1) % interleaverBranches
+	return out
+}
+
+// findRotation returns the quarter turns matching the soft-bits of the markers at the
+// start of each stride of the buffer with the marker value. It's false without a match.
+func findRotation(buf []byte) (int, bool) {
+	var sums [markerSize]int
+	for s := 0; s+markerStride <= len(buf); s += markerStride {
+		for k := range sums {
+			sums[k] += int(int8(buf[s+k]))
+		}
+	}
+
+	for rotation := 0; rotation < 4; rotation++ {
+		match := true
+		for k := 0; k < markerSize; k += 2 {
+			i, q := turn(sums[k], sums[k+1], rotation)
+			bitI, bitQ := marker>>uint(7-k)&1, marker>>uint(6-k)&1
+			match = match && (i < 0) == (bitI == 1) && (q < 0) == (bitQ == 1) && i != 0 && q != 0
+		}
+		if match {
+			return rotation, true
+		}
+	}
+	return 0, false
+}
+
+// rotate the pairs of I and Q soft-bits of the buffer by the quarter turns.
+func rotate(buf []byte, rotation int) {
+	if rotation == 0 {
+		return
+	}
+	for k := 0; k+1 < len(buf); k += 2 {
+		i, q := turn(int(int8(buf[k])), int(int8(buf[k+1])), rotation)
+		buf[k], buf[k+1] = clampSoftSymbol(i), clampSoftSymbol(q)
+	}
+}
+
+// turn rotates the soft-symbol counterclockwise by the quarter turns.
+func turn(i, q, rotation int) (int, int) {
+	for r := 0; r < rotation; r++ {
+		i, q = -q, i
+	}
+	return i, q
+}
+
+// findMarker returns the offset of the first marker inside the buffer.
+func findMarker(buf []byte) int {
+	strides := len(buf)/markerStride - 1
+	best, bestScore := 0, -1
+
+	for o := 0; o < markerStride; o++ {
+		score := 0
+		for k := 0; k < markerSize; k++ {
+			sum := 0
+			for s := 0; s < strides; s++ {
+				if int8(buf[s*markerStride+o+k]) < 0 {
+					sum--
+				} else {
+					sum++
+				}
+			}
+			if sum < 0 {
+				sum = -sum
+			}
+			score += sum
+		}
+		if score > bestScore {
+			best, bestScore = o, score
+		}
+	}
+	return best
+}
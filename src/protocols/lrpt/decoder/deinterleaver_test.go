@@ -0,0 +1,102 @@
+package decoder
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+	"testing/iotest"
+)
+
+// interleave returns the soft-bits of the bits after the transmitter's interleaver,
+// with a marker before every 72 of them. Branch b is delayed by b × 36 × 2048 bits,
+// so every bit comes out of the deinterleaver after the same latency. The delay lines
+// start full of random bits, like a transmitter already running, otherwise the
+// soft-bits of the delayed branches would be as constant as the markers.
+func interleave(rnd *rand.Rand, bits []byte) []byte {
+	delay := make([]byte, interleaverSize)
+	for i := range delay {
+		delay[i] = byte(rnd.Intn(2))
+	}
+	data := markerStride - markerSize
+
+	var out []byte
+	for i, b := range bits {
+		if i%data == 0 {
+			for n := markerSize - 1; n >= 0; n-- {
+				out = append(out, softBit(marker>>uint(n)&1))
+			}
+		}
+		delay[(i+i%interleaverBranches*interleaverBranches*interleaverDelay)%interleaverSize] = b
+		out = append(out, softBit(delay[i%interleaverSize]))
+	}
+	return out
+}
+
+func softBit(b byte) byte {
+	if b == 1 {
+		return 0x81 // -127
+	}
+	return 127
+}
+
+// TestDeinterleaver interleaves known bits after some soft-bits not aligned with the
+// strides, and expects them back after the marker search in all the phase ambiguities.
+func TestDeinterleaver(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	bits := make([]byte, interleaverSize*2+(markerStride-markerSize)*markerWindow*4)
+	for i := range bits {
+		bits[i] = byte(rnd.Intn(2))
+	}
+	stream := interleave(rnd, bits)
+
+	for _, tc := range []struct {
+		garbage  int
+		rotation int
+	}{{0, 0}, {37, 0}, {50, 1}, {50, 2}, {50, 3}} {
+		t.Run(fmt.Sprintf("%d/%d", tc.garbage, tc.rotation), func(t *testing.T) {
+			input := make([]byte, tc.garbage, tc.garbage+len(stream))
+			rnd.Read(input)
+			input = append(input, stream...)
+
+			// The transmitter's rotation is undone by the deinterleaver turning it back.
+			rotate(input[tc.garbage:], (4-tc.rotation)%4)
+
+			out, err := ioutil.ReadAll(newDeinterleaver(iotest.HalfReader(bytes.NewReader(input))))
+			if err != nil && err != io.EOF {
+				t.Fatal(err)
+			}
+			if len(out) < interleaverSize {
+				t.Fatalf("%d soft-bits deinterleaved, want at least %d", len(out), interleaverSize)
+			}
+			for i, b := range bits[:interleaverSize] {
+				if out[i] != softBit(b) {
+					t.Fatalf("soft-bit %d is %d, want %d", i, int8(out[i]), int8(softBit(b)))
+				}
+			}
+		})
+	}
+}
+
+func TestFindRotation(t *testing.T) {
+	strides := make([]byte, markerStride*2)
+	for s := 0; s < 2; s++ {
+		for n := 0; n < markerSize; n++ {
+			strides[s*markerStride+n] = softBit(marker >> uint(7-n) & 1)
+		}
+	}
+
+	for rotation := 0; rotation < 4; rotation++ {
+		buf := append([]byte(nil), strides...)
+		rotate(buf, rotation)
+		if got, ok := findRotation(buf); !ok || got != (4-rotation)%4 {
+			t.Errorf("rotation %d: found %d %t, want %d", rotation, got, ok, (4-rotation)%4)
+		}
+	}
+
+	if _, ok := findRotation(make([]byte, markerStride*2)); ok {
+		t.Error("rotation found without a marker")
+	}
+}
@@ -0,0 +1,72 @@
+package decoder
+
+import (
+	"io"
+)
+
+const differentialBufferSize = 8192
+
+// differentialDecoder reverts the QPSK differential encoding by taking the phase
+// difference between each soft-symbol and the previous one. The difference is
+// rotated by 45 degrees to land back on the QPSK constellation points.
+type differentialDecoder struct {
+	input  io.Reader
+	buf    []byte
+	carry  []byte
+	output []byte
+	lastI  int
+	lastQ  int
+}
+
+func newDifferentialDecoder(input io.Reader) *differentialDecoder {
+	return &differentialDecoder{
+		input: input,
+		buf:   make([]byte, differentialBufferSize),
+	}
+}
+
+func (e *differentialDecoder) Read(p []byte) (int, error) {
+	for len(e.output) == 0 {
+		n, err := e.input.Read(e.buf[len(e.carry):])
+		copy(e.buf, e.carry)
+		n += len(e.carry)
+
+		pairs := n / 2
+		e.carry = append(e.carry[:0], e.buf[pairs*2:n]...)
+		e.output = e.decode(e.buf[:pairs*2])
+
+		if err != nil && len(e.output) == 0 {
+			return 0, err
+		}
+	}
+
+	n := copy(p, e.output)
+	e.output = e.output[n:]
+	return n, nil
+}
+
+func (e *differentialDecoder) decode(dat []byte) []byte {
+	out := make([]byte, len(dat))
+	for i := 0; i < len(dat); i += 2 {
+		a, b := int(int8(dat[i])), int(int8(dat[i+1]))
+
+		re := a*e.lastI + b*e.lastQ
+		im := b*e.lastI - a*e.lastQ
+
+		out[i+0] = clampSoftSymbol((re - im) / 508)
+		out[i+1] = clampSoftSymbol((re + im) / 508)
+
+		e.lastI, e.lastQ = a, b
+	}
+	return out
+}
+
+func clampSoftSymbol(v int) byte {
+	if v > 127 {
+		v = 127
+	}
+	if v < -127 {
+		v = -127
+	}
+	return byte(int8(v))
+}
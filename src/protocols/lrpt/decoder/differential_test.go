@@ -0,0 +1,43 @@
+package decoder
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+	"testing/iotest"
+)
+
+// TestDifferentialDecoder differentially encodes known pairs of bits like the
+// transmitter, multiplying each symbol with the previous one, and expects the
+// original bits back. The first symbol is the reference of the encoder.
+func TestDifferentialDecoder(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	bits := make([]byte, 2*4096)
+	for i := range bits {
+		bits[i] = byte(rnd.Intn(2))
+	}
+
+	last := complex(1, 1)
+	encoded := []byte{127, 127}
+	for i := 0; i < len(bits); i += 2 {
+		symbol := complex(1-2*float64(bits[i]), 1-2*float64(bits[i+1]))
+		last *= symbol * complex(0.5, -0.5)
+		encoded = append(encoded, byte(int8(127*real(last))), byte(int8(127*imag(last))))
+	}
+
+	// The odd reads leave half of a soft-symbol for the next one.
+	out, err := ioutil.ReadAll(newDifferentialDecoder(iotest.OneByteReader(bytes.NewReader(encoded))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != len(encoded) {
+		t.Fatalf("%d soft-bits decoded, want %d", len(out), len(encoded))
+	}
+
+	for i, b := range bits {
+		if got := int8(out[i+2]); (got < 0) != (b == 1) || got == 0 {
+			t.Fatalf("soft-bit %d is %d, want bit %d", i, got, b)
+		}
+	}
+}
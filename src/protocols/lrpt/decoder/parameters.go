@@ -8,9 +8,24 @@ type parameters struct {
 	SyncWordSize       int
 	RsParityBlockSize  int
 	RsBlocks           int
+	SymbolRate         int
+	RRCAlpha           float64
+	Interleaved        bool
+	Differential       bool
 	SyncWords          [8]uint64
 }
 
+var lrptSyncWords = [8]uint64{
+	0xfca2b63db00d9794,
+	0x56fbd394daa4c1c2,
+	0x035d49c24ff2686b,
+	0xa9042c6b255b3e3d,
+	0xfc51793e700e6b68,
+	0xa9f7e368e558c2c1,
+	0x03ae86c18ff19497,
+	0x56081c971aa73d3e,
+}
+
 // Datalink parameters
 var datalink = map[string]parameters{
 	"LRPT": {
@@ -21,15 +36,37 @@ var datalink = map[string]parameters{
 		SyncWordSize:       4,
 		RsParityBlockSize:  (32 * 4),
 		RsBlocks:           4,
-		SyncWords: [8]uint64{
-			0xfca2b63db00d9794,
-			0x56fbd394daa4c1c2,
-			0x035d49c24ff2686b,
-			0xa9042c6b255b3e3d,
-			0xfc51793e700e6b68,
-			0xa9f7e368e558c2c1,
-			0x03ae86c18ff19497,
-			0x56081c971aa73d3e,
-		},
+		SymbolRate:         72000,
+		RRCAlpha:           0.6,
+		SyncWords:          lrptSyncWords,
+	},
+	// Meteor-M2-2 and newer in the 72k OQPSK interleaved mode.
+	"LRPT_OQPSK": {
+		FrameSize:          1024,
+		FrameBits:          (1024 * 8),
+		CodedFrameSize:     ((1024 * 8) * 2),
+		MinCorrelationBits: 46,
+		SyncWordSize:       4,
+		RsParityBlockSize:  (32 * 4),
+		RsBlocks:           4,
+		SymbolRate:         72000,
+		RRCAlpha:           0.6,
+		Interleaved:        true,
+		SyncWords:          lrptSyncWords,
+	},
+	// Meteor-M2-2 and newer in the 80k OQPSK interleaved and differential mode.
+	"LRPT_80K": {
+		FrameSize:          1024,
+		FrameBits:          (1024 * 8),
+		CodedFrameSize:     ((1024 * 8) * 2),
+		MinCorrelationBits: 46,
+		SyncWordSize:       4,
+		RsParityBlockSize:  (32 * 4),
+		RsBlocks:           4,
+		SymbolRate:         80000,
+		RRCAlpha:           0.6,
+		Interleaved:        true,
+		Differential:       true,
+		SyncWords:          lrptSyncWords,
 	},
 }
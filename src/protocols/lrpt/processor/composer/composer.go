@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"sort"
+	"weatherdump/src/geo"
 	"weatherdump/src/img"
 	"weatherdump/src/protocols/lrpt"
 	"weatherdump/src/protocols/lrpt/processor/parser"
@@ -16,6 +17,7 @@ type Composer struct {
 	ShortName        string
 	FileName         string
 	RequiredChannels []uint16
+	georeference     func(*parser.Channel) *geo.Swath
 }
 
 func (e *Composer) Register(pipeline img.Pipeline, scft lrpt.SpacecraftParameters) *Composer {
@@ -24,6 +26,20 @@ func (e *Composer) Register(pipeline img.Pipeline, scft lrpt.SpacecraftParameter
 	return e
 }
 
+// Georeference sets the function returning the swath of a processed channel.
+func (e *Composer) Georeference(georeference func(*parser.Channel) *geo.Swath) *Composer {
+	e.georeference = georeference
+	return e
+}
+
+// getSwath returns the swath of the channel, or nil without the function.
+func (e Composer) getSwath(ch *parser.Channel) *geo.Swath {
+	if e.georeference == nil {
+		return nil
+	}
+	return e.georeference(ch)
+}
+
 func (e Composer) Render(ch parser.List, outputFolder string) string {
 	ch01 := ch[e.RequiredChannels[0]]
 	ch02 := ch[e.RequiredChannels[1]]
@@ -63,11 +79,14 @@ func (e Composer) Render(ch parser.List, outputFolder string) string {
 		finalBuf[p] = 0xFF
 	}
 
-	// Compose images and fill buffer.
+	// Compose images and fill buffer. The composite is oriented
+	// after it's composed, with its georeference.
 	var buf []byte
 	e.pipeline.Target(img.NewGray(&buf, w, h))
 	e.pipeline.AddException("Invert", false)
 	e.pipeline.AddException("Equalize", e.Equalize)
+	e.pipeline.AddException("Flop", false)
+	e.pipeline.AddException("Rotate", false)
 
 	ch01.Export(&buf, e.scft)
 	e.pipeline.Process()
@@ -88,8 +107,8 @@ func (e Composer) Render(ch parser.List, outputFolder string) string {
 	}
 
 	// Render and save the true-color image.
-	e.pipeline.Target(img.NewRGBA(&finalBuf, w, h)).Export(outputName, 100)
 	e.pipeline.ResetExceptions()
+	e.pipeline.Target(img.NewRGBA(&finalBuf, w, h).Georeference(e.getSwath(ch01))).Process().Export(outputName, 100)
 	return outputName
 }
 
@@ -2,13 +2,21 @@ package parser
 
 import (
 	"fmt"
+	"time"
 	"weatherdump/src/ccsds/frames"
+	"weatherdump/src/geo"
 	"weatherdump/src/protocols/lrpt"
 	"weatherdump/src/protocols/lrpt/processor/parser/segment"
 )
 
 const maxFrameCount = 8192 * 3
 
+// linePeriod of the MSU-MR scans, used when the channel has a single valid MCU row.
+const linePeriod = 154 * time.Millisecond
+
+// scanHalfAngle of the MSU-MR swath in degrees, sampled uniformly by the columns.
+const scanHalfAngle = 55.37
+
 // Channel struct.
 type Channel struct {
 	APID         uint16
@@ -26,10 +34,12 @@ type Channel struct {
 	FirstSegment uint32
 	LastSegment  uint32
 
-	segments map[uint32]*segment.Data
-	rollover uint32
-	lastSeq  uint32
-	offset   uint32
+	segments  map[uint32]*segment.Data
+	rollover  uint32
+	lastSeq   uint32
+	offset    uint32
+	startLine uint32
+	endLine   uint32
 }
 
 // NewChannel instance.
@@ -66,6 +76,23 @@ func (e Channel) GetTime() (int, int) {
 	return int(e.StartTime.GetMilliseconds()), int(e.EndTime.GetMilliseconds())
 }
 
+// GetLineTimes returns the time of the first line and the interval between the lines.
+// The reference time gives the date of the pass. Should be called after the Process().
+func (e Channel) GetLineTimes(reference time.Time) (time.Time, time.Duration) {
+	start := e.StartTime.GetDate(reference)
+	interval := linePeriod
+	if e.endLine > e.startLine {
+		interval = e.EndTime.GetDate(reference).Sub(start) / time.Duration(e.endLine-e.startLine)
+	}
+	return start.Add(-time.Duration(e.startLine) * interval), interval
+}
+
+// GetScanModel returns the scan angle of each column of the channel.
+// Should be called after the Process().
+func (e Channel) GetScanModel() geo.ScanModel {
+	return geo.NewScanModel(scanHalfAngle, int(e.Width))
+}
+
 // Process corrects the current channel metadata.
 // Should be called every time SetBounds() is called.
 func (e *Channel) Process(scft lrpt.SpacecraftParameters) {
@@ -81,6 +108,7 @@ func (e *Channel) Process(scft lrpt.SpacecraftParameters) {
 	for i := e.FirstSegment; i <= e.LastSegment; i++ {
 		if e.segments[i].GetDate().GetMilliseconds() != uint32(0) {
 			e.StartTime = e.segments[i].GetDate()
+			e.startLine = (i - e.FirstSegment) / 14 * 8
 			break
 		}
 	}
@@ -88,6 +116,7 @@ func (e *Channel) Process(scft lrpt.SpacecraftParameters) {
 	for i := e.LastSegment; i >= e.FirstSegment; i-- {
 		if e.segments[i].GetDate().GetMilliseconds() != uint32(0) {
 			e.EndTime = e.segments[i].GetDate()
+			e.endLine = (i - e.FirstSegment) / 14 * 8
 			break
 		}
 	}
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"encoding/json"
+	"time"
 	"weatherdump/src/protocols/helpers"
 )
 
@@ -15,6 +16,23 @@ func New() List {
 // List datatype of the LRPT protocol.
 type List map[uint16]*Channel
 
+// GetPassTime returns the time of the first valid segment received by any channel.
+// The reference time gives the date of the pass.
+func (e List) GetPassTime(reference time.Time) (time.Time, bool) {
+	var first time.Time
+	for _, ch := range e {
+		for _, s := range ch.segments {
+			if s == nil || s.GetDate().GetMilliseconds() == 0 {
+				continue
+			}
+			if t := s.GetDate().GetDate(reference); first.IsZero() || t.Before(first) {
+				first = t
+			}
+		}
+	}
+	return first, !first.IsZero()
+}
+
 // Channels metadata available for the LRPT protocol.
 var Channels = List{
 	64: {APID: 64, ChannelName: "CH64", BlockDim: 8, Invert: false, FinalWidth: 1568},
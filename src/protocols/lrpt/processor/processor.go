@@ -2,10 +2,13 @@ package processor
 
 import (
 	"fmt"
-	"io/ioutil"
+	"log"
+	"os"
 	"path/filepath"
+	"time"
 	"weatherdump/src/ccsds"
 	"weatherdump/src/ccsds/frames"
+	"weatherdump/src/geo"
 	"weatherdump/src/handlers/interfaces"
 	"weatherdump/src/img"
 	"weatherdump/src/protocols/helpers"
@@ -19,20 +22,24 @@ import (
 
 var upgrader = websocket.Upgrader{}
 
-const frameSize = 892
-
 type Worker struct {
-	ccsds    *ccsds.Worker
-	scid     uint8
-	manifest helpers.ProcessingManifest
-	channels parser.List
+	demux     *ccsds.Demultiplexer
+	dumper    *ccsds.PacketDumper
+	scid      uint8
+	manifest  helpers.ProcessingManifest
+	channels  parser.List
+	tle       string
+	satellite *geo.Satellite
+	reference time.Time
+	passDate  time.Time
 }
 
 func NewProcessor(uuid string, manifest *helpers.ProcessingManifest) interfaces.Processor {
 	e := Worker{
-		ccsds:    ccsds.New(),
+		demux:    ccsds.NewDemultiplexer(ccsds.Version["LRPT"]),
 		channels: parser.New(),
 	}
+	e.demux.SubscribeRange(64, 69, e.parsePacket)
 
 	if manifest == nil {
 		e.manifest = e.GetProductsManifest()
@@ -49,33 +56,173 @@ func (e *Worker) Work(inputFile string) {
 	color.Yellow("[PRC] WARNING! This processor is currently in ALPHA development state.")
 	scidStat := [256]int{}
 
-	file, _ := ioutil.ReadFile(inputFile)
-	for i := len(file); i > 0; i -= frameSize {
-		f := frames.NewTransferFrame(file[(len(file) - i):])
-		p := frames.NewMultiplexingFrame(ccsds.Version["LRPT"], f.GetMPDU())
+	file, err := os.Open(inputFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
 
-		if !f.IsReplay() && p.IsValid() {
+	// The segments only carry the time of the day, without a pass
+	// date the date is the one of the file modification.
+	if !e.passDate.IsZero() {
+		e.reference = e.passDate.Add(12 * time.Hour)
+	} else {
+		e.reference = time.Now()
+		if fi, err := file.Stat(); err == nil {
+			e.reference = fi.ModTime()
+		}
+	}
+
+	err = ccsds.ReadFrames(file, func(f *frames.TransferFrame) {
+		if !f.IsReplay() {
 			scidStat[f.GetSCID()]++
-			switch f.GetVCID() {
-			case 5:
-				e.ccsds.ParseMPDU(*p) // VCID 5 Parser
-			}
+			e.demux.ParseFrame(f)
 		}
+	})
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	for _, packet := range e.ccsds.GetSpacePackets() {
-		if packet.GetAPID() >= 64 && packet.GetAPID() <= 69 {
-			e.channels[packet.GetAPID()].Parse(packet)
+	if e.dumper != nil {
+		if err := e.dumper.Close(); err != nil {
+			log.Fatal(err)
 		}
+		e.dumper.PrintStatistics()
 	}
 
 	e.scid = uint8(helpers.MaxIntSlice(scidStat[:]))
-	fmt.Printf("[PRC] Decoded %d packets from VCID 16.\n", len(e.ccsds.GetSpacePackets()))
+	e.demux.PrintStatistics()
+}
+
+// DumpPackets saves the raw packets of the APIDs to the output path while the file is processed.
+func (e *Worker) DumpPackets(outputPath string, apids []uint16) {
+	e.dumper = ccsds.NewPacketDumper(outputPath)
+	e.dumper.Subscribe(e.demux, apids)
+}
+
+// SetTLE propagates the orbit of the spacecraft with its element set inside the
+// TLE file, saving the sub-satellite track and georeferencing each product.
+func (e *Worker) SetTLE(path string) {
+	e.tle = path
+}
+
+// SetPassDate sets the UTC date of the pass, the segments only carry the time of the day.
+func (e *Worker) SetPassDate(date time.Time) {
+	e.passDate = date.UTC()
+}
+
+// navigate propagates the orbit of the TLE file and rotates the products of northbound passes.
+func (e *Worker) navigate(wf *img.Pipeline) {
+	passTime, ok := e.channels.GetPassTime(e.reference)
+	if ok && e.tle != "" {
+		if e.passDate.IsZero() {
+			fmt.Printf("[PRC] The pass is dated %s from the modification time of the file, set it with the pass date otherwise.\n",
+				passTime.Format("2006-01-02"))
+		}
+		e.satellite = helpers.LoadOrbit(e.tle, lrpt.Spacecrafts[e.scid].Catalog, passTime)
+	}
+
+	if e.satellite != nil {
+		if sv, err := e.satellite.Propagate(passTime); err == nil {
+			helpers.Orient(wf, sv.GetDirection())
+		}
+	} else if wf.Has("AutoRotate") {
+		fmt.Println("[PRC] The pass direction is unknown without a TLE file, the products won't be rotated.")
+	}
+}
+
+// exportTrack saves the sub-satellite track of the channel lines.
+func (e *Worker) exportTrack(ch *parser.Channel, outputName string) {
+	if e.satellite == nil {
+		return
+	}
+
+	start, interval := ch.GetLineTimes(e.reference)
+	_, h := ch.GetDimensions()
+	helpers.SaveTrack(e.satellite, start, interval, h, fmt.Sprintf("%s_TRACK.csv", outputName))
+}
+
+// georeference returns the swath locating the pixels of the channel image, or nil
+// without the orbit.
+func (e *Worker) georeference(ch *parser.Channel) *geo.Swath {
+	if e.satellite == nil {
+		return nil
+	}
+
+	start, interval := ch.GetLineTimes(e.reference)
+	_, h := ch.GetDimensions()
+	swath, err := geo.NewSwath(e.satellite, start, interval, h, ch.GetScanModel())
+	if err != nil {
+		fmt.Printf("[PRC] Channel %s wasn't georeferenced: %s.\n", ch.ChannelName, err)
+		return nil
+	}
+	return &swath
+}
+
+// Probe returns the fraction of valid transfer frames in the head of the file.
+func (e Worker) Probe(inputFile string) float64 {
+	file, err := helpers.OpenHead(inputFile)
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+
+	confidence, _ := ccsds.ProbeFrames(file, lrpt.IsValidFrame)
+	return confidence
+}
+
+// Inspect summarizes the frames and packets of all APIDs of the file.
+// Each imaging packet carries 14 MCUs with 8 lines, a line has 14 packets.
+func (e Worker) Inspect(inputFile string) (*helpers.Summary, error) {
+	summary := helpers.NewSummary("lrpt")
+	demux := ccsds.NewDemultiplexer(ccsds.Version["LRPT"])
+
+	demux.SubscribeRange(0, ccsds.IdleAPID-1, func(packet frames.SpacePacketFrame) {
+		apid := packet.GetAPID()
+		summary.AddPacket(packet)
+
+		if packet.HasSecondaryHeader() && len(packet.GetData()) >= 8 {
+			var t lrpt.Time
+			t.FromBinary(packet.GetData())
+			if t.IsValid() {
+				summary.AddTime(apid, t.GetClock())
+			}
+		}
+
+		if ch, ok := e.channels[apid]; ok {
+			s := summary.APID(apid)
+			s.Name = ch.ChannelName
+			s.ScanLines = s.Packets / 14 * 8
+		}
+	})
+
+	file, err := os.Open(inputFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	err = ccsds.ReadFrames(file, func(f *frames.TransferFrame) {
+		summary.AddFrame(f.GetSCID(), lrpt.Spacecrafts[f.GetSCID()].FullName)
+		demux.ParseFrame(f)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	summary.Finish(demux.GetStatistics())
+	return summary, nil
+}
+
+// parsePacket forwards each packet to its channel as soon as it's assembled.
+func (e *Worker) parsePacket(packet frames.SpacePacketFrame) {
+	e.channels[packet.GetAPID()].Parse(packet)
 }
 
 func (e *Worker) Export(outputPath string, wf img.Pipeline) {
 	fmt.Printf("[PRC] Exporting BISMW science products.\n")
 	e.manifest.Start()
+	e.navigate(&wf)
 
 	re := helpers.CaptureOutput(func() {
 		for _, apid := range e.manifest.Parser.Parse() {
@@ -91,8 +238,9 @@ func (e *Worker) Export(outputPath string, wf img.Pipeline) {
 				outputName, _ := filepath.Abs(fmt.Sprintf("%s/%s", outputPath, ch.FileName))
 
 				wf.AddException("Invert", ch.Invert)
-				wf.Target(img.NewGray(&buf, w, h)).Process().Export(outputName, 100)
+				wf.Target(img.NewGray(&buf, w, h).Georeference(e.georeference(ch))).Process().Export(outputName, 100)
 				wf.ResetExceptions()
+				e.exportTrack(ch, outputName)
 
 				e.manifest.Parser[apid].FileName(outputName)
 			}
@@ -102,14 +250,14 @@ func (e *Worker) Export(outputPath string, wf img.Pipeline) {
 
 		for _, code := range e.manifest.Composer.Parse() {
 			c := composer.Composers[code]
-			outputName := c.Register(wf, lrpt.Spacecrafts[e.scid]).Render(e.channels, outputPath)
+			outputName := c.Register(wf, lrpt.Spacecrafts[e.scid]).Georeference(e.georeference).Render(e.channels, outputPath)
 			e.manifest.Composer[code].FileName(outputName)
 			e.manifest.ComposerCompleted(code)
 		}
 	})
 
 	e.channels = make(parser.List)
-	e.ccsds = nil
+	e.demux = nil
 
 	e.manifest.Stop(re)
 	color.Green("[PRC] Done! All products and components were saved.")
@@ -1,9 +1,12 @@
 package lrpt
 
+import "weatherdump/src/ccsds/frames"
+
 type SpacecraftParameters struct {
 	Filename   string
 	FullName   string
 	SignalName string
+	Catalog    int
 }
 
 var Spacecrafts = map[uint8]SpacecraftParameters{
@@ -11,5 +14,12 @@ var Spacecrafts = map[uint8]SpacecraftParameters{
 		Filename:   "METEOR_MN2",
 		FullName:   "Meteor",
 		SignalName: "LRPT",
+		Catalog:    40069,
 	},
 }
+
+// IsValidFrame checks if the transfer frame is a real-time frame of a known spacecraft.
+func IsValidFrame(frame *frames.TransferFrame) bool {
+	_, ok := Spacecrafts[frame.GetSCID()]
+	return ok && frame.GetVersion() == 0x01 && !frame.IsReplay()
+}
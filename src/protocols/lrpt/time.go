@@ -3,6 +3,7 @@ package lrpt
 import (
 	"encoding/binary"
 	"fmt"
+	"time"
 )
 
 type Time struct {
@@ -37,6 +38,30 @@ func (e Time) GetZuluSafe() string {
 	return string(e.GetMilliseconds())
 }
 
+// GetClock returns the time of the day formatted as hh:mm:ss.sss.
+func (e Time) GetClock() string {
+	return time.Unix(0, int64(e.milliseconds)*int64(time.Millisecond)).UTC().Format("15:04:05.000")
+}
+
 func (e Time) GetMilliseconds() uint32 {
 	return e.milliseconds
 }
+
+// moscowOffset of the onboard clock, which keeps the Moscow time (UTC+3).
+const moscowOffset = 3 * time.Hour
+
+// GetDate returns the UTC time of the segment. The segments only carry the time of
+// the day, so the date comes from a reference time close to the pass, like the time
+// the recording was saved. The closest time of the day to the reference is returned.
+func (e Time) GetDate(reference time.Time) time.Time {
+	reference = reference.UTC()
+	day := time.Date(reference.Year(), reference.Month(), reference.Day(), 0, 0, 0, 0, time.UTC)
+	date := day.Add(time.Duration(e.milliseconds)*time.Millisecond - moscowOffset)
+
+	if date.Sub(reference) > 12*time.Hour {
+		date = date.Add(-24 * time.Hour)
+	} else if reference.Sub(date) > 12*time.Hour {
+		date = date.Add(24 * time.Hour)
+	}
+	return date
+}
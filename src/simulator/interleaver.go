@@ -0,0 +1,59 @@
+package simulator
+
+// Convolutional interleaver of the Meteor-M2-x OQPSK downlinks, the transmitter side
+// of the deinterleaver of the LRPT decoder: 36 branches, each delayed by 36 × 2048 bits
+// more than the previous one, and an 8-bit synchronization marker before every 72 bits.
+const (
+	interleaverBranches = 36
+	interleaverDelay    = 2048
+	interleaverSize     = interleaverBranches * interleaverBranches * interleaverDelay
+	markerData          = 72
+	marker              = 0x27 // gives the decoder the phase ambiguity of the stream
+	markerSize          = 8
+)
+
+type interleaver struct {
+	delay   []byte
+	offset  int
+	branch  int
+	pending []byte
+}
+
+func newInterleaver() *interleaver {
+	return &interleaver{delay: make([]byte, interleaverSize)}
+}
+
+// interleave returns the strides of interleaved bits, one bit per byte, each one
+// after its marker. Bits not filling a whole stride are kept for the next call.
+func (e *interleaver) interleave(bits []byte) []byte {
+	for _, b := range bits {
+		e.pending = append(e.pending, e.push(b))
+	}
+
+	strides := len(e.pending) / markerData
+	out := make([]byte, 0, strides*(markerSize+markerData))
+	for s := 0; s < strides; s++ {
+		for n := markerSize - 1; n >= 0; n-- {
+			out = append(out, marker>>uint(n)&1)
+		}
+		out = append(out, e.pending[s*markerData:(s+1)*markerData]...)
+	}
+
+	e.pending = append(e.pending[:0], e.pending[strides*markerData:]...)
+	return out
+}
+
+// flush returns the strides with the bits still inside the delay lines.
+func (e *interleaver) flush() []byte {
+	return e.interleave(make([]byte, interleaverSize+markerData))
+}
+
+// push feeds one bit into the delay lines and returns the oldest one. Branch b
+// is delayed by b * branches * delay bits, the first branch isn't delayed.
+func (e *interleaver) push(b byte) byte {
+	e.delay[(e.offset+e.branch*interleaverBranches*interleaverDelay)%interleaverSize] = b
+	out := e.delay[e.offset]
+	e.offset = (e.offset + 1) % interleaverSize
+	e.branch = (e.branch + 1) % interleaverBranches
+	return out
+}
@@ -0,0 +1,85 @@
+package simulator
+
+import (
+	"encoding/binary"
+	"math/rand"
+)
+
+// multiplexer splits a continuous stream of space packets into the packet zone
+// of consecutive transfer frames, keeping track of the first header pointer.
+type multiplexer struct {
+	params    parameters
+	rand      *rand.Rand
+	stream    []byte
+	starts    []int
+	apid      int
+	sequence  map[uint16]uint16
+	vcCounter uint32
+}
+
+func newMultiplexer(params parameters, seed int64) *multiplexer {
+	return &multiplexer{
+		params:   params,
+		rand:     rand.New(rand.NewSource(seed)),
+		sequence: map[uint16]uint16{},
+	}
+}
+
+// nextPacket appends a new space packet to the stream. The APIDs are used in
+// turns and the data contains a pattern derived from the sequence count.
+func (e *multiplexer) nextPacket() {
+	apid := e.params.APIDs[e.apid%len(e.params.APIDs)]
+	e.apid++
+
+	length := minPacketData + e.rand.Intn(maxPacketData-minPacketData)
+	packet := make([]byte, 6+length)
+
+	binary.BigEndian.PutUint16(packet[0:], 0x0800|apid&0x7FF)
+	binary.BigEndian.PutUint16(packet[2:], 0xC000|e.sequence[apid]&0x3FFF)
+	binary.BigEndian.PutUint16(packet[4:], uint16(length-1))
+
+	for i := range packet[6:] {
+		packet[6+i] = byte(int(e.sequence[apid]) + i)
+	}
+
+	e.sequence[apid] = (e.sequence[apid] + 1) & 0x3FFF
+	e.starts = append(e.starts, len(e.stream))
+	e.stream = append(e.stream, packet...)
+}
+
+// nextFrame returns the next transfer frame filled with packets.
+func (e *multiplexer) nextFrame() []byte {
+	frame := make([]byte, frameSize)
+
+	frame[0] = 0x40 | e.params.SCID>>2
+	frame[1] = e.params.SCID<<6 | e.params.VCID&0x3F
+	binary.BigEndian.PutUint32(frame[2:], e.vcCounter<<8)
+	if e.params.Replay {
+		frame[5] = 0x80
+	}
+	e.vcCounter = (e.vcCounter + 1) & 0xFFFFFF
+
+	mpdu := frame[frameHeaderSize+e.params.InsertZone:]
+	zone := mpdu[2:]
+
+	for len(e.stream) < len(zone) {
+		e.nextPacket()
+	}
+
+	fhp := noNewPacket
+	for len(e.starts) > 0 && e.starts[0] < len(zone) {
+		if fhp == noNewPacket {
+			fhp = e.starts[0]
+		}
+		e.starts = e.starts[1:]
+	}
+	for i := range e.starts {
+		e.starts[i] -= len(zone)
+	}
+
+	binary.BigEndian.PutUint16(mpdu[0:], uint16(fhp))
+	copy(zone, e.stream)
+	e.stream = e.stream[len(zone):]
+
+	return frame
+}
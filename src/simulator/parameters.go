@@ -0,0 +1,58 @@
+package simulator
+
+const (
+	frameSize       = 892
+	frameHeaderSize = 6
+	cadusPerFrame   = 4
+	syncWordSize    = 4
+	noNewPacket     = 2047
+	maxPacketData   = 1024
+	minPacketData   = 64
+	softAmplitude   = 100
+	iqAmplitude     = 0.5
+	iqSamples       = 4
+	rrcSpan         = 31
+)
+
+var syncWord = []byte{0x1A, 0xCF, 0xFC, 0x1D}
+
+type parameters struct {
+	SCID       uint8
+	VCID       uint8
+	Replay     bool
+	InsertZone int
+	APIDs      []uint16
+	DualBasis  bool
+	NRZM       bool
+	SymbolRate float64
+	RRCAlpha   float64
+	Formats    []string
+}
+
+// Datalink parameters
+var datalink = map[string]parameters{
+	"lrpt": {
+		SCID:       0,
+		VCID:       5,
+		Replay:     false,
+		InsertZone: 2,
+		APIDs:      []uint16{64, 65, 66, 67, 68, 69},
+		DualBasis:  false,
+		NRZM:       false,
+		SymbolRate: 72000,
+		RRCAlpha:   0.6,
+		Formats:    []string{"soft", "oqpsk", "interleaved80k", "iq"},
+	},
+	"hrd": {
+		SCID:       159,
+		VCID:       16,
+		Replay:     true,
+		InsertZone: 0,
+		APIDs:      []uint16{800, 801, 802, 803, 804, 805, 806, 807, 808, 809, 810, 811, 812, 813, 814, 815, 816, 817, 818, 819, 820, 821, 822, 823},
+		DualBasis:  true,
+		NRZM:       true,
+		SymbolRate: 15000000,
+		RRCAlpha:   0.5,
+		Formats:    []string{"soft", "iq"},
+	},
+}
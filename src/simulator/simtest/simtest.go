@@ -0,0 +1,101 @@
+// Package simtest decodes simulated files in the tests of the decoders and compares
+// the decoded transfer frames with the ones simulated.
+package simtest
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"weatherdump/src/handlers/interfaces"
+	"weatherdump/src/simulator"
+)
+
+// frameSize of the transfer frames written by the decoders, without the sync word and parity.
+const frameSize = 892
+
+// Case of a simulated file.
+type Case struct {
+	Datalink string
+	Format   string
+	Frames   int
+	Noise    float64
+	Rotation int
+	Seed     int64
+
+	// MaxLost frames at the start of the file, while the demodulator locks.
+	MaxLost int
+}
+
+// RoundTrip simulates the case, decodes the file with the decoder and expects the
+// simulated transfer frames back byte for byte, in order and without gaps.
+func RoundTrip(t *testing.T, decoder interfaces.Decoder, c Case) {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", c.Datalink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "simulated.bin")
+	output := filepath.Join(dir, "decoded.bin")
+
+	sim, err := simulator.New(c.Datalink, c.Seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.SetFormat(c.Format); err != nil {
+		t.Fatal(err)
+	}
+
+	var sent bytes.Buffer
+	sim.Noise = c.Noise
+	sim.Rotation = c.Rotation
+	sim.Frames = &sent
+	if err := sim.Work("", input, c.Frames); err != nil {
+		t.Fatal(err)
+	}
+
+	if demodulator, ok := decoder.(interfaces.Demodulator); ok {
+		demodulator.SetSampleRate(sim.SampleRate())
+	}
+	if reporter, ok := decoder.(interfaces.Reporter); ok {
+		reporter.SetOutput(ioutil.Discard)
+	}
+	decoder.Work(input, output, nil)
+
+	decoded, err := ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Compare(sent.Bytes(), decoded, c.MaxLost); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Compare expects the decoded frames to be the sent ones, except up to the
+// maximum of lost frames at the start.
+func Compare(sent, decoded []byte, maxLost int) error {
+	if len(decoded)%frameSize != 0 {
+		return fmt.Errorf("decoded file has %d bytes, not a multiple of the frame size", len(decoded))
+	}
+
+	total, received := len(sent)/frameSize, len(decoded)/frameSize
+	if received == 0 || received < total-maxLost {
+		return fmt.Errorf("%d of %d frames decoded, expected at least %d", received, total, total-maxLost)
+	}
+
+	first := total - received
+	for i := 0; i < received; i++ {
+		got := decoded[i*frameSize : (i+1)*frameSize]
+		want := sent[(first+i)*frameSize : (first+i+1)*frameSize]
+		if !bytes.Equal(got, want) {
+			return fmt.Errorf("decoded frame %d differs from the simulated frame %d", i, first+i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+package simulator
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"weatherdump/src/ccsds/coding"
+	"weatherdump/src/dsp"
+
+	"github.com/fatih/color"
+)
+
+// Simulator generates soft-symbol or baseband IQ files with known content. It runs
+// the same chain of the satellite in reverse order of the decoders: transfer frames
+// are Reed-Solomon encoded, randomized, prefixed by the sync word and convolutionally
+// encoded before being interleaved, differentially encoded and modulated, as the
+// format requires.
+type Simulator struct {
+	params       parameters
+	mux          *multiplexer
+	conv         *coding.ConvolutionalEncoder
+	nrzm         *coding.NRZMEncoder
+	rand         *rand.Rand
+	interleaver  *interleaver
+	differential bool
+	lastSymbol   complex128
+	shaper       *dsp.FIR
+	Noise        float64
+	Rotation     int
+	SwapIQ       bool
+
+	// Frames receives a copy of each transfer frame encoded, when set.
+	Frames io.Writer
+}
+
+// New creates a simulator for the datalink (lrpt or hrd).
+// The seed makes the packets and noise reproducible.
+func New(id string, seed int64) (*Simulator, error) {
+	params, ok := datalink[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown datalink %s", id)
+	}
+
+	return &Simulator{
+		params:     params,
+		mux:        newMultiplexer(params, seed),
+		conv:       coding.NewConvolutionalEncoder(),
+		nrzm:       coding.NewNRZMEncoder(),
+		rand:       rand.New(rand.NewSource(seed)),
+		lastSymbol: complex(1, 1),
+	}, nil
+}
+
+// SetFormat of the output file, named after the decoder reading it: soft-symbols
+// (soft), interleaved soft-symbols (oqpsk), interleaved and differentially encoded
+// soft-symbols (interleaved80k) or raw cf32 baseband IQ samples (iq).
+func (e *Simulator) SetFormat(format string) error {
+	supported := false
+	for _, f := range e.params.Formats {
+		supported = supported || f == format
+	}
+	if !supported {
+		return fmt.Errorf("the datalink doesn't support the %s format", format)
+	}
+
+	e.interleaver, e.differential, e.shaper = nil, false, nil
+	switch format {
+	case "oqpsk":
+		e.interleaver = newInterleaver()
+	case "interleaved80k":
+		e.interleaver = newInterleaver()
+		e.differential = true
+	case "iq":
+		e.shaper = dsp.NewRRC(iqSamples, 1, e.params.RRCAlpha, rrcSpan)
+	}
+	return nil
+}
+
+// SampleRate returns the sample rate in Hz of the IQ format.
+func (e Simulator) SampleRate() float64 {
+	return e.params.SymbolRate * iqSamples
+}
+
+// Work writes the number of frames informed into the output file. When the input
+// path isn't empty, transfer frames are read from it (e.g. a decoded file) instead
+// of being generated, and a zero number of frames means the whole input file.
+func (e *Simulator) Work(inputPath, outputPath string, frames int) error {
+	var input io.Reader
+	if inputPath == "" && frames <= 0 {
+		return fmt.Errorf("invalid number of frames %d", frames)
+	}
+	if inputPath != "" {
+		file, err := os.Open(inputPath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		input = file
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	output := bufio.NewWriter(file)
+	defer output.Flush()
+
+	frame := make([]byte, frameSize)
+	for i := 0; frames <= 0 || i < frames; i++ {
+		if input != nil {
+			if _, err := io.ReadFull(input, frame); err != nil {
+				break
+			}
+		} else {
+			frame = e.mux.nextFrame()
+		}
+
+		if e.Frames != nil {
+			if _, err := e.Frames.Write(frame); err != nil {
+				return err
+			}
+		}
+
+		if _, err := output.Write(e.encode(frame)); err != nil {
+			return err
+		}
+	}
+
+	// The last frames are still inside the delay lines and the filter.
+	if e.interleaver != nil {
+		if _, err := output.Write(e.modulate(e.interleaver.flush())); err != nil {
+			return err
+		}
+	}
+	if e.shaper != nil {
+		if _, err := output.Write(e.shape(make([]byte, rrcSpan*2))); err != nil {
+			return err
+		}
+	}
+
+	color.Green("[SIM] Simulated file saved at %s.\n", outputPath)
+	return nil
+}
+
+// encode a single transfer frame into the soft-symbols of the channel access data unit.
+func (e *Simulator) encode(frame []byte) []byte {
+	cadu := make([]byte, syncWordSize+coding.RSBlockSize*cadusPerFrame)
+	copy(cadu, syncWord)
+
+	codeblock := cadu[syncWordSize:]
+	block := make([]byte, coding.RSBlockSize)
+
+	for i := 0; i < cadusPerFrame; i++ {
+		for j := 0; j < coding.RSDataSize; j++ {
+			block[j] = frame[j*cadusPerFrame+i]
+		}
+
+		if e.params.DualBasis {
+			coding.RSEncodeDualBasis(block)
+		} else {
+			coding.RSEncode(block)
+		}
+
+		coding.RSInterleave(block, codeblock, i, cadusPerFrame)
+	}
+
+	coding.Randomize(codeblock)
+
+	if e.params.NRZM {
+		e.nrzm.Encode(cadu)
+	}
+
+	bits := unpack(e.conv.Encode(cadu))
+	if e.interleaver != nil {
+		bits = e.interleaver.interleave(bits)
+	}
+	return e.modulate(bits)
+}
+
+// unpack returns the bits of the bytes, MSB first, one bit per byte.
+func unpack(dat []byte) []byte {
+	bits := make([]byte, len(dat)*8)
+	for i := range bits {
+		bits[i] = dat[i/8] >> uint(7-i%8) & 1
+	}
+	return bits
+}
+
+// modulate converts each pair of bits into the I and Q soft-symbols, adding the
+// channel impairments. The differential encoding rotates each symbol from the
+// previous one by the phase of the bits, 45 degrees back. The IQ format shapes
+// the soft-symbols into baseband samples.
+func (e *Simulator) modulate(bits []byte) []byte {
+	out := make([]byte, len(bits)&^1)
+
+	for i := 0; i < len(out); i += 2 {
+		symbol := complex(1-2*float64(bits[i]), 1-2*float64(bits[i+1]))
+		if e.differential {
+			e.lastSymbol *= symbol * complex(0.5, -0.5)
+			symbol = e.lastSymbol
+		}
+
+		I := softAmplitude*real(symbol) + e.rand.NormFloat64()*e.Noise*softAmplitude
+		Q := softAmplitude*imag(symbol) + e.rand.NormFloat64()*e.Noise*softAmplitude
+		for r := 0; r < (e.Rotation/90)%4; r++ {
+			I, Q = -Q, I
+		}
+		if e.SwapIQ {
+			I, Q = Q, I
+		}
+
+		out[i+0] = clamp(I)
+		out[i+1] = clamp(Q)
+	}
+
+	if e.shaper != nil {
+		return e.shape(out)
+	}
+	return out
+}
+
+// shape returns the little-endian float32 IQ samples of the soft-symbols, upsampled
+// and filtered by the root-raised-cosine filter of the datalink.
+func (e *Simulator) shape(symbols []byte) []byte {
+	samples := make([]complex64, len(symbols)/2*iqSamples)
+	for i := 0; i < len(symbols)/2; i++ {
+		I := float32(int8(symbols[i*2])) / softAmplitude
+		Q := float32(int8(symbols[i*2+1])) / softAmplitude
+		samples[i*iqSamples] = complex(I, Q) * iqAmplitude * iqSamples
+	}
+
+	samples = e.shaper.Work(samples)
+	out := make([]byte, len(samples)*8)
+	for i, s := range samples {
+		binary.LittleEndian.PutUint32(out[i*8:], math.Float32bits(real(s)))
+		binary.LittleEndian.PutUint32(out[i*8+4:], math.Float32bits(imag(s)))
+	}
+	return out
+}
+
+func clamp(v float64) byte {
+	if v > 127 {
+		v = 127
+	}
+	if v < -127 {
+		v = -127
+	}
+	return byte(int8(v))
+}